@@ -2,18 +2,28 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	nethttp "net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/sessions"
 	"github.com/mbvlabs/grafto/config"
 	"github.com/mbvlabs/grafto/http"
 	"github.com/mbvlabs/grafto/http/handlers"
 	mw "github.com/mbvlabs/grafto/http/middleware"
+	"github.com/mbvlabs/grafto/migrations"
 	"github.com/mbvlabs/grafto/models"
 	awsses "github.com/mbvlabs/grafto/pkg/aws_ses"
 	"github.com/mbvlabs/grafto/pkg/telemetry"
 	"github.com/mbvlabs/grafto/psql"
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/psql/migrate"
 	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/queue/workers"
 	"github.com/mbvlabs/grafto/routes"
 	"github.com/mbvlabs/grafto/services"
 )
@@ -21,6 +31,15 @@ import (
 var appRelease string
 
 func main() {
+	// shared by the server and the embedded worker, so both stop gracefully
+	// on the signals a container is stopped with
+	ctx, stop := signal.NotifyContext(
+		context.Background(),
+		syscall.SIGINT,
+		syscall.SIGTERM,
+	)
+	defer stop()
+
 	cfg := config.NewConfig()
 
 	otel := telemetry.NewOtel(cfg)
@@ -37,16 +56,45 @@ func main() {
 		defer client.Stop()
 	}
 
+	queryTracer := psql.NewQueryTracer(otel.NewTracer("psql/tracer"), cfg.SlowQueryThreshold)
+
 	conn, err := psql.CreatePooledConnection(
 		context.Background(),
 		cfg.GetDatabaseURL(),
+		psql.WithPoolSettings(cfg.Database),
+		psql.WithQueryTracer(queryTracer),
+		psql.WithPoolMetrics("primary"),
 	)
 	if err != nil {
 		panic(err)
 	}
 
-	psql := psql.NewPostgres(conn)
-	riverClient := queue.NewClient(conn, queue.WithLogger(slog.Default()))
+	var postgresOpts []psql.PostgresOpts
+	if cfg.ReplicaURL != "" {
+		replicaConn, err := psql.CreatePooledConnection(
+			context.Background(),
+			cfg.ReplicaURL,
+			psql.WithPoolSettings(cfg.Database),
+			psql.WithQueryTracer(queryTracer),
+			psql.WithPoolMetrics("replica"),
+		)
+		if err != nil {
+			panic(err)
+		}
+		defer replicaConn.Close()
+
+		postgresOpts = append(postgresOpts, psql.WithReplica(replicaConn))
+	}
+
+	migrator, err := migrate.New(conn, migrations.FS)
+	if err != nil {
+		panic(err)
+	}
+	if err := migrator.OnStartup(context.Background(), cfg.MigrateOnStartup); err != nil {
+		panic(err)
+	}
+
+	psql := psql.NewPostgres(conn, postgresOpts...)
 
 	authSessionStore := sessions.NewCookieStore(
 		[]byte(cfg.SessionKey),
@@ -55,11 +103,61 @@ func main() {
 
 	awsSes := awsses.New()
 
+	keyring, err := queue.NewKeyring(cfg.Encryption)
+	if err != nil {
+		panic(err)
+	}
+
+	scheduler, err := queue.NewScheduler(workers.Schedules(cfg.Alerting), cfg.Scheduler)
+	if err != nil {
+		panic(err)
+	}
+
+	riverClient := queue.NewClient(
+		conn,
+		queue.WithLogger(slog.Default()),
+		queue.WithKeyring(keyring),
+	)
+
+	// small deployments can work the jobs in the app process instead of
+	// running cmd/worker next to it
+	var embeddedWorker *queue.Runtime
+	if cfg.EmbeddedWorker {
+		riverWorkers, err := workers.SetupWorkers(workers.WorkerDependencies{
+			DB:       database.New(conn),
+			Emailer:  awsSes,
+			Tracer:   otel.NewTracer("worker/tracer"),
+			Keyring:  keyring,
+			Alerting: cfg.Alerting,
+			Email:    cfg.Email,
+			Users:    cfg.Users,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		periodicJobs, err := scheduler.PeriodicJobs()
+		if err != nil {
+			panic(err)
+		}
+
+		embeddedWorker = queue.NewRuntime(
+			conn,
+			cfg.Worker,
+			queue.WithWorkers(riverWorkers),
+			queue.WithPeriodicJobs(periodicJobs),
+			queue.WithLogger(slog.Default()),
+			queue.WithKeyring(keyring),
+			queue.WithErrorHandler(queue.NewDeadLetterHandler(database.New(conn))),
+		)
+		riverClient = embeddedWorker.Client()
+	}
+
 	authSvc := services.NewAuth(psql, authSessionStore, cfg)
 	tokenService := services.NewTokenSvc(psql, cfg.TokenSigningKey)
-	emailService := services.NewEmailSvc(cfg, &awsSes, riverClient)
+	emailService := services.NewEmailSvc(cfg, &awsSes, riverClient, psql)
 
-	userModelSvc := models.NewUserService(psql, authSvc)
+	userModelSvc := models.NewUserService(psql, authSvc, models.WithDeletedUsers(cfg.Users))
 
 	flashStore := handlers.NewCookieStore("")
 	baseHandler := handlers.NewDependencies(
@@ -78,7 +176,9 @@ func main() {
 		*tokenService,
 		emailService,
 	)
-	apiHandlers := handlers.NewApi()
+	apiHandlers := handlers.NewApi(baseHandler, userModelSvc)
+	adminJobsHandlers := handlers.NewAdminJobs(baseHandler, scheduler)
+	adminUsersHandlers := handlers.NewAdminUsers(baseHandler, userModelSvc)
 	authenticationHandlers := handlers.NewAuthentication(
 		authSvc,
 		baseHandler,
@@ -87,7 +187,7 @@ func main() {
 		emailService,
 	)
 
-	serverMW := mw.NewMiddleware(authSvc)
+	serverMW := mw.NewMiddleware(authSvc, psql, appTracer)
 
 	routes := routes.NewRoutes(
 		appHandlers,
@@ -95,6 +195,8 @@ func main() {
 		authenticationHandlers,
 		registrationHandlers,
 		apiHandlers,
+		adminJobsHandlers,
+		adminUsersHandlers,
 		baseHandler,
 		serverMW,
 		cfg,
@@ -104,5 +206,50 @@ func main() {
 
 	server := http.NewServer(router, cfg)
 
-	server.Start()
+	if embeddedWorker != nil {
+		workerDone := make(chan error, 1)
+		go func() {
+			workerDone <- embeddedWorker.Run(ctx)
+		}()
+
+		// the probes of the worker are served like cmd/worker does, so they
+		// are the same whether the worker runs embedded or on its own
+		healthServer := embeddedWorker.HealthServer(cfg.WorkerHealthAddr)
+		go func() {
+			slog.Info("starting worker health server", "addr", cfg.WorkerHealthAddr)
+			if err := healthServer.ListenAndServe(); err != nil &&
+				!errors.Is(err, nethttp.ErrServerClosed) {
+				slog.Error("worker health server stopped", "error", err)
+			}
+		}()
+
+		// the first signal starts a soft stop through ctx, a second one
+		// cancels the jobs that are still running
+		go func() {
+			<-ctx.Done()
+
+			force := make(chan os.Signal, 1)
+			signal.Notify(force, syscall.SIGINT, syscall.SIGTERM)
+			<-force
+
+			slog.Warn("received second interrupt, cancelling running jobs")
+			embeddedWorker.ForceStop()
+		}()
+
+		// the worker stops along with the server, which is waited on here
+		// before the process exits
+		defer func() {
+			if err := <-workerDone; err != nil {
+				slog.Error("embedded worker stopped with an error", "error", err)
+			}
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := healthServer.Shutdown(shutdownCtx); err != nil {
+				slog.Error("could not shut down worker health server", "error", err)
+			}
+		}()
+	}
+
+	server.Start(ctx)
 }
@@ -1,115 +1,173 @@
 package main
 
 import (
-	"html/template"
+	"errors"
+	"flag"
+	"io/fs"
 	"log"
 	"log/slog"
 	"net/http"
-	"strings"
+	"os"
+	"time"
 
-	"github.com/gosimple/slug"
 	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/pkg/rawmail"
+	"github.com/mbvlabs/grafto/services"
 	"github.com/mbvlabs/grafto/views/emails"
 )
 
-func getTextVersionSlugs() ([]string, error) {
-	files, err := emails.TextTemplates.ReadDir(".")
-	if err != nil {
-		return nil, err
-	}
+var errFixtureNotFound = errors.New("fixture not found")
 
-	var textVersions []string
-	for _, f := range files {
-		removedSuffix, _ := strings.CutSuffix(f.Name(), ".txt")
-		splitted := strings.Split(removedSuffix, "_")
+type previewServer struct {
+	fixtures fs.FS
+}
 
-		var finished string
-		for _, el := range splitted {
-			finished = slug.Make(finished + " " + el)
+func (p previewServer) previews() ([]emailPreview, error) {
+	var previews []emailPreview
+	for _, name := range emails.Templates() {
+		fixtures, err := emails.LoadFixtures(p.fixtures, name)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
 		}
 
-		textVersions = append(textVersions, finished)
+		preview := emailPreview{Name: name}
+		for _, fixture := range fixtures {
+			preview.Fixtures = append(preview.Fixtures, fixture.Name)
+		}
+
+		previews = append(previews, preview)
 	}
 
-	return textVersions, nil
+	return previews, nil
 }
 
-func getHtmlVersionSlugs() ([]string, error) {
-	files, err := emails.HtmlTemplates.ReadDir(".")
+// fixture loads the props from disk on every request so edits to the json
+// files show up on the next reload
+func (p previewServer) fixture(name, fixtureName string) (emails.TemplateHandler, error) {
+	fixtures, err := emails.LoadFixtures(p.fixtures, name)
 	if err != nil {
 		return nil, err
 	}
 
-	var htmlVersions []string
-	for _, f := range files {
-		removedSuffix, _ := strings.CutSuffix(f.Name(), "_templ.go")
-		splitted := strings.Split(removedSuffix, "_")
-
-		var finished string
-		for _, el := range splitted {
-			finished = slug.Make(finished + " " + el)
+	for _, fixture := range fixtures {
+		if fixture.Name == fixtureName {
+			return fixture.Props, nil
 		}
-
-		htmlVersions = append(htmlVersions, finished)
 	}
 
-	return htmlVersions, nil
+	return nil, errFixtureNotFound
 }
 
-func main() {
-	textEmails, err := getTextVersionSlugs()
+func (p previewServer) render(
+	c echo.Context,
+	format func(props emails.TemplateHandler) (string, string, error),
+) error {
+	props, err := p.fixture(c.Param("name"), c.Param("fixture"))
 	if err != nil {
-		panic(err)
+		slog.Error("could not load fixture", "error", err)
+		return c.String(http.StatusNotFound, err.Error())
 	}
 
-	htmlEmails, err := getHtmlVersionSlugs()
+	contentType, body, err := format(props)
 	if err != nil {
-		panic(err)
+		slog.Error("could not render email", "error", err)
+		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
-	e := echo.New()
+	return c.Blob(http.StatusOK, contentType, []byte(body))
+}
 
-	index := emailsIndex(textEmails, htmlEmails)
-	e.GET("/", func(c echo.Context) error {
-		return index.Render(c.Request().Context(), c.Response())
-	})
+func main() {
+	fixturesDir := flag.String(
+		"fixtures",
+		"views/emails/fixtures",
+		"directory with the sample props for each email",
+	)
+	addr := flag.String("addr", ":4444", "address to serve the previews on")
+	flag.Parse()
 
-	passwordReset := emails.PasswordReset{
-		ResetPasswordLink: "wvSwI8Yq02o9cmJ6zVSTkP44lXGJZjmMF8v10vxAhrrV6UyzRr59ogUzdo3VKP7y",
-	}
-	userSignupWelcome := emails.UserSignupWelcome{
-		ConfirmationLink: "wvSwI8Yq02o9cmJ6zVSTkP44lXGJZjmMF8v10vxAhrrV6UyzRr59ogUzdo3VKP7y",
-	}
+	server := previewServer{os.DirFS(*fixturesDir)}
 
-	textGroup := e.Group("/text-emails")
-	textGroup.GET("/password-reset", func(c echo.Context) error {
-		tmpl, err := template.ParseFS(emails.TextTemplates, "password_reset.txt")
+	e := echo.New()
+
+	e.GET("/", func(c echo.Context) error {
+		previews, err := server.previews()
 		if err != nil {
-			slog.Error("error", "e", err)
-			return c.HTML(http.StatusInternalServerError, "could not parse template")
+			slog.Error("could not load previews", "error", err)
+			return c.String(http.StatusInternalServerError, err.Error())
 		}
 
-		return tmpl.Execute(c.Response().Writer, passwordReset)
-	})
-	textGroup.GET("/user-signup-welcome", func(c echo.Context) error {
-		return userSignupWelcome.Render(c.Request().Context(), c.Response())
+		return emailsIndex(previews).Render(c.Request().Context(), c.Response())
 	})
 
-	htmlGroup := e.Group("/html-emails")
-	htmlGroup.GET("/password-reset", func(c echo.Context) error {
-		return passwordReset.Render(c.Request().Context(), c.Response())
+	e.GET("/emails/:name/:fixture", func(c echo.Context) error {
+		return emailPreviewPage(c.Param("name"), c.Param("fixture")).
+			Render(c.Request().Context(), c.Response())
+	})
+	e.GET("/emails/:name/:fixture/html", func(c echo.Context) error {
+		return server.render(c, func(props emails.TemplateHandler) (string, string, error) {
+			html, err := props.GenerateHtmlVersion()
+			return echo.MIMETextHTMLCharsetUTF8, html, err
+		})
+	})
+	e.GET("/emails/:name/:fixture/text", func(c echo.Context) error {
+		return server.render(c, func(props emails.TemplateHandler) (string, string, error) {
+			text, err := props.GenerateTextVersion()
+			return echo.MIMETextPlainCharsetUTF8, text, err
+		})
 	})
-	htmlGroup.GET("/user-signup-welcome", func(c echo.Context) error {
-		return userSignupWelcome.Render(c.Request().Context(), c.Response())
+	e.GET("/emails/:name/:fixture/raw", func(c echo.Context) error {
+		return server.render(c, func(props emails.TemplateHandler) (string, string, error) {
+			html, err := props.GenerateHtmlVersion()
+			if err != nil {
+				return "", "", err
+			}
+
+			text, err := props.GenerateTextVersion()
+			if err != nil {
+				return "", "", err
+			}
+
+			raw, err := rawmail.Build(services.EmailPayload{
+				To:       []string{"preview@grafto.com"},
+				From:     "noreply@grafto.com",
+				Subject:  c.Param("name"),
+				HtmlBody: html,
+				TextBody: text,
+			})
+
+			return echo.MIMETextPlainCharsetUTF8, string(raw), err
+		})
 	})
 
-	// http.Handle("/password-reset-mail", templ.Handler(&emails.PasswordReset{
-	// 	ResetPasswordLink: "https://mortenvistisen.com",
-	// }))
-	// http.Handle("/user-signup-welcome-mail", templ.Handler(&emails.UserSignupWelcomeMail{
-	// 	ConfirmationLink: "https://mortenvistisen.com",
-	// }))
+	// the page keeps an event stream open; when the server is restarted by
+	// wgo the stream breaks and the page reloads once /live-reload/ping
+	// answers again
+	e.GET("/live-reload", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Flush()
+
+		ticker := time.NewTicker(15 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.Request().Context().Done():
+				return nil
+			case <-ticker.C:
+				if _, err := c.Response().Write([]byte(": keep-alive\n\n")); err != nil {
+					return nil
+				}
+				c.Response().Flush()
+			}
+		}
+	})
+	e.GET("/live-reload/ping", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
 
-	slog.Info("starting the password server on port: 4444")
-	log.Fatal(e.Start(":4444"))
+	slog.Info("starting the email preview server", "addr", *addr)
+	log.Fatal(e.Start(*addr))
 }
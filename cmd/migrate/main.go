@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/migrations"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/mbvlabs/grafto/psql/migrate"
+)
+
+const usage = `usage: migrate <command>
+
+commands:
+  up      apply every pending migration
+  down    roll back the latest migration
+  status  list the migrations and when they were applied
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	cfg := config.NewConfig()
+
+	conn, err := psql.CreatePooledConnection(ctx, cfg.GetDatabaseURL())
+	if err != nil {
+		slog.Error("could not connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	migrator, err := migrate.New(conn, migrations.FS)
+	if err != nil {
+		slog.Error("could not read migrations", "error", err)
+		os.Exit(1)
+	}
+
+	switch flag.Arg(0) {
+	case "up":
+		err = up(ctx, migrator)
+	case "down":
+		err = down(ctx, migrator)
+	case "status":
+		err = status(ctx, migrator)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		slog.Error("command failed", "command", flag.Arg(0), "error", err)
+		os.Exit(1)
+	}
+}
+
+func up(ctx context.Context, migrator *migrate.Migrator) error {
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Println("no pending migrations")
+	}
+	for _, migration := range applied {
+		fmt.Printf("applied %s\n", migration.Name)
+	}
+
+	return nil
+}
+
+func down(ctx context.Context, migrator *migrate.Migrator) error {
+	rolledBack, err := migrator.Down(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("rolled back %s\n", rolledBack.Name)
+
+	return nil
+}
+
+func status(ctx context.Context, migrator *migrate.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "APPLIED AT\tMIGRATION")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.IsApplied() {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\n", appliedAt, status.Name)
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/queue/workers"
+)
+
+const usage = `usage: scheduler <command>
+
+commands:
+  list            list the schedules with their next and last run
+  trigger <name>  enqueue the job of a schedule right away
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	cfg := config.NewConfig()
+
+	scheduler, err := queue.NewScheduler(workers.Schedules(cfg.Alerting), cfg.Scheduler)
+	if err != nil {
+		slog.Error("could not set up schedules", "error", err)
+		os.Exit(1)
+	}
+
+	conn, err := psql.CreatePooledConnection(ctx, cfg.GetDatabaseURL())
+	if err != nil {
+		slog.Error("could not connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	switch flag.Arg(0) {
+	case "list":
+		err = list(ctx, psql.NewPostgres(conn), scheduler)
+	case "trigger":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		err = trigger(ctx, cfg, conn, scheduler, flag.Arg(1))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		slog.Error("command failed", "command", flag.Arg(0), "error", err)
+		os.Exit(1)
+	}
+}
+
+func list(ctx context.Context, db psql.Postgres, scheduler *queue.Scheduler) error {
+	states, err := db.QueryJobScheduleStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tKIND\tSCHEDULE\tENABLED\tNEXT RUN\tLAST RUN\tLAST OUTCOME")
+
+	now := time.Now()
+	for _, job := range scheduler.Jobs() {
+		enabled := !job.Disabled
+		var lastRun time.Time
+		lastOutcome := "-"
+		for _, state := range states {
+			if state.Name != job.Name {
+				continue
+			}
+
+			enabled = enabled && state.Enabled
+			lastRun = state.LastRunAt
+			if state.HasRun() {
+				lastOutcome = state.LastOutcome
+			}
+		}
+
+		nextRun := "-"
+		if enabled {
+			nextRun = job.NextRun(lastRun, now).Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%t\t%s\t%s\t%s\n",
+			job.Name,
+			job.Args.Kind(),
+			job.Spec,
+			enabled,
+			nextRun,
+			formatTime(lastRun),
+			lastOutcome,
+		)
+	}
+
+	return writer.Flush()
+}
+
+func trigger(
+	ctx context.Context,
+	cfg config.Config,
+	conn *pgxpool.Pool,
+	scheduler *queue.Scheduler,
+	name string,
+) error {
+	keyring, err := queue.NewKeyring(cfg.Encryption)
+	if err != nil {
+		return err
+	}
+
+	client := queue.NewClient(conn, queue.WithKeyring(keyring))
+
+	job, err := scheduler.Trigger(ctx, client, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("enqueued %s job %d\n", job.Kind, job.ID)
+
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/factory"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/seed"
+	"github.com/mbvlabs/grafto/services"
+)
+
+const usage = `usage: seed
+
+Loads users, tokens and jobs for development into the database. It is refused
+when ENVIRONMENT is production and does nothing on a database that is seeded.
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+	}
+	flag.Parse()
+
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	cfg := config.NewConfig()
+
+	// checked before connecting as well, so production is never touched
+	if cfg.Environment == config.PROD_ENVIRONMENT {
+		slog.Error("could not seed database", "error", seed.ErrProduction)
+		os.Exit(1)
+	}
+
+	conn, err := psql.CreatePooledConnection(ctx, cfg.GetDatabaseURL())
+	if err != nil {
+		slog.Error("could not connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	keyring, err := queue.NewKeyring(cfg.Encryption)
+	if err != nil {
+		slog.Error("could not set up encryption", "error", err)
+		os.Exit(1)
+	}
+
+	db := psql.NewPostgres(conn)
+	result, err := seed.Run(ctx, seed.Deps{
+		Environment: cfg.Environment,
+		Sender:      cfg.DefaultSenderSignature,
+		DB:          db,
+		Hasher:      services.NewAuth(db, nil, cfg),
+		Tokens:      services.NewTokenSvc(db, cfg.TokenSigningKey),
+		Jobs:        queue.NewClient(conn, queue.WithKeyring(keyring)),
+	})
+	if err != nil {
+		slog.Error("could not seed database", "error", err)
+		os.Exit(1)
+	}
+
+	if result.AlreadySeeded {
+		fmt.Printf("database is already seeded, %s exists\n", seed.AdminEmail)
+		return
+	}
+
+	fmt.Printf("created %d users with the password %q\n", len(result.Users), factory.Password)
+	fmt.Printf("  admin       %s\n", seed.AdminEmail)
+	fmt.Printf("  verified    %s\n", seed.VerifiedEmail)
+	fmt.Printf("  unverified  %s\n", seed.UnverifiedEmail)
+	fmt.Printf("email verification token of %s: %s\n", seed.UnverifiedEmail, result.EmailVerificationToken)
+	fmt.Printf("password reset token of %s: %s\n", seed.VerifiedEmail, result.PasswordResetToken)
+	for _, job := range result.Jobs {
+		fmt.Printf("enqueued %s job %d\n", job.Kind, job.ID)
+	}
+}
@@ -3,27 +3,43 @@ package main
 import (
 	"context"
 	"errors"
-	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/migrations"
 	awsses "github.com/mbvlabs/grafto/pkg/aws_ses"
 	"github.com/mbvlabs/grafto/pkg/telemetry"
 	"github.com/mbvlabs/grafto/psql"
 	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/psql/migrate"
 	"github.com/mbvlabs/grafto/queue"
 	"github.com/mbvlabs/grafto/queue/workers"
-	"github.com/riverqueue/river"
 )
 
 var appRelease string
 
 func main() {
-	ctx := context.Background()
+	if err := run(); err != nil {
+		slog.Error("worker stopped with an error", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run works jobs until the process is signalled to stop. It returns instead of
+// exiting so the deferred shutdown of telemetry runs on errors too.
+func run() error {
+	ctx, stop := signal.NotifyContext(
+		context.Background(),
+		syscall.SIGINT,
+		syscall.SIGTERM,
+	)
+	defer stop()
+
 	cfg := config.NewConfig()
 
 	otel := telemetry.NewOtel(cfg)
@@ -45,123 +61,95 @@ func main() {
 	conn, err := psql.CreatePooledConnection(
 		context.Background(),
 		cfg.GetDatabaseURL(),
+		psql.WithPoolSettings(cfg.Database),
+		psql.WithQueryTracer(psql.NewQueryTracer(
+			otel.NewTracer("psql/tracer"),
+			cfg.SlowQueryThreshold,
+		)),
+		psql.WithPoolMetrics("primary"),
 	)
 	if err != nil {
 		panic(err)
 	}
+
+	migrator, err := migrate.New(conn, migrations.FS)
+	if err != nil {
+		panic(err)
+	}
+	if err := migrator.OnStartup(context.Background(), cfg.MigrateOnStartup); err != nil {
+		panic(err)
+	}
 	db := database.New(conn)
 
-	jobStarted := make(chan struct{})
+	keyring, err := queue.NewKeyring(cfg.Encryption)
+	if err != nil {
+		panic(err)
+	}
 
-	workers, err := workers.SetupWorkers(workers.WorkerDependencies{
-		DB:      db,
-		Emailer: awsSes,
-		Tracer:  workerTracer,
-	})
+	scheduler, err := queue.NewScheduler(workers.Schedules(cfg.Alerting), cfg.Scheduler)
 	if err != nil {
 		panic(err)
 	}
 
-	q := map[string]river.QueueConfig{river.QueueDefault: {MaxWorkers: 100}}
-	riverClient := queue.NewClient(
-		conn,
-		queue.WithQueues(q),
-		queue.WithWorkers(workers),
-		queue.WithLogger(slog.Default()),
-	)
+	riverWorkers, err := workers.SetupWorkers(workers.WorkerDependencies{
+		DB:       db,
+		Emailer:  awsSes,
+		Tracer:   workerTracer,
+		Keyring:  keyring,
+		Alerting: cfg.Alerting,
+		Email:    cfg.Email,
+		Users:    cfg.Users,
+	})
+	if err != nil {
+		panic(err)
+	}
 
-	if err := riverClient.Start(ctx); err != nil {
+	periodicJobs, err := scheduler.PeriodicJobs()
+	if err != nil {
 		panic(err)
 	}
 
-	sigintOrTerm := make(chan os.Signal, 1)
-	signal.Notify(sigintOrTerm, syscall.SIGINT, syscall.SIGTERM)
+	runtime := queue.NewRuntime(
+		conn,
+		cfg.Worker,
+		queue.WithWorkers(riverWorkers),
+		queue.WithPeriodicJobs(periodicJobs),
+		queue.WithLogger(slog.Default()),
+		queue.WithKeyring(keyring),
+		queue.WithErrorHandler(queue.NewDeadLetterHandler(db)),
+	)
 
-	// This is meant to be a realistic-looking stop goroutine that might go in a
-	// real program. It waits for SIGINT/SIGTERM and when received, tries to stop
-	// gracefully by allowing a chance for jobs to finish. But if that isn't
-	// working, a second SIGINT/SIGTERM will tell it to terminate with prejudice and
-	// it'll issue a hard stop that cancels the context of all active jobs. In
-	// case that doesn't work, a third SIGINT/SIGTERM ignores River's stop procedure
-	// completely and exits uncleanly.
+	healthServer := runtime.HealthServer(cfg.WorkerHealthAddr)
 	go func() {
-		<-sigintOrTerm
-		fmt.Printf(
-			"Received SIGINT/SIGTERM; initiating soft stop (try to wait for jobs to finish)\n",
-		)
-
-		softStopCtx, softStopCtxCancel := context.WithTimeout(
-			ctx,
-			10*time.Second,
-		)
-		defer softStopCtxCancel()
-
-		go func() {
-			select {
-			case <-sigintOrTerm:
-				fmt.Printf(
-					"Received SIGINT/SIGTERM again; initiating hard stop (cancel everything)\n",
-				)
-				softStopCtxCancel()
-			case <-softStopCtx.Done():
-				fmt.Printf(
-					"Soft stop timeout; initiating hard stop (cancel everything)\n",
-				)
-			}
-		}()
-
-		err := riverClient.Stop(softStopCtx)
-		if err != nil && !errors.Is(err, context.DeadlineExceeded) &&
-			!errors.Is(err, context.Canceled) {
-			panic(err)
-		}
-		if err == nil {
-			fmt.Printf("Soft stop succeeded\n")
-			return
+		slog.Info("starting worker health server", "addr", cfg.WorkerHealthAddr)
+		if err := healthServer.ListenAndServe(); err != nil &&
+			!errors.Is(err, http.ErrServerClosed) {
+			slog.Error("worker health server stopped", "error", err)
 		}
+	}()
 
-		hardStopCtx, hardStopCtxCancel := context.WithTimeout(
-			ctx,
-			10*time.Second,
-		)
-		defer hardStopCtxCancel()
-
-		// As long as all jobs respect context cancellation, StopAndCancel will
-		// always work. However, in the case of a bug where a job blocks despite
-		// being cancelled, it may be necessary to either ignore River's stop
-		// result (what's shown here) or have a supervisor kill the process.
-		err = riverClient.StopAndCancel(hardStopCtx)
-		if err != nil && errors.Is(err, context.DeadlineExceeded) {
-			fmt.Printf(
-				"Hard stop timeout; ignoring stop procedure and exiting unsafely\n",
-			)
-		} else if err != nil {
-			panic(err)
-		}
+	// the first signal starts a soft stop through ctx, a second one cancels
+	// the jobs that are still running
+	go func() {
+		<-ctx.Done()
 
-		// hard stop succeeded
+		force := make(chan os.Signal, 1)
+		signal.Notify(force, syscall.SIGINT, syscall.SIGTERM)
+		<-force
+
+		slog.Warn("received second interrupt, cancelling running jobs")
+		runtime.ForceStop()
 	}()
 
-	// Make sure our job starts being worked before doing anything else.
-	<-jobStarted
-
-	// Cheat a little by sending a SIGTERM manually for the purpose of this
-	// example (normally this will be sent by user or supervisory process). The
-	// first SIGTERM tries a soft stop in which jobs are given a chance to
-	// finish up.
-	sigintOrTerm <- syscall.SIGTERM
-
-	// The soft stop will never work in this example because our job only
-	// respects context cancellation, but wait a short amount of time to give it
-	// a chance. After it elapses, send another SIGTERM to initiate a hard stop.
-	select {
-	case <-riverClient.Stopped():
-		// Will never be reached in this example because our job will only ever
-		// finish on context cancellation.
-		fmt.Printf("Soft stop succeeded\n")
-
-	case <-time.After(100 * time.Millisecond):
-		sigintOrTerm <- syscall.SIGTERM
-		<-riverClient.Stopped()
+	runErr := runtime.Run(ctx)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := healthServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("could not shut down worker health server", "error", err)
 	}
+
+	conn.Close()
+
+	return runErr
 }
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v10"
+)
+
+type Alerting struct {
+	// DeadLetterAlertThreshold is the share of the jobs finalized within
+	// DeadLetterAlertWindow that can be dead-lettered before operators are
+	// alerted
+	DeadLetterAlertThreshold float64       `env:"DEAD_LETTER_ALERT_THRESHOLD" envDefault:"0.05"`
+	DeadLetterAlertWindow    time.Duration `env:"DEAD_LETTER_ALERT_WINDOW" envDefault:"15m"`
+	// DeadLetterAlertMinJobs keeps a couple of failures in a quiet window from
+	// raising an alert
+	DeadLetterAlertMinJobs int64 `env:"DEAD_LETTER_ALERT_MIN_JOBS" envDefault:"20"`
+	// AlertEmail and AlertWebhookURL are where alerts are sent, leave both
+	// empty to only log them
+	AlertEmail      string `env:"ALERT_EMAIL" envDefault:""`
+	AlertWebhookURL string `env:"ALERT_WEBHOOK_URL" envDefault:""`
+}
+
+func newAlerting() Alerting {
+	alertingCfg := Alerting{}
+
+	if err := env.ParseWithOptions(&alertingCfg, env.Options{
+		RequiredIfNoDef: true,
+	}); err != nil {
+		panic(err)
+	}
+
+	return alertingCfg
+}
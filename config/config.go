@@ -7,6 +7,13 @@ type Config struct {
 	Authentication
 	App
 	Telemetry
+	Worker
+	Encryption
+	Alerting
+	Email
+	Scheduler
+	Migrations
+	Users
 	AwsAccessKeyID     string
 	AwsSecretAccessKey string
 }
@@ -26,6 +33,13 @@ func NewConfig() Config {
 		newAuthentication(),
 		newApp(),
 		newTelemetry(),
+		newWorker(),
+		newEncryption(),
+		newAlerting(),
+		newEmail(),
+		newScheduler(),
+		newMigrations(),
+		newUsers(),
 		awsAccessKeyID,
 		awsSecretAccessKey,
 	}
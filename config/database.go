@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/caarlos0/env/v10"
 )
@@ -14,6 +15,18 @@ type Database struct {
 	Password     string `env:"DB_PASSWORD"`
 	DatabaseKind string `env:"DB_KIND"`
 	SSL_MODE     string `env:"DB_SSL_MODE"`
+	// SlowQueryThreshold logs queries that take longer, zero turns it off
+	SlowQueryThreshold time.Duration `env:"DB_SLOW_QUERY_THRESHOLD" envDefault:"200ms"`
+	// MaxConns of zero lets pgx pick, which is the number of cpus but at
+	// least 4
+	MaxConns          int32         `env:"DB_MAX_CONNS" envDefault:"0"`
+	MinConns          int32         `env:"DB_MIN_CONNS" envDefault:"0"`
+	MaxConnLifetime   time.Duration `env:"DB_MAX_CONN_LIFETIME" envDefault:"1h"`
+	MaxConnIdleTime   time.Duration `env:"DB_MAX_CONN_IDLE_TIME" envDefault:"30m"`
+	HealthCheckPeriod time.Duration `env:"DB_HEALTH_CHECK_PERIOD" envDefault:"1m"`
+	// ReplicaURL points read-only queries at a replica, leave it empty to
+	// send everything to the primary
+	ReplicaURL string `env:"DB_REPLICA_URL" envDefault:""`
 }
 
 func (d Database) GetDatabaseURL() string {
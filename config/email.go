@@ -0,0 +1,27 @@
+package config
+
+import "github.com/caarlos0/env/v10"
+
+type Email struct {
+	// EmailRateLimit is the messages per second sent across every worker,
+	// set it to the send rate of the provider, e.g. 14 for a new ses account
+	EmailRateLimit float64 `env:"EMAIL_RATE_LIMIT" envDefault:"14"`
+	// EmailRateBurst is how many messages can go out at once after a quiet
+	// period
+	EmailRateBurst float64 `env:"EMAIL_RATE_BURST" envDefault:"14"`
+	// EmailTransactionalReserve is the share of the burst that bulk mail
+	// leaves for transactional mail
+	EmailTransactionalReserve float64 `env:"EMAIL_TRANSACTIONAL_RESERVE" envDefault:"0.25"`
+}
+
+func newEmail() Email {
+	emailCfg := Email{}
+
+	if err := env.ParseWithOptions(&emailCfg, env.Options{
+		RequiredIfNoDef: true,
+	}); err != nil {
+		panic(err)
+	}
+
+	return emailCfg
+}
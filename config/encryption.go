@@ -0,0 +1,24 @@
+package config
+
+import "github.com/caarlos0/env/v10"
+
+type Encryption struct {
+	// JobEncryptionKeys are base64 encoded 256 bit keys by id, e.g.
+	// "2026-10:<key>,2026-01:<key>". A rotated out key has to stay listed
+	// until no jobs encrypted with it are left.
+	JobEncryptionKeys map[string]string `env:"JOB_ENCRYPTION_KEYS"`
+	// JobEncryptionKeyID is the id of the key new jobs are encrypted with
+	JobEncryptionKeyID string `env:"JOB_ENCRYPTION_KEY_ID"`
+}
+
+func newEncryption() Encryption {
+	encryptionCfg := Encryption{}
+
+	if err := env.ParseWithOptions(&encryptionCfg, env.Options{
+		RequiredIfNoDef: true,
+	}); err != nil {
+		panic(err)
+	}
+
+	return encryptionCfg
+}
@@ -0,0 +1,21 @@
+package config
+
+import "github.com/caarlos0/env/v10"
+
+type Migrations struct {
+	// MigrateOnStartup is off, check to refuse to start with pending
+	// migrations, or apply to apply them before starting
+	MigrateOnStartup string `env:"MIGRATE_ON_STARTUP" envDefault:"off"`
+}
+
+func newMigrations() Migrations {
+	migrationsCfg := Migrations{}
+
+	if err := env.ParseWithOptions(&migrationsCfg, env.Options{
+		RequiredIfNoDef: true,
+	}); err != nil {
+		panic(err)
+	}
+
+	return migrationsCfg
+}
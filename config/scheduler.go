@@ -0,0 +1,25 @@
+package config
+
+import "github.com/caarlos0/env/v10"
+
+type Scheduler struct {
+	// ScheduleOverrides replaces the schedule of jobs by name with a cron
+	// expression or an interval, e.g.
+	// "purge_idempotency_keys=0 */2 * * *;check_dead_letter_rate=@every 30m"
+	ScheduleOverrides map[string]string `env:"SCHEDULE_OVERRIDES" envDefault:"" envSeparator:";" envKeyValSeparator:"="`
+	// DisabledSchedules are never enqueued. A schedule can also be disabled
+	// from the admin, which does not need a restart.
+	DisabledSchedules []string `env:"DISABLED_SCHEDULES" envDefault:""`
+}
+
+func newScheduler() Scheduler {
+	schedulerCfg := Scheduler{}
+
+	if err := env.ParseWithOptions(&schedulerCfg, env.Options{
+		RequiredIfNoDef: true,
+	}); err != nil {
+		panic(err)
+	}
+
+	return schedulerCfg
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/caarlos0/env/v10"
+)
+
+// The policies for when the email of a deleted user can be registered again.
+const (
+	// EmailReleasedOnDelete frees the email as soon as the user is deleted,
+	// which makes restoring fail if someone has registered it since
+	EmailReleasedOnDelete = "delete"
+	// EmailReleasedOnPurge keeps the email taken until the user is purged
+	EmailReleasedOnPurge = "purge"
+)
+
+type Users struct {
+	// DeletedUserRetention is how long a deleted user can be restored before
+	// the purge job removes it for good
+	DeletedUserRetention time.Duration `env:"DELETED_USER_RETENTION" envDefault:"720h"`
+	// EmailRelease is delete or purge, see EmailReleasedOnDelete and
+	// EmailReleasedOnPurge
+	EmailRelease string `env:"USER_EMAIL_RELEASE" envDefault:"purge"`
+}
+
+func newUsers() Users {
+	usersCfg := Users{}
+
+	if err := env.ParseWithOptions(&usersCfg, env.Options{
+		RequiredIfNoDef: true,
+	}); err != nil {
+		panic(err)
+	}
+
+	if usersCfg.EmailRelease != EmailReleasedOnDelete &&
+		usersCfg.EmailRelease != EmailReleasedOnPurge {
+		panic(fmt.Sprintf("USER_EMAIL_RELEASE must be %q or %q", EmailReleasedOnDelete, EmailReleasedOnPurge))
+	}
+
+	return usersCfg
+}
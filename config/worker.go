@@ -0,0 +1,36 @@
+package config
+
+import (
+	"time"
+
+	"github.com/caarlos0/env/v10"
+)
+
+type Worker struct {
+	// WorkerQueues lists the queues to work and their concurrency. Email is
+	// sent from the email_transactional and email_bulk queues, which at least
+	// one worker has to work.
+	WorkerQueues map[string]int `env:"WORKER_QUEUES" envDefault:"default:100,email_transactional:10,email_bulk:5"`
+	// WorkerHealthAddr serves /healthz, /readyz, /status and /metrics
+	WorkerHealthAddr      string        `env:"WORKER_HEALTH_ADDR" envDefault:":8081"`
+	WorkerSoftStopTimeout time.Duration `env:"WORKER_SOFT_STOP_TIMEOUT" envDefault:"10s"`
+	WorkerHardStopTimeout time.Duration `env:"WORKER_HARD_STOP_TIMEOUT" envDefault:"10s"`
+	// EmbeddedWorker runs the workers inside the app process instead of a
+	// separate worker process
+	EmbeddedWorker bool `env:"EMBEDDED_WORKER" envDefault:"false"`
+	// WorkerRendersEmails queues emails as a template name and props that the
+	// worker renders at send time instead of as rendered html and text
+	WorkerRendersEmails bool `env:"WORKER_RENDERS_EMAILS" envDefault:"false"`
+}
+
+func newWorker() Worker {
+	workerCfg := Worker{}
+
+	if err := env.ParseWithOptions(&workerCfg, env.Options{
+		RequiredIfNoDef: true,
+	}); err != nil {
+		panic(err)
+	}
+
+	return workerCfg
+}
@@ -0,0 +1,173 @@
+// Package factory builds valid models and job args with made up data, for
+// seeding a development database and for fixtures in tests.
+package factory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/queue/jobs"
+)
+
+// Password is the password of every user the factory builds data for.
+const Password = "password"
+
+// Epoch is when the first row a Factory builds is created. It is fixed, so a
+// seed builds the same rows every time.
+var Epoch = time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+var (
+	firstNames = []string{"Jon", "Arya", "Sansa", "Bran", "Robb", "Daenerys", "Tyrion", "Brienne", "Samwell", "Davos"}
+	lastNames  = []string{"Snow", "Stark", "Targaryen", "Lannister", "Tarth", "Tarly", "Seaworth", "Greyjoy"}
+)
+
+/*
+Factory builds rows that differ from each other but are the same for the same
+seed, in the order they are built. Every row gets the next sequence number,
+which keeps emails unique and spaces out CreatedAt by an hour. A Factory is not
+safe for concurrent use.
+*/
+type Factory struct {
+	rng *rand.Rand
+	seq int
+}
+
+func New(seed uint64) *Factory {
+	return &Factory{rng: rand.New(rand.NewPCG(seed, seed))}
+}
+
+func (f *Factory) next() int {
+	f.seq++
+	return f.seq
+}
+
+// uuid returns a version 4 uuid read from the random numbers of the seed.
+func (f *Factory) uuid() uuid.UUID {
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[:8], f.rng.Uint64())
+	binary.BigEndian.PutUint64(id[8:], f.rng.Uint64())
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	return id
+}
+
+type UserOpts func(user *models.User)
+
+func Admin() UserOpts {
+	return func(user *models.User) {
+		user.IsAdmin = true
+	}
+}
+
+// Verified marks the email of the user verified an hour after it was created.
+func Verified() UserOpts {
+	return func(user *models.User) {
+		user.EmailVerifiedAt = user.CreatedAt.Add(time.Hour)
+	}
+}
+
+func WithName(name string) UserOpts {
+	return func(user *models.User) {
+		user.Name = name
+	}
+}
+
+func WithEmail(email string) UserOpts {
+	return func(user *models.User) {
+		user.Email = email
+	}
+}
+
+// User builds an unverified user that is not an admin, with a name and email
+// that pass CreateUserValidations.
+func (f *Factory) User(opts ...UserOpts) models.User {
+	seq := f.next()
+	first := firstNames[f.rng.IntN(len(firstNames))]
+	last := lastNames[f.rng.IntN(len(lastNames))]
+	createdAt := Epoch.Add(time.Duration(seq) * time.Hour)
+
+	user := models.User{
+		ID:        f.uuid(),
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		Name:      first + " " + last,
+		Email:     fmt.Sprintf("%s.%s.%d@example.com", strings.ToLower(first), strings.ToLower(last), seq),
+		Locale:    "en",
+		Version:   1,
+	}
+	for _, opt := range opts {
+		opt(&user)
+	}
+
+	return user
+}
+
+// CreateUserData builds the data to sign up the user that User would build,
+// with Password as the password.
+func (f *Factory) CreateUserData(
+	opts ...func(data *models.CreateUserData),
+) models.CreateUserData {
+	user := f.User()
+	data := models.CreateUserData{
+		ID:              user.ID,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+		Name:            user.Name,
+		Email:           user.Email,
+		Password:        Password,
+		ConfirmPassword: Password,
+	}
+	for _, opt := range opts {
+		opt(&data)
+	}
+
+	return data
+}
+
+// UpdateUserData builds a change of name and email to the current version of
+// user.
+func (f *Factory) UpdateUserData(
+	user models.User,
+	opts ...func(data *models.UpdateUserData),
+) models.UpdateUserData {
+	changed := f.User()
+	data := models.UpdateUserData{
+		ID:        user.ID,
+		UpdatedAt: changed.CreatedAt,
+		Name:      changed.Name,
+		Email:     changed.Email,
+		Version:   user.Version,
+	}
+	for _, opt := range opts {
+		opt(&data)
+	}
+
+	return data
+}
+
+// EmailJob builds the args of a plain email to user, sent from from.
+func (f *Factory) EmailJob(
+	user models.User,
+	from string,
+	opts ...func(args *jobs.EmailJobArgs),
+) jobs.EmailJobArgs {
+	seq := f.next()
+	args := jobs.EmailJobArgs{
+		To:          jobs.Recipients{user.Email},
+		From:        from,
+		Subject:     fmt.Sprintf("Hello %s (%d)", user.Name, seq),
+		TextVersion: fmt.Sprintf("Hello %s, this is email %d.", user.Name, seq),
+		HtmlVersion: fmt.Sprintf("<p>Hello %s, this is email %d.</p>", user.Name, seq),
+	}
+	for _, opt := range opts {
+		opt(&args)
+	}
+
+	return args
+}
@@ -0,0 +1,65 @@
+package factory_test
+
+import (
+	"testing"
+
+	"github.com/mbvlabs/grafto/factory"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/pkg/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSameSeedBuildsSameUsers(t *testing.T) {
+	first, second := factory.New(7), factory.New(7)
+
+	for range 5 {
+		assert.Equal(t, first.User(), second.User())
+	}
+
+	assert.NotEqual(t, factory.New(7).User().ID, factory.New(8).User().ID)
+}
+
+func TestUsersAreValidAndUnique(t *testing.T) {
+	f := factory.New(1)
+
+	emails := map[string]bool{}
+	for range 50 {
+		data := f.CreateUserData()
+		assert.Nil(t, validation.ValidateStruct(data, models.CreateUserValidations(data.ConfirmPassword)))
+		assert.False(t, emails[data.Email], "email %s was built twice", data.Email)
+		emails[data.Email] = true
+	}
+}
+
+func TestUserOpts(t *testing.T) {
+	user := factory.New(1).User(
+		factory.WithName("Ned Stark"),
+		factory.WithEmail("ned@example.com"),
+		factory.Admin(),
+		factory.Verified(),
+	)
+
+	assert.Equal(t, "Ned Stark", user.Name)
+	assert.Equal(t, "ned@example.com", user.Email)
+	assert.True(t, user.IsAdmin)
+	assert.True(t, user.IsVerified())
+	assert.True(t, user.EmailVerifiedAt.After(user.CreatedAt))
+
+	assert.False(t, factory.New(1).User().IsVerified())
+}
+
+func TestUpdateUserData(t *testing.T) {
+	f := factory.New(1)
+	user := f.User()
+	user.Version = 3
+
+	data := f.UpdateUserData(user, func(data *models.UpdateUserData) {
+		data.Name = "Jon Snow"
+	})
+
+	assert.Equal(t, user.ID, data.ID)
+	assert.Equal(t, int64(3), data.Version)
+	assert.Equal(t, "Jon Snow", data.Name)
+	assert.NotEqual(t, user.Email, data.Email)
+	assert.Nil(t, validation.ValidateStruct(data, models.UpdateUserValidations()))
+}
@@ -7,8 +7,11 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/sessions v1.2.2
 	github.com/lmittmann/tint v1.0.1
+	github.com/prometheus/client_golang v1.19.0
+	github.com/prometheus/client_model v0.6.1
 	github.com/riverqueue/river v0.0.18
 	github.com/riverqueue/river/riverdriver/riverpgxv5 v0.0.18
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/samber/slog-loki/v3 v3.5.0
 	github.com/samber/slog-otel v0.0.0-20240701120852-8150bb781d6a
 	github.com/stretchr/testify v1.9.0
@@ -55,8 +58,6 @@ require (
 	github.com/mwitkow/go-conntrack v0.0.0-20190716064945-2f068394615f // indirect
 	github.com/oklog/ulid/v2 v2.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/prometheus/client_golang v1.19.0 // indirect
-	github.com/prometheus/client_model v0.6.1 // indirect
 	github.com/prometheus/common v0.53.0 // indirect
 	github.com/prometheus/procfs v0.13.0 // indirect
 	github.com/prometheus/prometheus v0.35.0 // indirect
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/csrf"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/mbvlabs/grafto/views"
+	"github.com/mbvlabs/grafto/views/admin"
+)
+
+type DeadLettersIndexPayload struct {
+	Resolved bool `query:"resolved"`
+	Page     int  `query:"page"`
+}
+
+func (a *AdminJobs) DeadLetters(ctx echo.Context) error {
+	var payload DeadLettersIndexPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return a.InternalError(ctx)
+	}
+
+	if payload.Page < 1 {
+		payload.Page = 1
+	}
+
+	total, err := a.db.CountDeadLetterJobs(ctx.Request().Context(), payload.Resolved)
+	if err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not count dead-lettered jobs", "error", err)
+		return a.InternalError(ctx)
+	}
+
+	deadLetters, err := a.db.QueryDeadLetterJobs(
+		ctx.Request().Context(),
+		payload.Resolved,
+		jobsPerPage,
+		(payload.Page-1)*jobsPerPage,
+	)
+	if err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not query dead-lettered jobs", "error", err)
+		return a.InternalError(ctx)
+	}
+
+	rows := make([]admin.DeadLetterRow, len(deadLetters))
+	for i, deadLetter := range deadLetters {
+		rows[i] = admin.DeadLetterRow{
+			Job:  deadLetter,
+			Args: string(jobs.RedactArgs(deadLetter.Kind, deadLetter.Args)),
+		}
+	}
+
+	return admin.DeadLettersPage(admin.DeadLettersPageProps{
+		CsrfToken:  csrf.Token(ctx.Request()),
+		Rows:       rows,
+		Resolved:   payload.Resolved,
+		Page:       payload.Page,
+		TotalPages: max(1, int((total+jobsPerPage-1)/jobsPerPage)),
+		Total:      total,
+	}).Render(views.ExtractRenderDeps(ctx))
+}
+
+// RetryDeadLetter makes the dead-lettered job available again and resolves
+// it, which is only possible until river has cleaned up the job.
+func (a *AdminJobs) RetryDeadLetter(ctx echo.Context) error {
+	var payload JobPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	if _, err := a.db.RetryJob(ctx.Request().Context(), payload.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusConflict, "job is running or has been cleaned up")
+		}
+
+		slog.ErrorContext(ctx.Request().Context(), "could not retry job", "error", err, "job_id", payload.ID)
+		return a.InternalError(ctx)
+	}
+
+	if err := a.db.ResolveDeadLetterJob(ctx.Request().Context(), payload.ID); err != nil &&
+		!errors.Is(err, psql.ErrNoRowWithIdentifier) {
+		slog.ErrorContext(ctx.Request().Context(), "could not resolve dead-lettered job", "error", err, "job_id", payload.ID)
+		return a.InternalError(ctx)
+	}
+
+	return a.RedirectHx(ctx.Response(), "/admin/dead-letters")
+}
+
+func (a *AdminJobs) ResolveDeadLetter(ctx echo.Context) error {
+	var payload JobPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	if err := a.db.ResolveDeadLetterJob(ctx.Request().Context(), payload.ID); err != nil {
+		if errors.Is(err, psql.ErrNoRowWithIdentifier) {
+			return echo.ErrNotFound
+		}
+
+		slog.ErrorContext(ctx.Request().Context(), "could not resolve dead-lettered job", "error", err, "job_id", payload.ID)
+		return a.InternalError(ctx)
+	}
+
+	return a.RedirectHx(ctx.Response(), "/admin/dead-letters")
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/gorilla/csrf"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/mbvlabs/grafto/views"
+	"github.com/mbvlabs/grafto/views/admin"
+	"github.com/riverqueue/river"
+)
+
+const jobsPerPage = 25
+
+type AdminJobs struct {
+	Base
+	scheduler *queue.Scheduler
+}
+
+func NewAdminJobs(base Base, scheduler *queue.Scheduler) AdminJobs {
+	return AdminJobs{base, scheduler}
+}
+
+type JobsIndexPayload struct {
+	State string `query:"state"`
+	Queue string `query:"queue"`
+	Kind  string `query:"kind"`
+	Page  int    `query:"page"`
+}
+
+func (a *AdminJobs) Index(ctx echo.Context) error {
+	var payload JobsIndexPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return a.InternalError(ctx)
+	}
+
+	if !slices.Contains(models.JobStates, payload.State) {
+		payload.State = ""
+	}
+	if payload.Page < 1 {
+		payload.Page = 1
+	}
+
+	filters := models.JobFilters{
+		State: payload.State,
+		Queue: payload.Queue,
+		Kind:  payload.Kind,
+	}
+
+	total, err := a.db.CountJobs(ctx.Request().Context(), filters)
+	if err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not count jobs", "error", err)
+		return a.InternalError(ctx)
+	}
+
+	foundJobs, err := a.db.QueryJobs(
+		ctx.Request().Context(),
+		filters,
+		jobsPerPage,
+		(payload.Page-1)*jobsPerPage,
+	)
+	if err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not query jobs", "error", err)
+		return a.InternalError(ctx)
+	}
+
+	table := admin.JobsTableProps{
+		Jobs:       foundJobs,
+		Filters:    filters,
+		Page:       payload.Page,
+		TotalPages: max(1, int((total+jobsPerPage-1)/jobsPerPage)),
+		Total:      total,
+	}
+
+	// filtering and paging only swap the table
+	if ctx.Request().Header.Get("HX-Target") == "jobs-table" {
+		return admin.JobsTable(table).Render(views.ExtractRenderDeps(ctx))
+	}
+
+	queues, err := a.db.QueryQueueStats(ctx.Request().Context())
+	if err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not query queue stats", "error", err)
+		return a.InternalError(ctx)
+	}
+
+	kinds, err := a.db.QueryJobKinds(ctx.Request().Context())
+	if err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not query job kinds", "error", err)
+		return a.InternalError(ctx)
+	}
+
+	return admin.JobsPage(admin.JobsPageProps{
+		CsrfToken: csrf.Token(ctx.Request()),
+		Queues:    queues,
+		Kinds:     kinds,
+		Table:     table,
+	}).Render(views.ExtractRenderDeps(ctx))
+}
+
+type JobPayload struct {
+	ID int64 `param:"id"`
+}
+
+func (a *AdminJobs) Show(ctx echo.Context) error {
+	var payload JobPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	job, err := a.db.QueryJobByID(ctx.Request().Context(), payload.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return echo.ErrNotFound
+		}
+
+		slog.ErrorContext(ctx.Request().Context(), "could not query job", "error", err)
+		return a.InternalError(ctx)
+	}
+
+	return admin.JobPage(admin.JobPageProps{
+		CsrfToken: csrf.Token(ctx.Request()),
+		Job:       job,
+		Args:      string(jobs.RedactArgs(job.Kind, job.Args)),
+	}).Render(views.ExtractRenderDeps(ctx))
+}
+
+func (a *AdminJobs) Retry(ctx echo.Context) error {
+	var payload JobPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	if _, err := a.db.RetryJob(ctx.Request().Context(), payload.ID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusConflict, "job is running or does not exist")
+		}
+
+		slog.ErrorContext(ctx.Request().Context(), "could not retry job", "error", err, "job_id", payload.ID)
+		return a.InternalError(ctx)
+	}
+
+	return a.RedirectHx(ctx.Response(), fmt.Sprintf("/admin/jobs/%d", payload.ID))
+}
+
+func (a *AdminJobs) Cancel(ctx echo.Context) error {
+	var payload JobPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	if _, err := a.queueClient.JobCancel(ctx.Request().Context(), payload.ID); err != nil {
+		if errors.Is(err, river.ErrNotFound) {
+			return echo.ErrNotFound
+		}
+
+		slog.ErrorContext(ctx.Request().Context(), "could not cancel job", "error", err, "job_id", payload.ID)
+		return a.InternalError(ctx)
+	}
+
+	return a.RedirectHx(ctx.Response(), fmt.Sprintf("/admin/jobs/%d", payload.ID))
+}
+
+func (a *AdminJobs) Delete(ctx echo.Context) error {
+	var payload JobPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	if err := a.db.DeleteJob(ctx.Request().Context(), payload.ID); err != nil {
+		if errors.Is(err, psql.ErrNoRowWithIdentifier) {
+			return echo.NewHTTPError(http.StatusConflict, "job is running or does not exist")
+		}
+
+		slog.ErrorContext(ctx.Request().Context(), "could not delete job", "error", err, "job_id", payload.ID)
+		return a.InternalError(ctx)
+	}
+
+	return a.RedirectHx(ctx.Response(), "/admin/jobs")
+}
+
+type QueuePayload struct {
+	Name string `param:"name"`
+}
+
+func (a *AdminJobs) PauseQueue(ctx echo.Context) error {
+	var payload QueuePayload
+	if err := ctx.Bind(&payload); err != nil || payload.Name == "" {
+		return echo.ErrNotFound
+	}
+
+	if err := a.db.PauseRiverQueue(ctx.Request().Context(), payload.Name); err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not pause queue", "error", err, "queue", payload.Name)
+		return a.InternalError(ctx)
+	}
+
+	return a.RedirectHx(ctx.Response(), "/admin/jobs")
+}
+
+func (a *AdminJobs) ResumeQueue(ctx echo.Context) error {
+	var payload QueuePayload
+	if err := ctx.Bind(&payload); err != nil || payload.Name == "" {
+		return echo.ErrNotFound
+	}
+
+	if err := a.db.ResumeRiverQueue(ctx.Request().Context(), payload.Name); err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not resume queue", "error", err, "queue", payload.Name)
+		return a.InternalError(ctx)
+	}
+
+	return a.RedirectHx(ctx.Response(), "/admin/jobs")
+}
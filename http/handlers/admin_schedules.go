@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gorilla/csrf"
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/views"
+	"github.com/mbvlabs/grafto/views/admin"
+)
+
+type SchedulePayload struct {
+	Name string `param:"name"`
+}
+
+func (a *AdminJobs) Schedules(ctx echo.Context) error {
+	states, err := a.db.QueryJobScheduleStates(ctx.Request().Context())
+	if err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not query job schedules", "error", err)
+		return a.InternalError(ctx)
+	}
+
+	stateByName := make(map[string]models.JobScheduleState, len(states))
+	for _, state := range states {
+		stateByName[state.Name] = state
+	}
+
+	now := time.Now()
+	jobs := a.scheduler.Jobs()
+	rows := make([]admin.ScheduleRow, len(jobs))
+	for i, job := range jobs {
+		state, ok := stateByName[job.Name]
+		if !ok {
+			state = models.JobScheduleState{Name: job.Name, Enabled: true}
+		}
+
+		row := admin.ScheduleRow{
+			Name:             job.Name,
+			Kind:             job.Args.Kind(),
+			Spec:             job.Spec,
+			Overridden:       job.Overridden,
+			DisabledInConfig: job.Disabled,
+			State:            state,
+		}
+		if !job.Disabled && state.Enabled {
+			row.NextRunAt = job.NextRun(state.LastRunAt, now)
+		}
+		rows[i] = row
+	}
+
+	return admin.SchedulesPage(admin.SchedulesPageProps{
+		CsrfToken: csrf.Token(ctx.Request()),
+		Rows:      rows,
+	}).Render(views.ExtractRenderDeps(ctx))
+}
+
+func (a *AdminJobs) EnableSchedule(ctx echo.Context) error {
+	return a.setScheduleEnabled(ctx, true)
+}
+
+func (a *AdminJobs) DisableSchedule(ctx echo.Context) error {
+	return a.setScheduleEnabled(ctx, false)
+}
+
+func (a *AdminJobs) setScheduleEnabled(ctx echo.Context, enabled bool) error {
+	var payload SchedulePayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	if _, err := a.scheduler.Job(payload.Name); err != nil {
+		return echo.ErrNotFound
+	}
+
+	if err := a.db.SetJobScheduleEnabled(ctx.Request().Context(), payload.Name, enabled); err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not update job schedule", "error", err, "schedule", payload.Name)
+		return a.InternalError(ctx)
+	}
+
+	return a.RedirectHx(ctx.Response(), "/admin/schedules")
+}
+
+// TriggerSchedule enqueues the job of a schedule right away, even when the
+// schedule is disabled.
+func (a *AdminJobs) TriggerSchedule(ctx echo.Context) error {
+	var payload SchedulePayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	job, err := a.scheduler.Trigger(ctx.Request().Context(), a.queueClient, payload.Name)
+	if err != nil {
+		if errors.Is(err, queue.ErrUnknownSchedule) {
+			return echo.ErrNotFound
+		}
+
+		slog.ErrorContext(ctx.Request().Context(), "could not trigger job schedule", "error", err, "schedule", payload.Name)
+		return a.InternalError(ctx)
+	}
+
+	return a.RedirectHx(ctx.Response(), fmt.Sprintf("/admin/jobs/%d", job.ID))
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/csrf"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/pkg/pagination"
+	"github.com/mbvlabs/grafto/pkg/validation"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/mbvlabs/grafto/views"
+	"github.com/mbvlabs/grafto/views/admin"
+)
+
+type AdminUsers struct {
+	Base
+	userModel models.UserService
+}
+
+func NewAdminUsers(base Base, userSvc models.UserService) AdminUsers {
+	return AdminUsers{base, userSvc}
+}
+
+// Index lists the users with numbered pages, which the JSON API pages through
+// with cursors instead.
+func (a *AdminUsers) Index(ctx echo.Context) error {
+	spec := psql.UserListSpec
+	spec.Mode = pagination.Offset
+
+	params, err := bindPagination(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	page, err := a.db.ListUsers(ctx.Request().Context(), params)
+	if err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not list users", "error", err)
+		return a.InternalError(ctx)
+	}
+
+	table := admin.UsersTableProps{
+		CsrfToken: csrf.Token(ctx.Request()),
+		Users:     page.Items,
+		Meta:      page.Meta,
+		Links:     page.Links(ctx.Request().URL.Path),
+	}
+
+	// filtering, sorting and paging only swap the table
+	if ctx.Request().Header.Get("HX-Target") == "users-table" {
+		return admin.UsersTable(table).Render(views.ExtractRenderDeps(ctx))
+	}
+
+	return admin.UsersPage(admin.UsersPageProps{
+		Filters: admin.UserFilters{
+			Name:    ctx.QueryParam("name"),
+			Email:   ctx.QueryParam("email"),
+			IsAdmin: ctx.QueryParam("is_admin"),
+			Sort:    params.Sort.String(),
+		},
+		Table: table,
+	}).Render(views.ExtractRenderDeps(ctx))
+}
+
+func (a *AdminUsers) Edit(ctx echo.Context) error {
+	var payload UserPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	user, err := a.db.QueryUserByID(ctx.Request().Context(), payload.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return echo.ErrNotFound
+		}
+
+		slog.ErrorContext(ctx.Request().Context(), "could not query user", "error", err, "user_id", payload.ID)
+		return a.InternalError(ctx)
+	}
+
+	return admin.UserEditPage(admin.UserFormProps{
+		CsrfToken: csrf.Token(ctx.Request()),
+		User:      user,
+		Fields: map[string]views.InputFieldProps{
+			admin.UserNameField:  {Value: user.Name},
+			admin.UserEmailField: {Value: user.Email},
+		},
+	}).Render(views.ExtractRenderDeps(ctx))
+}
+
+type UpdateUserFormPayload struct {
+	ID      uuid.UUID `param:"id"`
+	Name    string    `form:"name"`
+	Email   string    `form:"email"`
+	Version int64     `form:"version"`
+}
+
+/*
+Update saves the form if the user has not changed since the form was opened.
+If it has, the form is shown again with what was entered and a message to
+reload, rather than overwriting the change someone else made.
+*/
+func (a *AdminUsers) Update(ctx echo.Context) error {
+	var payload UpdateUserFormPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	props := admin.UserFormProps{
+		CsrfToken: csrf.Token(ctx.Request()),
+		User:      models.User{ID: payload.ID, Version: payload.Version},
+		Fields: map[string]views.InputFieldProps{
+			admin.UserNameField:  {Value: payload.Name},
+			admin.UserEmailField: {Value: payload.Email},
+		},
+	}
+
+	_, err := a.userModel.Update(ctx.Request().Context(), models.UpdateUserData{
+		ID:        payload.ID,
+		UpdatedAt: time.Now(),
+		Name:      payload.Name,
+		Email:     payload.Email,
+		Version:   payload.Version,
+	})
+	if err == nil {
+		return a.RedirectHx(ctx.Response(), "/admin/users")
+	}
+
+	var valiErr validation.ValidationErrors
+	switch {
+	case errors.Is(err, models.ErrEditConflict):
+		props.Conflict = true
+	case errors.Is(err, psql.ErrNoRowWithIdentifier):
+		return echo.ErrNotFound
+	case errors.As(err, &valiErr):
+		for _, validationError := range valiErr {
+			field := ""
+			switch validationError.GetFieldName() {
+			case "Name":
+				field = admin.UserNameField
+			case "Email":
+				field = admin.UserEmailField
+			}
+
+			if entry, ok := props.Fields[field]; ok {
+				entry.ErrorMsgs = validationError.GetHumanExplanations()
+				props.Fields[field] = entry
+			}
+		}
+	default:
+		props.InternalError = true
+	}
+
+	return admin.UserForm(props).Render(views.ExtractRenderDeps(ctx))
+}
+
+// Search shows the users that match the q query parameter, best match first
+// and with the matching parts highlighted.
+func (a *AdminUsers) Search(ctx echo.Context) error {
+	search, err := bindUserSearch(ctx)
+	if err != nil {
+		return err
+	}
+	if search.Query == "" {
+		if ctx.Request().Header.Get("HX-Request") == "true" {
+			return a.RedirectHx(ctx.Response(), "/admin/users")
+		}
+
+		return a.Redirect(ctx.Response(), ctx.Request(), "/admin/users")
+	}
+
+	results, err := a.db.SearchUsers(ctx.Request().Context(), search.Query, search.Limit)
+	if err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not search users", "error", err)
+		return a.InternalError(ctx)
+	}
+
+	props := admin.UserSearchProps{
+		CsrfToken: csrf.Token(ctx.Request()),
+		Query:     search.Query,
+		Results:   results,
+	}
+
+	if ctx.Request().Header.Get("HX-Target") == "users-table" {
+		return admin.UserSearchResults(props).Render(views.ExtractRenderDeps(ctx))
+	}
+
+	return admin.UserSearchPage(props).Render(views.ExtractRenderDeps(ctx))
+}
+
+// Deleted lists the users that are deleted but not purged yet, which can be
+// restored from there.
+func (a *AdminUsers) Deleted(ctx echo.Context) error {
+	params, err := bindPagination(ctx, psql.DeletedUserListSpec)
+	if err != nil {
+		return err
+	}
+
+	page, err := a.db.ListDeletedUsers(ctx.Request().Context(), params)
+	if err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not list deleted users", "error", err)
+		return a.InternalError(ctx)
+	}
+
+	table := admin.UsersTableProps{
+		CsrfToken: csrf.Token(ctx.Request()),
+		Users:     page.Items,
+		Meta:      page.Meta,
+		Links:     page.Links(ctx.Request().URL.Path),
+		Deleted:   true,
+	}
+
+	if ctx.Request().Header.Get("HX-Target") == "users-table" {
+		return admin.UsersTable(table).Render(views.ExtractRenderDeps(ctx))
+	}
+
+	return admin.DeletedUsersPage(admin.UsersPageProps{
+		Filters: admin.UserFilters{
+			Name:  ctx.QueryParam("name"),
+			Email: ctx.QueryParam("email"),
+			Sort:  params.Sort.String(),
+		},
+		Table: table,
+	}).Render(views.ExtractRenderDeps(ctx))
+}
+
+func (a *AdminUsers) Delete(ctx echo.Context) error {
+	var payload UserPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	if err := a.userModel.Delete(ctx.Request().Context(), payload.ID); err != nil {
+		if errors.Is(err, psql.ErrNoRowWithIdentifier) {
+			return echo.ErrNotFound
+		}
+
+		return a.InternalError(ctx)
+	}
+
+	return a.RedirectHx(ctx.Response(), "/admin/users")
+}
+
+func (a *AdminUsers) Restore(ctx echo.Context) error {
+	var payload UserPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	err := a.userModel.Restore(ctx.Request().Context(), payload.ID)
+	switch {
+	case errors.Is(err, models.ErrUserNotRestorable):
+		return echo.NewHTTPError(http.StatusConflict, "user is not deleted or past the retention period")
+	case errors.Is(err, models.ErrUserAlreadyExists):
+		return echo.NewHTTPError(http.StatusConflict, "email has been registered by another user")
+	case err != nil:
+		return a.InternalError(ctx)
+	}
+
+	return a.RedirectHx(ctx.Response(), "/admin/users/deleted")
+}
@@ -1,17 +1,247 @@
 package handlers
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/psql"
 )
 
-type Api struct{}
+type Api struct {
+	Base
+	userModel models.UserService
+}
 
-func NewApi() Api {
-	return Api{}
+func NewApi(base Base, userSvc models.UserService) Api {
+	return Api{base, userSvc}
 }
 
 func (a *Api) AppHealth(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, "app is healthy and running")
 }
+
+type UserResponse struct {
+	ID              uuid.UUID  `json:"id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Locale          string     `json:"locale"`
+	IsAdmin         bool       `json:"is_admin"`
+	Version         int64      `json:"version"`
+}
+
+func newUserResponse(user models.User) UserResponse {
+	response := UserResponse{
+		ID:        user.ID,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Name:      user.Name,
+		Email:     user.Email,
+		Locale:    user.Locale,
+		IsAdmin:   user.IsAdmin,
+		Version:   user.Version,
+	}
+	if user.IsVerified() {
+		response.EmailVerifiedAt = &user.EmailVerifiedAt
+	}
+
+	return response
+}
+
+// Users lists users a page at a time, with the pages around it in the Link
+// header and the number of users matching the filters in X-Total-Count.
+func (a *Api) Users(ctx echo.Context) error {
+	params, err := bindPagination(ctx, psql.UserListSpec)
+	if err != nil {
+		return err
+	}
+
+	page, err := a.db.ListUsers(ctx.Request().Context(), params)
+	if err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not list users", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	users := make([]UserResponse, len(page.Items))
+	for i, user := range page.Items {
+		users[i] = newUserResponse(user)
+	}
+
+	setPaginationHeaders(ctx, page.Meta)
+	return ctx.JSON(http.StatusOK, users)
+}
+
+// SearchUsers finds users by partial or misspelled name or email, best match
+// first, see psql.Postgres.SearchUsers.
+func (a *Api) SearchUsers(ctx echo.Context) error {
+	search, err := bindUserSearch(ctx)
+	if err != nil {
+		return err
+	}
+	if search.Query == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "q is required")
+	}
+
+	results, err := a.db.SearchUsers(ctx.Request().Context(), search.Query, search.Limit)
+	if err != nil {
+		slog.ErrorContext(ctx.Request().Context(), "could not search users", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	response := make([]UserSearchResponse, len(results))
+	for i, result := range results {
+		response[i] = newUserSearchResponse(result)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+type CreateUserPayload struct {
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirm_password"`
+}
+
+/*
+CreateUser registers a user on behalf of an admin and returns it with its
+ETag. Send an Idempotency-Key to retry the request safely, a retry with the
+same key gets the user created by the first request back instead of a 409
+for the email that is taken by then.
+*/
+func (a *Api) CreateUser(ctx echo.Context) error {
+	var payload CreateUserPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	now := time.Now()
+	user, err := a.userModel.New(ctx.Request().Context(), models.CreateUserData{
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Name:            payload.Name,
+		Email:           payload.Email,
+		Password:        payload.Password,
+		ConfirmPassword: payload.ConfirmPassword,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrFailValidation):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		case errors.Is(err, models.ErrUserAlreadyExists):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		default:
+			return echo.ErrInternalServerError
+		}
+	}
+
+	ctx.Response().Header().Set("ETag", userETag(user))
+	return ctx.JSON(http.StatusCreated, newUserResponse(user))
+}
+
+type UserPayload struct {
+	ID uuid.UUID `param:"id"`
+}
+
+// User returns the user with its version as the ETag, which UpdateUser takes
+// in If-Match.
+func (a *Api) User(ctx echo.Context) error {
+	var payload UserPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.ErrNotFound
+	}
+
+	user, err := a.db.QueryUserByID(ctx.Request().Context(), payload.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return echo.ErrNotFound
+		}
+
+		slog.ErrorContext(ctx.Request().Context(), "could not query user", "error", err, "user_id", payload.ID)
+		return echo.ErrInternalServerError
+	}
+
+	ctx.Response().Header().Set("ETag", userETag(user))
+	return ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type UpdateUserPayload struct {
+	ID    uuid.UUID `param:"id"`
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+}
+
+type ConflictResponse struct {
+	Message string       `json:"message"`
+	Current UserResponse `json:"current"`
+}
+
+/*
+UpdateUser changes the name and email of a user. The request must send the
+ETag it read the user with in If-Match, and gets 409 Conflict with the current
+user and its ETag when someone else changed the user since. Send the changes as
+JSON, which needs no CSRF token, see http.Protect.
+*/
+func (a *Api) UpdateUser(ctx echo.Context) error {
+	var payload UpdateUserPayload
+	if err := ctx.Bind(&payload); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	version, ok, err := ifMatchVersion(ctx.Request().Header.Get("If-Match"))
+	if errors.Is(err, errMissingIfMatch) {
+		return echo.NewHTTPError(http.StatusPreconditionRequired, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if !ok {
+		current, err := a.db.QueryUserByID(ctx.Request().Context(), payload.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return echo.ErrNotFound
+		}
+		if err != nil {
+			slog.ErrorContext(ctx.Request().Context(), "could not query user", "error", err, "user_id", payload.ID)
+			return echo.ErrInternalServerError
+		}
+
+		version = current.Version
+	}
+
+	user, err := a.userModel.Update(ctx.Request().Context(), models.UpdateUserData{
+		ID:        payload.ID,
+		UpdatedAt: time.Now(),
+		Name:      payload.Name,
+		Email:     payload.Email,
+		Version:   version,
+	})
+	if err != nil {
+		var conflict models.UserConflictError
+		switch {
+		case errors.As(err, &conflict):
+			ctx.Response().Header().Set("ETag", userETag(conflict.Current))
+			return ctx.JSON(http.StatusConflict, ConflictResponse{
+				Message: "the user was changed after it was read, reload it and apply the change again",
+				Current: newUserResponse(conflict.Current),
+			})
+		case errors.Is(err, models.ErrFailValidation):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		case errors.Is(err, psql.ErrNoRowWithIdentifier):
+			return echo.ErrNotFound
+		default:
+			return echo.ErrInternalServerError
+		}
+	}
+
+	ctx.Response().Header().Set("ETag", userETag(user))
+	return ctx.JSON(http.StatusOK, newUserResponse(user))
+}
@@ -0,0 +1,175 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/http/handlers"
+	"github.com/mbvlabs/grafto/http/middleware"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/stretchr/testify/assert"
+)
+
+// userRow scans the columns of a users row in the order sqlc selects them.
+type userRow struct {
+	id      uuid.UUID
+	name    string
+	version int64
+}
+
+func (r userRow) Scan(dest ...any) error {
+	now := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	values := []any{
+		r.id, now, now, r.name, "jon@stark.com", pgtype.Timestamptz{}, "hashed", "en", false, r.version,
+		pgtype.Timestamptz{}, false,
+	}
+	if len(dest) != len(values) {
+		return errors.New("unexpected number of columns")
+	}
+
+	for i, value := range values {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+	}
+
+	return nil
+}
+
+// usersPool holds a single user and applies updates the way UpdateUser does,
+// only when the version matches.
+type usersPool struct {
+	psql.Pool
+	current  userRow
+	executed statements
+}
+
+func (p *usersPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	p.executed.record(sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (p *usersPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	p.executed.record(sql)
+
+	switch {
+	case strings.HasPrefix(sql, "-- name: UpdateUser "):
+		if args[4].(int64) != p.current.version {
+			return fakeRow{pgx.ErrNoRows}
+		}
+
+		p.current.name = args[2].(string)
+		p.current.version++
+		return p.current
+	case strings.HasPrefix(sql, "-- name: QueryUserByID "):
+		return p.current
+	default:
+		return fakeRow{pgx.ErrNoRows}
+	}
+}
+
+func TestUpdateUserIfMatch(t *testing.T) {
+	tests := map[string]struct {
+		ifMatch          string
+		expectedStatus   int
+		expectedETag     string
+		expectedName     string
+		expectedExecuted statements
+	}{
+		"should update the user when the version matches": {
+			ifMatch:          `"4"`,
+			expectedStatus:   http.StatusOK,
+			expectedETag:     `"5"`,
+			expectedName:     "Aegon Targaryen",
+			expectedExecuted: statements{"UpdateUser"},
+		},
+		"should update the current version with a wildcard": {
+			ifMatch:          `*`,
+			expectedStatus:   http.StatusOK,
+			expectedETag:     `"5"`,
+			expectedName:     "Aegon Targaryen",
+			expectedExecuted: statements{"QueryUserByID", "UpdateUser"},
+		},
+		"should conflict when the user changed since it was read": {
+			ifMatch:          `"3"`,
+			expectedStatus:   http.StatusConflict,
+			expectedETag:     `"4"`,
+			expectedName:     "Jon Snow",
+			expectedExecuted: statements{"UpdateUser", "QueryUserByID"},
+		},
+		"should require If-Match": {
+			expectedStatus: http.StatusPreconditionRequired,
+			expectedName:   "Jon Snow",
+		},
+		"should reject a weak ETag": {
+			ifMatch:        `W/"4"`,
+			expectedStatus: http.StatusBadRequest,
+			expectedName:   "Jon Snow",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			id := uuid.New()
+			pool := &usersPool{current: userRow{id, "Jon Snow", 4}}
+			db := psql.NewPostgres(pool)
+
+			var cfg config.Config
+			api := handlers.NewApi(
+				handlers.NewDependencies(cfg, db, handlers.NewCookieStore(""), nil, telemetry.Tracer{}),
+				models.NewUserService(db, nil),
+			)
+
+			req := httptest.NewRequest(
+				http.MethodPut,
+				"/api/v1/users/"+id.String(),
+				strings.NewReader(`{"name": "Aegon Targaryen", "email": "jon@stark.com"}`),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if test.ifMatch != "" {
+				req.Header.Set("If-Match", test.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			ctx := &middleware.UserContext{Context: e.NewContext(req, rec)}
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(id.String())
+
+			err := api.UpdateUser(ctx)
+
+			status := rec.Code
+			var httpErr *echo.HTTPError
+			if errors.As(err, &httpErr) {
+				status = httpErr.Code
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expectedStatus, status)
+			assert.Equal(t, test.expectedETag, rec.Header().Get("ETag"))
+			assert.Equal(t, test.expectedName, pool.current.name)
+			assert.Equal(t, test.expectedExecuted, pool.executed)
+
+			if test.expectedStatus == http.StatusConflict {
+				var body handlers.ConflictResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.Equal(t, int64(4), body.Current.Version)
+				assert.Equal(t, "Jon Snow", body.Current.Name)
+			}
+		})
+	}
+}
@@ -1,7 +1,15 @@
 package handlers
 
 import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
 	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/http/middleware"
+	"github.com/mbvlabs/grafto/pkg/i18n"
 	"github.com/mbvlabs/grafto/views"
 )
 
@@ -16,3 +24,65 @@ func NewApp(base Base) App {
 func (a *App) LandingPage(ctx echo.Context) error {
 	return views.HomePage().Render(views.ExtractRenderDeps(ctx))
 }
+
+type UpdateLocalePayload struct {
+	Locale string `form:"locale"`
+}
+
+func (a *App) UpdateLocale(ctx echo.Context) error {
+	var payload UpdateLocalePayload
+	if err := ctx.Bind(&payload); err != nil {
+		return a.InternalError(ctx)
+	}
+
+	locale := i18n.Match(payload.Locale).String()
+
+	ctx.SetCookie(&http.Cookie{
+		Name:     middleware.LocaleCookieName,
+		Value:    locale,
+		Path:     "/",
+		MaxAge:   365 * 86400,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	if userCtx, ok := ctx.(*middleware.UserContext); ok && userCtx.GetAuthStatus() {
+		if err := a.db.UpdateUserLocale(
+			ctx.Request().Context(),
+			userCtx.GetID(),
+			locale,
+			time.Now(),
+		); err != nil {
+			slog.ErrorContext(
+				ctx.Request().Context(),
+				"could not store user locale",
+				"error",
+				err,
+			)
+		}
+	}
+
+	return a.Redirect(ctx.Response(), ctx.Request(), localRedirect(ctx.Request().Referer()))
+}
+
+/*
+localRedirect returns the path and query of referer to redirect back to, or /
+when that would not stay on the site. A path that starts with // or /\ is
+taken by browsers as the address of another host, so keeping only the path is
+not enough to prevent an open redirect.
+*/
+func localRedirect(referer string) string {
+	parsed, err := url.Parse(referer)
+	if err != nil || parsed.Path == "" {
+		return "/"
+	}
+
+	target := parsed.RequestURI()
+	if !strings.HasPrefix(target, "/") ||
+		strings.HasPrefix(target, "//") ||
+		strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+
+	return target
+}
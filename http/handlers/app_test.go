@@ -0,0 +1,63 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/http/handlers"
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateLocaleRedirect(t *testing.T) {
+	tests := map[string]struct {
+		referer  string
+		expected string
+	}{
+		"should redirect back to the page of the referer": {
+			referer:  "https://grafto.com/dashboard?tab=jobs",
+			expected: "/dashboard?tab=jobs",
+		},
+		"should redirect home without a referer": {
+			referer:  "",
+			expected: "/",
+		},
+		"should not redirect to another host given as the path": {
+			referer:  "https://evil.com//evil.com/x",
+			expected: "/",
+		},
+		"should not redirect to another host given with a backslash": {
+			referer:  `https://evil.com/\evil.com/x`,
+			expected: "/%5Cevil.com/x",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := handlers.NewApp(handlers.NewDependencies(
+				config.Config{},
+				psql.NewPostgres(nil),
+				handlers.NewCookieStore(""),
+				nil,
+				telemetry.Tracer{},
+			))
+
+			req := httptest.NewRequest(http.MethodPost, "/locale", strings.NewReader("locale=da"))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			if test.referer != "" {
+				req.Header.Set("Referer", test.referer)
+			}
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, app.UpdateLocale(echo.New().NewContext(req, rec)))
+
+			assert.Equal(t, http.StatusSeeOther, rec.Code)
+			assert.Equal(t, test.expected, rec.Header().Get("Location"))
+		})
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/pkg/i18n"
 	"github.com/mbvlabs/grafto/pkg/validation"
+	"github.com/mbvlabs/grafto/psql"
 	"github.com/mbvlabs/grafto/services"
 	"github.com/mbvlabs/grafto/views"
 	"github.com/mbvlabs/grafto/views/authentication"
@@ -75,9 +77,15 @@ func (a *Authentication) StoreAuthenticatedSession(ctx echo.Context) error {
 
 		switch err {
 		case services.ErrPasswordNotMatch, services.ErrUserNotExist:
-			errors[authentication.ErrAuthDetailsWrong] = "The email or password you entered is incorrect."
+			errors[authentication.ErrAuthDetailsWrong] = i18n.T(
+				ctx.Request().Context(),
+				"auth.login.wrong_details",
+			)
 		case services.ErrEmailNotValidated:
-			errors[authentication.ErrEmailNotValidated] = "Your email has not yet been verified."
+			errors[authentication.ErrEmailNotValidated] = i18n.T(
+				ctx.Request().Context(),
+				"auth.login.email_not_verified",
+			)
 		}
 
 		return authentication.LoginForm(csrf.Token(ctx.Request()), false, errors).
@@ -137,16 +145,27 @@ func (a *Authentication) StorePasswordReset(ctx echo.Context) error {
 		}).
 			Render(views.ExtractRenderDeps(ctx))
 	}
-	resetToken, err := a.tknService.CreateResetPasswordToken(
-		ctx.Request().Context(),
-		user.ID,
-	)
-	if err != nil {
-		return err
-	}
+	if err := a.db.WithTx(ctx.Request().Context(), func(tx psql.Postgres) error {
+		resetToken, err := a.tknService.WithTx(tx).CreateResetPasswordToken(
+			ctx.Request().Context(),
+			user.ID,
+		)
+		if err != nil {
+			return err
+		}
 
-	if err := a.emailService.SendPasswordReset(ctx.Request().Context(), user.Email, resetToken, true); err != nil {
-		return err
+		return a.emailService.WithTx(tx).SendPasswordReset(
+			ctx.Request().Context(),
+			user.Email,
+			resetToken,
+			true,
+		)
+	}); err != nil {
+		return authentication.ForgottenPasswordForm(authentication.ForgottenPasswordFormProps{
+			CsrfToken:     csrf.Token(ctx.Request()),
+			InternalError: true,
+		}).
+			Render(views.ExtractRenderDeps(ctx))
 	}
 
 	return authentication.ForgottenPasswordForm(authentication.ForgottenPasswordFormProps{
@@ -197,14 +216,31 @@ func (a *Authentication) StoreResetPassword(ctx echo.Context) error {
 			Render(views.ExtractRenderDeps(ctx))
 	}
 
-	err = a.userModel.ChangePassword(ctx.Request().Context(),
-		models.ChangeUserPasswordData{
-			ID:              userID,
-			UpdatedAt:       time.Now(),
-			Password:        payload.Password,
-			ConfirmPassword: payload.ConfirmPassword,
-		},
-	)
+	// the token is only spent if the new password is stored
+	err = a.db.WithTx(ctx.Request().Context(), func(tx psql.Postgres) error {
+		if err := a.userModel.WithTx(tx).ChangePassword(ctx.Request().Context(),
+			models.ChangeUserPasswordData{
+				ID:              userID,
+				UpdatedAt:       time.Now(),
+				Password:        payload.Password,
+				ConfirmPassword: payload.ConfirmPassword,
+			},
+		); err != nil {
+			return err
+		}
+
+		if err := a.tknService.WithTx(tx).Delete(ctx.Request().Context(), payload.Token); err != nil {
+			slog.ErrorContext(
+				ctx.Request().Context(),
+				"could not delete reset password token",
+				"error",
+				err,
+			)
+			return err
+		}
+
+		return nil
+	})
 	if err != nil && errors.Is(err, models.ErrFailValidation) {
 		var valiErrs validation.ValidationErrors
 		if ok := errors.As(err, &valiErrs); !ok {
@@ -229,19 +265,9 @@ func (a *Authentication) StoreResetPassword(ctx echo.Context) error {
 			Render(views.ExtractRenderDeps(ctx))
 	}
 	if err != nil {
-		return a.InternalError(ctx)
-	}
-
-	if err := a.tknService.Delete(ctx.Request().Context(), payload.Token); err != nil {
 		ctx.Response().Writer.Header().Add("HX-Redirect", "/500")
 		ctx.Response().Writer.Header().Add("PreviousLocation", "/login")
 
-		slog.ErrorContext(
-			ctx.Request().Context(),
-			"could not query user",
-			"error",
-			err,
-		)
 		return a.InternalError(ctx)
 	}
 
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/mbvlabs/grafto/models"
+)
+
+var (
+	errMissingIfMatch = errors.New("If-Match header is required, use the ETag of the record")
+	errInvalidIfMatch = errors.New("If-Match header must hold a single strong ETag or *")
+)
+
+// userETag is the entity tag of a user, it changes with every version of the
+// user.
+func userETag(user models.User) string {
+	return strconv.Quote(strconv.FormatInt(user.Version, 10))
+}
+
+/*
+ifMatchVersion reads the version an update was made against from the If-Match
+header. A * matches any version, which is reported with ok false so the caller
+uses the current one. Weak tags are rejected since If-Match compares strongly.
+*/
+func ifMatchVersion(header string) (version int64, ok bool, err error) {
+	header = strings.TrimSpace(header)
+	switch {
+	case header == "":
+		return 0, false, errMissingIfMatch
+	case header == "*":
+		return 0, false, nil
+	}
+
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, false, errInvalidIfMatch
+	}
+
+	version, err = strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return 0, false, errInvalidIfMatch
+	}
+
+	return version, true, nil
+}
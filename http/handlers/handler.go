@@ -3,19 +3,18 @@ package handlers
 import (
 	"net/http"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/mbvlabs/grafto/config"
 	"github.com/mbvlabs/grafto/pkg/telemetry"
 	"github.com/mbvlabs/grafto/psql"
-	"github.com/riverqueue/river"
+	"github.com/mbvlabs/grafto/queue"
 )
 
 type Base struct {
 	cfg         config.Config
 	db          psql.Postgres
 	flashStore  FlashStorage
-	queueClient *river.Client[pgx.Tx]
+	queueClient *queue.Client
 	tracer      telemetry.Tracer
 }
 
@@ -23,7 +22,7 @@ func NewDependencies(
 	cfg config.Config,
 	db psql.Postgres,
 	flashStore FlashStorage,
-	queueClient *river.Client[pgx.Tx],
+	queueClient *queue.Client,
 	tracer telemetry.Tracer,
 ) Base {
 	return Base{
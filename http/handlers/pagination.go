@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/pkg/pagination"
+)
+
+const totalCountHeader = "X-Total-Count"
+
+// bindPagination reads the page of a list the request asks for from the query
+// string. Values the spec does not allow are a bad request.
+func bindPagination(ctx echo.Context, spec pagination.Spec) (pagination.Params, error) {
+	params, err := pagination.Parse(ctx.QueryParams(), spec)
+	if err != nil {
+		return pagination.Params{}, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return params, nil
+}
+
+// setPaginationHeaders links to the pages around meta and sets the total, so
+// clients of the JSON API can page through a list without a wrapping object.
+func setPaginationHeaders(ctx echo.Context, meta pagination.Meta) {
+	header := ctx.Response().Header()
+	if links := meta.Links(ctx.Request().URL.Path).Header(); links != "" {
+		header.Set("Link", links)
+	}
+	header.Set(totalCountHeader, strconv.FormatInt(meta.Total, 10))
+}
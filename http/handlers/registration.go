@@ -9,6 +9,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/mbvlabs/grafto/models"
 	"github.com/mbvlabs/grafto/pkg/validation"
+	"github.com/mbvlabs/grafto/psql"
 	"github.com/mbvlabs/grafto/services"
 	"github.com/mbvlabs/grafto/views"
 	"github.com/mbvlabs/grafto/views/authentication"
@@ -56,15 +57,39 @@ func (r *Registration) StoreUser(ctx echo.Context) error {
 			Render(views.ExtractRenderDeps(ctx))
 	}
 
+	// the user, the verification token and the welcome email job are
+	// committed together so a failure halfway does not leave a user behind
+	// that never receives the verification email
 	t := time.Now()
-	user, err := r.userModel.New(ctx.Request().Context(), models.CreateUserData{
-		ID:              uuid.New(),
-		CreatedAt:       t,
-		UpdatedAt:       t,
-		Name:            payload.UserName,
-		Email:           payload.Email,
-		Password:        payload.Password,
-		ConfirmPassword: payload.ConfirmPassword,
+	err := r.db.WithTx(ctx.Request().Context(), func(tx psql.Postgres) error {
+		user, err := r.userModel.WithTx(tx).New(
+			ctx.Request().Context(),
+			models.CreateUserData{
+				ID:              uuid.New(),
+				CreatedAt:       t,
+				UpdatedAt:       t,
+				Name:            payload.UserName,
+				Email:           payload.Email,
+				Password:        payload.Password,
+				ConfirmPassword: payload.ConfirmPassword,
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		emailActivationTkn, err := r.tknService.WithTx(tx).
+			CreateUserEmailVerification(ctx.Request().Context(), user.ID)
+		if err != nil {
+			return err
+		}
+
+		return r.emailService.WithTx(tx).SendUserSignupWelcome(
+			ctx.Request().Context(),
+			user.Email,
+			emailActivationTkn,
+			true,
+		)
 	})
 	if err != nil &&
 		errors.Is(err, models.ErrUserAlreadyExists) { // TODO handle this better
@@ -123,10 +148,6 @@ func (r *Registration) StoreUser(ctx echo.Context) error {
 			Render(views.ExtractRenderDeps(ctx))
 	}
 
-	emailActivationTkn, err := r.tknService.CreateUserEmailVerification(
-		ctx.Request().Context(),
-		user.ID,
-	)
 	if err != nil {
 		props := authentication.RegisterFormProps{
 			InternalError: true,
@@ -136,16 +157,6 @@ func (r *Registration) StoreUser(ctx echo.Context) error {
 			Render(views.ExtractRenderDeps(ctx))
 	}
 
-	if err := r.emailService.SendUserSignupWelcome(ctx.Request().Context(), user.Email, emailActivationTkn, true); err != nil {
-		props := authentication.RegisterFormProps{
-			InternalError: true,
-			CsrfToken:     csrf.Token(ctx.Request()),
-		}
-		return authentication.RegisterForm(props).
-			Render(views.ExtractRenderDeps(ctx))
-
-	}
-
 	props := authentication.RegisterFormProps{
 		SuccessRegister: true,
 		CsrfToken:       csrf.Token(ctx.Request()),
@@ -0,0 +1,217 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/http/handlers"
+	"github.com/mbvlabs/grafto/http/middleware"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/mbvlabs/grafto/services"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+	"github.com/stretchr/testify/assert"
+)
+
+var errQueueUnavailable = errors.New("queue unavailable")
+
+// statements records the sqlc query names, taken from the "-- name: X"
+// comment every generated query starts with.
+type statements []string
+
+func (s *statements) record(sql string) {
+	name, _, _ := strings.Cut(strings.TrimPrefix(sql, "-- name: "), " ")
+	*s = append(*s, name)
+}
+
+type fakeRow struct {
+	err error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	return r.err
+}
+
+// fakeTx embeds pgx.Tx so only the methods used by the flow need an
+// implementation; anything else panics.
+type fakeTx struct {
+	pgx.Tx
+	executed   statements
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *fakeTx) Exec(
+	ctx context.Context,
+	sql string,
+	args ...any,
+) (pgconn.CommandTag, error) {
+	tx.executed.record(sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (tx *fakeTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	tx.executed.record(sql)
+	if strings.HasPrefix(sql, "-- name: QueryUserByEmail") {
+		return fakeRow{pgx.ErrNoRows}
+	}
+
+	return fakeRow{}
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	tx.rolledBack = true
+	return nil
+}
+
+type fakePool struct {
+	tx       *fakeTx
+	executed statements
+}
+
+func (p *fakePool) Exec(
+	ctx context.Context,
+	sql string,
+	args ...any,
+) (pgconn.CommandTag, error) {
+	p.executed.record(sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (p *fakePool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	p.executed.record(sql)
+	return nil, errors.New("not implemented")
+}
+
+func (p *fakePool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	p.executed.record(sql)
+	return fakeRow{}
+}
+
+func (p *fakePool) Begin(ctx context.Context) (pgx.Tx, error) {
+	return p.tx, nil
+}
+
+type fakeQueue struct {
+	err      error
+	insertTx pgx.Tx
+}
+
+func (q *fakeQueue) Insert(
+	ctx context.Context,
+	args river.JobArgs,
+	opts *river.InsertOpts,
+) (*rivertype.JobRow, error) {
+	return nil, errors.New("jobs must be inserted in the transaction")
+}
+
+func (q *fakeQueue) InsertTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	args river.JobArgs,
+	opts *river.InsertOpts,
+) (*rivertype.JobRow, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	q.insertTx = tx
+	return &rivertype.JobRow{}, nil
+}
+
+func TestStoreUserIsAtomic(t *testing.T) {
+	tests := map[string]struct {
+		queueErr       error
+		wantCommitted  bool
+		wantRolledBack bool
+		wantJobInTx    bool
+	}{
+		"should commit the user, token and welcome email job together": {
+			queueErr:       nil,
+			wantCommitted:  true,
+			wantRolledBack: false,
+			wantJobInTx:    true,
+		},
+		"should roll back the user and token when the job cannot be enqueued": {
+			queueErr:       errQueueUnavailable,
+			wantCommitted:  false,
+			wantRolledBack: true,
+			wantJobInTx:    false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tx := &fakeTx{}
+			pool := &fakePool{tx: tx}
+			queue := &fakeQueue{err: test.queueErr}
+
+			var cfg config.Config
+			db := psql.NewPostgres(pool)
+			authSvc := services.NewAuth(db, sessions.NewCookieStore([]byte("session")), cfg)
+			registration := handlers.NewRegistration(
+				authSvc,
+				handlers.NewDependencies(
+					cfg,
+					db,
+					handlers.NewCookieStore(""),
+					nil,
+					telemetry.Tracer{},
+				),
+				models.NewUserService(db, authSvc),
+				*services.NewTokenSvc(db, "signing-key"),
+				services.NewEmailSvc(cfg, nil, queue, db),
+			)
+
+			form := url.Values{
+				"username":         {"Jon Snow"},
+				"email":            {"jon@stark.com"},
+				"password":         {"ghostthewolf"},
+				"confirm_password": {"ghostthewolf"},
+			}
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/user/store",
+				strings.NewReader(form.Encode()),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			ctx := &middleware.UserContext{Context: e.NewContext(req, rec)}
+
+			if err := registration.StoreUser(ctx); err != nil {
+				t.Fatalf("could not store user: %v", err)
+			}
+
+			assert.Equal(
+				t,
+				statements{"QueryUserByEmail", "InsertUser", "InsertToken"},
+				tx.executed,
+			)
+			assert.Empty(t, pool.executed, "no query may run outside the transaction")
+			assert.Equal(t, test.wantCommitted, tx.committed)
+			assert.Equal(t, test.wantRolledBack, tx.rolledBack)
+			if test.wantJobInTx {
+				assert.Same(t, tx, queue.insertTx)
+			}
+		})
+	}
+}
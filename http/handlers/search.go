@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/models"
+)
+
+const defaultUserSearchResults = 20
+
+type userSearch struct {
+	Query string
+	Limit int
+}
+
+// bindUserSearch reads a search for users from the q and limit query
+// parameters. A limit that is not a positive number is a bad request.
+func bindUserSearch(ctx echo.Context) (userSearch, error) {
+	search := userSearch{
+		Query: strings.TrimSpace(ctx.QueryParam("q")),
+		Limit: defaultUserSearchResults,
+	}
+
+	if limit := ctx.QueryParam("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 {
+			return userSearch{}, echo.NewHTTPError(http.StatusBadRequest, "limit must be a positive number")
+		}
+		search.Limit = parsed
+	}
+
+	return search, nil
+}
+
+type HighlightResponse struct {
+	Text  string `json:"text"`
+	Match bool   `json:"match"`
+}
+
+type UserSearchResponse struct {
+	User UserResponse `json:"user"`
+	Rank float32      `json:"rank"`
+	// Highlights has the name and email split into the parts that matched
+	// the search and the parts that did not
+	Highlights map[string][]HighlightResponse `json:"highlights"`
+}
+
+func newUserSearchResponse(result models.UserSearchResult) UserSearchResponse {
+	highlights := func(parts []models.Highlight) []HighlightResponse {
+		response := make([]HighlightResponse, len(parts))
+		for i, part := range parts {
+			response[i] = HighlightResponse{part.Text, part.Match}
+		}
+
+		return response
+	}
+
+	return UserSearchResponse{
+		User: newUserResponse(result.User),
+		Rank: result.Rank,
+		Highlights: map[string][]HighlightResponse{
+			"name":  highlights(result.NameHighlight),
+			"email": highlights(result.EmailHighlight),
+		},
+	}
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/models"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+
+	// keys of requests share the idempotency_keys table with the keys of
+	// jobs, see jobs.Idempotency
+	httpIdempotencyKeyPrefix = "http:"
+	// requests without a session share the keys of this principal
+	anonymousPrincipal      = "anonymous"
+	httpIdempotencyWindow   = 24 * time.Hour
+	maxIdempotencyKeyLength = 255
+)
+
+type idempotencyStorage interface {
+	ClaimIdempotencyKey(
+		ctx context.Context,
+		key string,
+		requestHash string,
+		expiresAt time.Time,
+	) (bool, error)
+	QueryIdempotencyKey(ctx context.Context, key string) (models.IdempotencyKey, error)
+	StoreIdempotentResponse(
+		ctx context.Context,
+		key string,
+		status int,
+		contentType string,
+		body []byte,
+	) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+}
+
+// recordingWriter keeps a copy of the response body so it can be replayed
+type recordingWriter struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *recordingWriter) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+/*
+Idempotent makes POST requests that carry an Idempotency-Key header safe to
+retry. The response to the first request with a key is stored and replayed to
+every retry with the same key for 24 hours, without running the handler
+again. Keys are scoped to the user of the session, so users that happen to
+send the same key never get each other's responses. A retry that arrives while
+the first request is still being handled gets a 409, and reusing a key for a
+different request gets a 422. Responses with a 5xx status are not stored so the
+request can be retried.
+*/
+func (m *Middleware) Idempotent(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		key := req.Header.Get(IdempotencyKeyHeader)
+		if req.Method != http.MethodPost || key == "" {
+			return next(c)
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return echo.NewHTTPError(http.StatusBadRequest, "Idempotency-Key is too long")
+		}
+
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "could not read request body")
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		storageKey := httpIdempotencyKeyPrefix + m.idempotencyPrincipal(req) + ":" + key
+		hash := requestHash(req, body)
+
+		claimed, err := m.storage.ClaimIdempotencyKey(
+			req.Context(),
+			storageKey,
+			hash,
+			time.Now().Add(httpIdempotencyWindow),
+		)
+		if err != nil {
+			slog.ErrorContext(req.Context(), "could not claim idempotency key", "error", err)
+			return echo.ErrInternalServerError
+		}
+
+		if !claimed {
+			return m.replay(c, storageKey, hash)
+		}
+
+		recorder := &recordingWriter{ResponseWriter: c.Response().Writer}
+		c.Response().Writer = recorder
+
+		if err := next(c); err != nil {
+			// let echo write the response so it is the one that is stored
+			c.Error(err)
+		}
+
+		// the request context may be cancelled by now, the outcome still has
+		// to be recorded
+		ctx := context.WithoutCancel(req.Context())
+
+		status := c.Response().Status
+		if status >= http.StatusInternalServerError {
+			if err := m.storage.DeleteIdempotencyKey(ctx, storageKey); err != nil {
+				slog.ErrorContext(ctx, "could not release idempotency key", "error", err)
+			}
+
+			return nil
+		}
+
+		if err := m.storage.StoreIdempotentResponse(
+			ctx,
+			storageKey,
+			status,
+			c.Response().Header().Get(echo.HeaderContentType),
+			recorder.body.Bytes(),
+		); err != nil {
+			slog.ErrorContext(ctx, "could not store idempotent response", "error", err)
+		}
+
+		return nil
+	}
+}
+
+func (m *Middleware) replay(c echo.Context, key string, hash string) error {
+	stored, err := m.storage.QueryIdempotencyKey(c.Request().Context(), key)
+	if err != nil {
+		// the key expired and was purged since it was claimed
+		if errors.Is(err, pgx.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusConflict, "retry the request")
+		}
+
+		slog.ErrorContext(c.Request().Context(), "could not query idempotency key", "error", err)
+		return echo.ErrInternalServerError
+	}
+
+	if stored.RequestHash != hash {
+		return echo.NewHTTPError(
+			http.StatusUnprocessableEntity,
+			"Idempotency-Key has already been used for a different request",
+		)
+	}
+
+	if !stored.HasResponse() {
+		return echo.NewHTTPError(
+			http.StatusConflict,
+			"a request with this Idempotency-Key is still being processed",
+		)
+	}
+
+	c.Response().Header().Set(idempotencyReplayedHeader, "true")
+
+	return c.Blob(stored.ResponseStatus, stored.ResponseContentType, stored.ResponseBody)
+}
+
+// idempotencyPrincipal identifies who sent the request, which is the user of
+// the session or anonymousPrincipal without one.
+func (m *Middleware) idempotencyPrincipal(req *http.Request) string {
+	sess, err := m.authSvc.GetUserSession(req)
+	if err != nil || !sess.Authenticated {
+		return anonymousPrincipal
+	}
+
+	return sess.ID.String()
+}
+
+func requestHash(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,261 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/http/middleware"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"github.com/mbvlabs/grafto/services"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStorage struct {
+	mu   sync.Mutex
+	keys map[string]models.IdempotencyKey
+	// userLocale is the locale of every user, userQueries counts the queries
+	// for users
+	userLocale  string
+	userQueries int
+	// deletedUsers are not found, like soft-deleted users
+	deletedUsers map[uuid.UUID]bool
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{keys: map[string]models.IdempotencyKey{}}
+}
+
+func (f *fakeStorage) QueryUserByID(ctx context.Context, id uuid.UUID) (models.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.userQueries++
+	if f.deletedUsers[id] {
+		return models.User{}, pgx.ErrNoRows
+	}
+	return models.User{ID: id, Locale: f.userLocale}, nil
+}
+
+func (f *fakeStorage) QueryUserByEmail(ctx context.Context, email string) (models.User, error) {
+	return models.User{Email: email}, nil
+}
+
+func (f *fakeStorage) ClaimIdempotencyKey(
+	ctx context.Context,
+	key string,
+	requestHash string,
+	expiresAt time.Time,
+) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if existing, ok := f.keys[key]; ok && existing.ExpiresAt.After(time.Now()) {
+		return false, nil
+	}
+
+	f.keys[key] = models.IdempotencyKey{Key: key, RequestHash: requestHash, ExpiresAt: expiresAt}
+	return true, nil
+}
+
+func (f *fakeStorage) QueryIdempotencyKey(ctx context.Context, key string) (models.IdempotencyKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing, ok := f.keys[key]
+	if !ok {
+		return models.IdempotencyKey{}, pgx.ErrNoRows
+	}
+
+	return existing, nil
+}
+
+func (f *fakeStorage) StoreIdempotentResponse(
+	ctx context.Context,
+	key string,
+	status int,
+	contentType string,
+	body []byte,
+) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	existing := f.keys[key]
+	existing.ResponseStatus = status
+	existing.ResponseContentType = contentType
+	existing.ResponseBody = body
+	f.keys[key] = existing
+
+	return nil
+}
+
+func (f *fakeStorage) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.keys, key)
+	return nil
+}
+
+type idempotentServer struct {
+	router  *echo.Echo
+	auth    services.Auth
+	storage *fakeStorage
+	calls   int
+	status  int
+	onCall  func()
+}
+
+func newIdempotentServer() *idempotentServer {
+	server := &idempotentServer{
+		router:  echo.New(),
+		storage: newFakeStorage(),
+		status:  http.StatusCreated,
+	}
+	server.auth = services.NewAuth(
+		server.storage,
+		sessions.NewCookieStore([]byte("session-key")),
+		config.Config{},
+	)
+
+	mw := middleware.NewMiddleware(server.auth, server.storage, telemetry.Tracer{})
+	api := server.router.Group("/api/v1", mw.Idempotent)
+	api.POST("/things", func(c echo.Context) error {
+		server.calls++
+		if server.onCall != nil {
+			server.onCall()
+		}
+		return c.JSON(server.status, map[string]int{"call": server.calls})
+	})
+
+	return server
+}
+
+// login returns the session cookie of userID.
+func (s *idempotentServer) login(t *testing.T, userID uuid.UUID) *http.Cookie {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	_, err := s.auth.NewUserSession(httptest.NewRequest(http.MethodGet, "/", nil), rec, userID)
+	assert.NoError(t, err)
+
+	cookies := rec.Result().Cookies()
+	assert.Len(t, cookies, 1)
+
+	return cookies[0]
+}
+
+func (s *idempotentServer) post(key string, body string) *httptest.ResponseRecorder {
+	return s.postAs(nil, key, body)
+}
+
+func (s *idempotentServer) postAs(
+	session *http.Cookie,
+	key string,
+	body string,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/things", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if session != nil {
+		req.AddCookie(session)
+	}
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestIdempotentReplaysResponse(t *testing.T) {
+	server := newIdempotentServer()
+
+	first := server.post("key-1", `{"name":"thing"}`)
+	second := server.post("key-1", `{"name":"thing"}`)
+
+	assert.Equal(t, 1, server.calls)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Header().Get(echo.HeaderContentType), second.Header().Get(echo.HeaderContentType))
+}
+
+func TestIdempotentKeyReusedByAnotherUser(t *testing.T) {
+	server := newIdempotentServer()
+	jon := server.login(t, uuid.New())
+	arya := server.login(t, uuid.New())
+
+	first := server.postAs(jon, "key-1", "")
+	second := server.postAs(arya, "key-1", "")
+	retry := server.postAs(jon, "key-1", "")
+
+	assert.Equal(t, 2, server.calls)
+	assert.Equal(t, `{"call":1}`, strings.TrimSpace(first.Body.String()))
+	assert.Equal(t, `{"call":2}`, strings.TrimSpace(second.Body.String()))
+	assert.Empty(t, second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotentWithoutKey(t *testing.T) {
+	server := newIdempotentServer()
+
+	server.post("", `{"name":"thing"}`)
+	server.post("", `{"name":"thing"}`)
+
+	assert.Equal(t, 2, server.calls)
+}
+
+func TestIdempotentKeyReusedForDifferentRequest(t *testing.T) {
+	server := newIdempotentServer()
+
+	server.post("key-1", `{"name":"thing"}`)
+	rec := server.post("key-1", `{"name":"other thing"}`)
+
+	assert.Equal(t, 1, server.calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestIdempotentRequestInProgress(t *testing.T) {
+	server := newIdempotentServer()
+
+	// the retry arrives while the first request is still being handled
+	var retry *httptest.ResponseRecorder
+	server.onCall = func() {
+		server.onCall = nil
+		retry = server.post("key-1", `{"name":"thing"}`)
+	}
+
+	first := server.post("key-1", `{"name":"thing"}`)
+
+	assert.Equal(t, 1, server.calls)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusConflict, retry.Code)
+}
+
+func TestIdempotentServerErrorCanBeRetried(t *testing.T) {
+	server := newIdempotentServer()
+
+	server.status = http.StatusInternalServerError
+	first := server.post("key-1", `{"name":"thing"}`)
+
+	server.status = http.StatusCreated
+	second := server.post("key-1", `{"name":"thing"}`)
+
+	assert.Equal(t, 2, server.calls)
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Equal(t, http.StatusCreated, second.Code)
+}
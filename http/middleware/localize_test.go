@@ -0,0 +1,107 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/http/middleware"
+	"github.com/mbvlabs/grafto/pkg/i18n"
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"github.com/mbvlabs/grafto/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalize(t *testing.T) {
+	tests := map[string]struct {
+		path                string
+		session             func(store *sessions.CookieStore) *http.Cookie
+		expectedLocale      string
+		expectedUserQueries int
+	}{
+		"should use the locale of the session without querying the user": {
+			path: "/dashboard",
+			session: func(store *sessions.CookieStore) *http.Cookie {
+				return sessionCookie(t, store, uuid.New(), "da")
+			},
+			expectedLocale:      "da",
+			expectedUserQueries: 0,
+		},
+		"should query the user for a session without a locale": {
+			path: "/dashboard",
+			session: func(store *sessions.CookieStore) *http.Cookie {
+				return sessionCookie(t, store, uuid.New(), nil)
+			},
+			expectedLocale:      "da",
+			expectedUserQueries: 1,
+		},
+		"should skip static files": {
+			path: "/static/css/output.css",
+			session: func(store *sessions.CookieStore) *http.Cookie {
+				return sessionCookie(t, store, uuid.New(), nil)
+			},
+			expectedLocale:      i18n.DefaultLocale,
+			expectedUserQueries: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := sessions.NewCookieStore([]byte("session-key"))
+			storage := newFakeStorage()
+			storage.userLocale = "da"
+
+			cfg := config.Config{App: config.App{ProjectName: "Grafto"}}
+			mw := middleware.NewMiddleware(
+				services.NewAuth(storage, store, cfg),
+				storage,
+				telemetry.Tracer{},
+			)
+
+			var locale string
+			router := echo.New()
+			router.Use(mw.Localize)
+			router.GET("/*", func(c echo.Context) error {
+				locale = i18n.FromContext(c.Request().Context()).Locale().String()
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.AddCookie(test.session(store))
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, test.expectedLocale, locale)
+			assert.Equal(t, test.expectedUserQueries, storage.userQueries)
+		})
+	}
+}
+
+// sessionCookie returns the cookie of an authenticated session of userID, with
+// locale stored in it unless it is nil.
+func sessionCookie(
+	t *testing.T,
+	store *sessions.CookieStore,
+	userID uuid.UUID,
+	locale any,
+) *http.Cookie {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	session, err := store.New(req, "grafto-ua")
+	assert.NoError(t, err)
+	session.Values["user_id"] = userID
+	session.Values["authenticated"] = true
+	session.Values["is_admin"] = false
+	if locale != nil {
+		session.Values["locale"] = locale
+	}
+	assert.NoError(t, session.Save(req, rec))
+
+	return rec.Result().Cookies()[0]
+}
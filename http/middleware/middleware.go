@@ -1,19 +1,138 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/pkg/i18n"
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"github.com/mbvlabs/grafto/psql"
 	"github.com/mbvlabs/grafto/services"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const LocaleCookieName = "locale"
+
+// staticPaths are served from files, see routes.NewRoutes
+var staticPaths = []string{"/static/", "/favicon.ico", "/robots.txt", "/sitemap.xml"}
+
+func isStaticPath(path string) bool {
+	for _, static := range staticPaths {
+		if strings.HasPrefix(path, static) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type userStorage interface {
+	QueryUserByID(ctx context.Context, id uuid.UUID) (models.User, error)
+}
+
+type storage interface {
+	userStorage
+	idempotencyStorage
+}
+
 type Middleware struct {
 	authSvc services.Auth
+	storage storage
+	tracer  telemetry.Tracer
+}
+
+func NewMiddleware(
+	authSvc services.Auth,
+	storage storage,
+	tracer telemetry.Tracer,
+) Middleware {
+	return Middleware{authSvc, storage, tracer}
+}
+
+/*
+Trace wraps the request in a span, continuing the trace of the caller if it
+sent a traceparent header. Jobs queued while handling the request pick up the
+span from the request context and join the same trace.
+*/
+func (m *Middleware) Trace(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		route := c.Path()
+		if route == "" {
+			route = req.URL.Path
+		}
+
+		ctx := telemetry.ExtractHTTP(req.Context(), req.Header)
+		ctx, span := m.tracer.CreateSpan(
+			ctx,
+			req.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", req.Method),
+				attribute.String("http.route", route),
+			),
+		)
+		defer span.End()
+
+		c.SetRequest(req.WithContext(ctx))
+
+		err := next(c)
+		if err != nil {
+			// let echo write the response so the status below is the real one
+			c.Error(err)
+		}
+
+		status := c.Response().Status
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		return nil
+	}
 }
 
-func NewMiddleware(authSvc services.Auth) Middleware {
-	return Middleware{authSvc}
+/*
+ReadYourWrites pins the reads of the request to the primary once it has
+written, so a handler that updates a row and renders it again does not show the
+stale row from a replica that has not caught up yet.
+*/
+func (m *Middleware) ReadYourWrites(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.SetRequest(
+			c.Request().WithContext(psql.WithReadYourWrites(c.Request().Context())),
+		)
+
+		return next(c)
+	}
+}
+
+/*
+isUserDeleted reports whether the user of a session has been deleted. Deleting
+a user leaves the cookies of their sessions valid, so the middleware that trust
+the session look the user up, and QueryUserByID does not find soft-deleted
+users.
+*/
+func (m *Middleware) isUserDeleted(ctx context.Context, id uuid.UUID) (bool, error) {
+	if _, err := m.storage.QueryUserByID(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return true, nil
+		}
+
+		return false, err
+	}
+
+	return false, nil
 }
 
 func (m *Middleware) AuthOnly(next echo.HandlerFunc) echo.HandlerFunc {
@@ -30,12 +149,27 @@ func (m *Middleware) AuthOnly(next echo.HandlerFunc) echo.HandlerFunc {
 			return c.Redirect(http.StatusPermanentRedirect, "/login")
 		}
 
-		if sess.Authenticated {
-			ctx := &UserContext{c, sess.ID, true}
-			return next(ctx)
-		} else {
+		if !sess.Authenticated {
 			return c.Redirect(http.StatusPermanentRedirect, "/login")
 		}
+
+		deleted, err := m.isUserDeleted(c.Request().Context(), sess.ID)
+		if err != nil {
+			slog.ErrorContext(
+				c.Request().Context(),
+				"could not query user of session",
+				"error",
+				err,
+			)
+
+			return c.Redirect(http.StatusPermanentRedirect, "/500")
+		}
+		if deleted {
+			return c.Redirect(http.StatusPermanentRedirect, "/login")
+		}
+
+		ctx := &UserContext{c, sess.ID, true}
+		return next(ctx)
 	}
 }
 
@@ -54,10 +188,25 @@ func (m *Middleware) RegisterUserContext(
 			return c.Redirect(http.StatusPermanentRedirect, "/500")
 		}
 
+		authenticated := sess.Authenticated
+		if authenticated {
+			deleted, err := m.isUserDeleted(c.Request().Context(), sess.ID)
+			if err != nil {
+				slog.ErrorContext(
+					c.Request().Context(),
+					"could not query user of session",
+					"error",
+					err,
+				)
+				return c.Redirect(http.StatusPermanentRedirect, "/500")
+			}
+			authenticated = !deleted
+		}
+
 		authContext := &UserContext{
 			c,
 			sess.ID,
-			sess.Authenticated,
+			authenticated,
 		}
 
 		return next(authContext)
@@ -71,7 +220,78 @@ func (m *Middleware) AdminOnly(next echo.HandlerFunc) echo.HandlerFunc {
 			return c.Redirect(http.StatusPermanentRedirect, "/500")
 		}
 
+		if !sess.Authenticated {
+			return c.Redirect(http.StatusPermanentRedirect, "/login")
+		}
+
+		deleted, err := m.isUserDeleted(c.Request().Context(), sess.ID)
+		if err != nil {
+			return c.Redirect(http.StatusPermanentRedirect, "/500")
+		}
+		if deleted {
+			return c.Redirect(http.StatusPermanentRedirect, "/login")
+		}
+
+		if !sess.IsAdmin {
+			return echo.ErrNotFound
+		}
+
 		ctx := &AdminContext{c, sess.IsAdmin}
 		return next(ctx)
 	}
 }
+
+/*
+Localize negotiates the locale for the request and puts a translator on the
+request context. An explicit choice stored in the locale cookie wins over the
+preference saved on the user, which in turn wins over Accept-Language. The
+preference of the user is read from the session, and only from the database
+for sessions that began before the session held it. Static files are served
+without a translator.
+*/
+func (m *Middleware) Localize(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if isStaticPath(c.Request().URL.Path) {
+			return next(c)
+		}
+
+		var cookieLocale string
+		if cookie, err := c.Cookie(LocaleCookieName); err == nil {
+			cookieLocale = cookie.Value
+		}
+
+		var userLocale string
+		if cookieLocale == "" {
+			sess, err := m.authSvc.GetUserSession(c.Request())
+			if err == nil && sess.Authenticated {
+				userLocale = sess.Locale
+			}
+			if err == nil && sess.Authenticated && userLocale == "" {
+				user, err := m.storage.QueryUserByID(c.Request().Context(), sess.ID)
+				if err != nil {
+					slog.ErrorContext(
+						c.Request().Context(),
+						"could not query user for locale",
+						"error",
+						err,
+					)
+				}
+				userLocale = user.Locale
+			}
+		}
+
+		locale := i18n.Match(
+			cookieLocale,
+			userLocale,
+			c.Request().Header.Get("Accept-Language"),
+		)
+
+		ctx := i18n.WithTranslator(
+			c.Request().Context(),
+			i18n.NewTranslator(locale.String()),
+		)
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		return next(c)
+	}
+}
@@ -0,0 +1,80 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/http/middleware"
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"github.com/mbvlabs/grafto/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionOfDeletedUser(t *testing.T) {
+	tests := map[string]struct {
+		deleted               bool
+		expectedStatus        int
+		expectedLocation      string
+		expectedAuthenticated bool
+	}{
+		"should let an existing user through": {
+			deleted:               false,
+			expectedStatus:        http.StatusOK,
+			expectedAuthenticated: true,
+		},
+		"should redirect a deleted user to login": {
+			deleted:               true,
+			expectedStatus:        http.StatusPermanentRedirect,
+			expectedLocation:      "/login",
+			expectedAuthenticated: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := sessions.NewCookieStore([]byte("session-key"))
+			storage := newFakeStorage()
+			userID := uuid.New()
+			storage.deletedUsers = map[uuid.UUID]bool{userID: test.deleted}
+
+			cfg := config.Config{App: config.App{ProjectName: "Grafto"}}
+			mw := middleware.NewMiddleware(
+				services.NewAuth(storage, store, cfg),
+				storage,
+				telemetry.Tracer{},
+			)
+
+			var authenticated bool
+			router := echo.New()
+			router.Use(mw.RegisterUserContext)
+			router.GET("/", func(c echo.Context) error {
+				authenticated = c.(*middleware.UserContext).GetAuthStatus()
+				return c.NoContent(http.StatusOK)
+			})
+			router.GET("/dashboard", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, mw.AuthOnly)
+
+			cookie := sessionCookie(t, store, userID, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+			req.AddCookie(cookie)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expectedStatus, rec.Code)
+			assert.Equal(t, test.expectedLocation, rec.Header().Get("Location"))
+
+			req = httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(cookie)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, test.expectedAuthenticated, authenticated)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/config"
+	graftohttp "github.com/mbvlabs/grafto/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtect(t *testing.T) {
+	tests := map[string]struct {
+		path           string
+		contentType    string
+		expectedStatus int
+	}{
+		"should let json requests to the api through without a token": {
+			path:           "/api/v1/users",
+			contentType:    echo.MIMEApplicationJSON,
+			expectedStatus: http.StatusOK,
+		},
+		"should let json requests with a charset to the api through": {
+			path:           "/api/v1/users",
+			contentType:    echo.MIMEApplicationJSONCharsetUTF8,
+			expectedStatus: http.StatusOK,
+		},
+		"should reject forms to the api without a token": {
+			path:           "/api/v1/users",
+			contentType:    echo.MIMEApplicationForm,
+			expectedStatus: http.StatusForbidden,
+		},
+		"should reject json requests outside the api without a token": {
+			path:           "/login",
+			contentType:    echo.MIMEApplicationJSON,
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			router := echo.New()
+			router.POST("/*", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			handler := graftohttp.Protect(router, config.Config{
+				Authentication: config.Authentication{CsrfToken: strings.Repeat("k", 32)},
+			})
+
+			req := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader("{}"))
+			req.Header.Set(echo.HeaderContentType, test.contentType)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
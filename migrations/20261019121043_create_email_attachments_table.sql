@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create table if not exists email_attachments (
+    id uuid not null,
+    primary key (id),
+    created_at timestamp with time zone not null,
+    filename text not null,
+    content_type text not null,
+    content bytea not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop table if exists email_attachments;
+-- +goose StatementEnd
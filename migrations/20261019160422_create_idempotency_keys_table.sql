@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create table if not exists idempotency_keys (
+    key text not null,
+    primary key (key),
+    created_at timestamp with time zone not null default now(),
+    expires_at timestamp with time zone not null,
+    request_hash text,
+    job_id bigint,
+    response_status integer,
+    response_content_type text,
+    response_body bytea
+);
+create index if not exists idempotency_keys_expires_at_idx on idempotency_keys (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop table if exists idempotency_keys;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create table if not exists dead_letter_jobs (
+    job_id bigint not null,
+    primary key (job_id),
+    kind text not null,
+    queue text not null,
+    args jsonb not null,
+    reason text not null,
+    error text not null,
+    attempt smallint not null,
+    failed_at timestamp with time zone not null default now(),
+    resolved_at timestamp with time zone
+);
+create index if not exists dead_letter_jobs_failed_at_idx on dead_letter_jobs (failed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop table if exists dead_letter_jobs;
+-- +goose StatementEnd
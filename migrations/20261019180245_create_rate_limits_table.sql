@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create table if not exists rate_limits (
+    name text not null,
+    primary key (name),
+    tokens double precision not null,
+    updated_at timestamp with time zone not null default now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop table if exists rate_limits;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create table if not exists job_schedules (
+    name text not null,
+    primary key (name),
+    enabled boolean not null default true,
+    last_job_id bigint,
+    last_run_at timestamp with time zone,
+    last_outcome text,
+    last_error text,
+    updated_at timestamp with time zone not null default now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop table if exists job_schedules;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+alter table users add column if not exists deleted_at timestamp with time zone;
+-- set when a user is deleted under a policy that lets its email be registered
+-- again before the user is purged
+alter table users add column if not exists email_released boolean not null default false;
+alter table users drop constraint if exists users_email_key;
+create unique index if not exists users_email_key on users (email) where not email_released;
+create index if not exists users_deleted_at_idx on users (deleted_at) where deleted_at is not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- fails while a released email has been registered again, purge the deleted
+-- user first
+drop index if exists users_deleted_at_idx;
+drop index if exists users_email_key;
+alter table users add constraint users_email_key unique (email);
+alter table users drop column if exists email_released;
+alter table users drop column if exists deleted_at;
+-- +goose StatementEnd
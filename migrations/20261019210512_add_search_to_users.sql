@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+create extension if not exists pg_trgm;
+-- emails are split on @ and . so their parts can be searched for as words
+alter table users add column if not exists search_vector tsvector generated always as (
+    setweight(to_tsvector('simple', name), 'A') ||
+    setweight(to_tsvector('simple', replace(replace(email, '@', ' '), '.', ' ')), 'B')
+) stored;
+create index if not exists users_search_vector_idx on users using gin (search_vector);
+create index if not exists users_name_trgm_idx on users using gin (name gin_trgm_ops);
+create index if not exists users_email_trgm_idx on users using gin (email gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop index if exists users_email_trgm_idx;
+drop index if exists users_name_trgm_idx;
+drop index if exists users_search_vector_idx;
+alter table users drop column if exists search_vector;
+-- +goose StatementEnd
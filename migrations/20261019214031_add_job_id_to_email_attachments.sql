@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+alter table email_attachments add column if not exists job_id bigint;
+create index if not exists email_attachments_created_at_idx on email_attachments (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop index if exists email_attachments_created_at_idx;
+alter table email_attachments drop column if exists job_id;
+-- +goose StatementEnd
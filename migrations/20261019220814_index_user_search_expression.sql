@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- the search vector is indexed as an expression instead of being stored, so
+-- reading users does not send it along
+drop index if exists users_search_vector_idx;
+alter table users drop column if exists search_vector;
+create index if not exists users_search_idx on users using gin ((
+    setweight(to_tsvector('simple', name), 'A') ||
+    setweight(to_tsvector('simple', replace(replace(email, '@', ' '), '.', ' ')), 'B')
+));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+drop index if exists users_search_idx;
+alter table users add column if not exists search_vector tsvector generated always as (
+    setweight(to_tsvector('simple', name), 'A') ||
+    setweight(to_tsvector('simple', replace(replace(email, '@', ' '), '.', ' ')), 'B')
+) stored;
+create index if not exists users_search_vector_idx on users using gin (search_vector);
+-- +goose StatementEnd
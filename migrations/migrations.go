@@ -0,0 +1,19 @@
+/*
+Package migrations embeds the sql migrations so the binaries can apply them,
+see psql/migrate.
+
+The schema of River is kept here as copies of its migrations, the
+create_riverqueue_v1 to v4 files, rather than being applied with rivermigrate.
+The rivermigrate of the River version in go.mod only ships versions 1 to 3 of
+the schema, while the copies are at version 4, and the copies never recorded
+themselves in river_migration, so rivermigrate would try to create the tables
+again on every existing database. A new version of the schema that comes with
+a River upgrade is added as another copy, with the sql of the matching
+rivermigrate migration.
+*/
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	// DeadLetterReasonPermanent is a job that failed with an error retrying
+	// can not fix.
+	DeadLetterReasonPermanent = "permanent_error"
+	// DeadLetterReasonExhausted is a job that failed every attempt it had.
+	DeadLetterReasonExhausted = "retries_exhausted"
+)
+
+type DeadLetterJob struct {
+	JobID      int64
+	Kind       string
+	Queue      string
+	Args       []byte
+	Reason     string
+	Error      string
+	Attempt    int
+	FailedAt   time.Time
+	ResolvedAt time.Time
+}
+
+func (d DeadLetterJob) IsResolved() bool {
+	return !d.ResolvedAt.IsZero()
+}
@@ -5,4 +5,24 @@ import "errors"
 var (
 	ErrFailValidation    = errors.New("the object failed validations")
 	ErrUserAlreadyExists = errors.New("an user with the provided email already exists")
+	ErrEditConflict      = errors.New("the record was changed after it was read")
+	ErrUserNotRestorable = errors.New("the user is not deleted or can no longer be restored")
 )
+
+/*
+UserConflictError is returned when a user is updated from a version that is no
+longer the current one, which means someone else changed the user in between
+and saving would overwrite their change. It matches ErrEditConflict and holds
+the user as it is now.
+*/
+type UserConflictError struct {
+	Current User
+}
+
+func (e UserConflictError) Error() string {
+	return ErrEditConflict.Error()
+}
+
+func (e UserConflictError) Is(target error) bool {
+	return target == ErrEditConflict
+}
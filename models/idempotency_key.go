@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type IdempotencyKey struct {
+	Key                 string
+	CreatedAt           time.Time
+	ExpiresAt           time.Time
+	RequestHash         string
+	JobID               int64
+	ResponseStatus      int
+	ResponseContentType string
+	ResponseBody        []byte
+}
+
+// HasResponse reports whether the request the key was claimed for has
+// finished and its response can be replayed.
+func (i IdempotencyKey) HasResponse() bool {
+	return i.ResponseStatus != 0
+}
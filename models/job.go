@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// JobStates lists the states a river job can be in, in the order they are
+// shown in the admin.
+var JobStates = []string{
+	"available",
+	"scheduled",
+	"running",
+	"retryable",
+	"pending",
+	"completed",
+	"cancelled",
+	"discarded",
+}
+
+type JobError struct {
+	At      time.Time `json:"at"`
+	Attempt int       `json:"attempt"`
+	Error   string    `json:"error"`
+	Trace   string    `json:"trace"`
+}
+
+type Job struct {
+	ID          int64
+	State       string
+	Queue       string
+	Kind        string
+	Priority    int
+	Attempt     int
+	MaxAttempts int
+	Args        []byte
+	Metadata    []byte
+	Errors      []JobError
+	AttemptedBy []string
+	Tags        []string
+	CreatedAt   time.Time
+	ScheduledAt time.Time
+	AttemptedAt time.Time
+	FinalizedAt time.Time
+}
+
+// IsFinalized reports whether river is done with the job, successfully or
+// not.
+func (j Job) IsFinalized() bool {
+	return j.State == "completed" || j.State == "cancelled" ||
+		j.State == "discarded"
+}
+
+type JobFilters struct {
+	State string
+	Queue string
+	Kind  string
+}
+
+type QueueStats struct {
+	Name     string
+	PausedAt time.Time
+	Counts   map[string]int64
+}
+
+func (q QueueStats) IsPaused() bool {
+	return !q.PausedAt.IsZero()
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// JobScheduleState is what is stored about a schedule between deploys, the
+// schedule itself is declared in code.
+type JobScheduleState struct {
+	Name        string
+	Enabled     bool
+	LastJobID   int64
+	LastRunAt   time.Time
+	LastOutcome string
+	LastError   string
+}
+
+// HasRun reports whether a job of the schedule has been worked.
+func (j JobScheduleState) HasRun() bool {
+	return !j.LastRunAt.IsZero()
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// UserSearchResult is a user found by a search, with how well it matched and
+// its name and email split up to highlight the matching parts.
+type UserSearchResult struct {
+	User User
+	// Rank is between 0 and 1, higher is a better match
+	Rank           float32
+	NameHighlight  []Highlight
+	EmailHighlight []Highlight
+}
+
+// Highlight is a part of a text, which either matched a search or not.
+type Highlight struct {
+	Text  string
+	Match bool
+}
+
+/*
+HighlightMatches splits text into the parts that contain a word of query and the
+parts in between, ignoring case. Query is read like websearch_to_tsquery reads
+it, so quotes, "or" and words excluded with a leading minus are left out. A
+text that only matched a search by similarity has no part to highlight and
+comes back whole.
+*/
+func HighlightMatches(text string, query string) []Highlight {
+	var words []string
+	for _, word := range strings.Fields(query) {
+		word = strings.Trim(word, `"`)
+		if word == "" || strings.HasPrefix(word, "-") || strings.EqualFold(word, "or") {
+			continue
+		}
+
+		words = append(words, regexp.QuoteMeta(word))
+	}
+	if len(words) == 0 || text == "" {
+		return []Highlight{{Text: text}}
+	}
+
+	// the longest first, so "jonathan" is highlighted whole when searching
+	// for "jon jonathan"
+	slices.SortFunc(words, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+
+	matches := regexp.MustCompile("(?i)"+strings.Join(words, "|")).FindAllStringIndex(text, -1)
+
+	var highlights []Highlight
+	last := 0
+	for _, match := range matches {
+		if match[0] > last {
+			highlights = append(highlights, Highlight{Text: text[last:match[0]]})
+		}
+		highlights = append(highlights, Highlight{Text: text[match[0]:match[1]], Match: true})
+		last = match[1]
+	}
+	if last < len(text) {
+		highlights = append(highlights, Highlight{Text: text[last:]})
+	}
+
+	return highlights
+}
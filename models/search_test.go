@@ -0,0 +1,70 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/mbvlabs/grafto/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightMatches(t *testing.T) {
+	tests := map[string]struct {
+		text     string
+		query    string
+		expected []models.Highlight
+	}{
+		"should highlight every word of the query ignoring case": {
+			text:  "Jon Snow",
+			query: "jon SNOW",
+			expected: []models.Highlight{
+				{Text: "Jon", Match: true},
+				{Text: " "},
+				{Text: "Snow", Match: true},
+			},
+		},
+		"should highlight partial matches in an email": {
+			text:  "jon@stark.com",
+			query: "stark",
+			expected: []models.Highlight{
+				{Text: "jon@"},
+				{Text: "stark", Match: true},
+				{Text: ".com"},
+			},
+		},
+		"should prefer the longest word": {
+			text:  "Jonathan",
+			query: "jon jonathan",
+			expected: []models.Highlight{
+				{Text: "Jonathan", Match: true},
+			},
+		},
+		"should skip excluded words, quotes and or": {
+			text:  "Arya or Sansa",
+			query: `"arya" or -sansa`,
+			expected: []models.Highlight{
+				{Text: "Arya", Match: true},
+				{Text: " or Sansa"},
+			},
+		},
+		"should treat the query as text rather than a pattern": {
+			text:  "a.b",
+			query: ".",
+			expected: []models.Highlight{
+				{Text: "a"},
+				{Text: ".", Match: true},
+				{Text: "b"},
+			},
+		},
+		"should return the text whole when only similar": {
+			text:     "Jon Snow",
+			query:    "jno",
+			expected: []models.Highlight{{Text: "Jon Snow"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, models.HighlightMatches(test.text, test.query))
+		})
+	}
+}
@@ -14,12 +14,22 @@ type User struct {
 	Name            string
 	Email           string
 	EmailVerifiedAt time.Time
+	Locale          string
+	IsAdmin         bool
+	// Version counts the changes to the user, see UserConflictError
+	Version int64
+	// DeletedAt is set on users that are deleted but not purged yet
+	DeletedAt time.Time
 }
 
 func (u User) IsVerified() bool {
 	return !u.EmailVerifiedAt.IsZero()
 }
 
+func (u User) IsDeleted() bool {
+	return !u.DeletedAt.IsZero()
+}
+
 type CreateUserData struct {
 	ID              uuid.UUID
 	CreatedAt       time.Time
@@ -53,6 +63,9 @@ type UpdateUserData struct {
 	UpdatedAt time.Time
 	Name      string
 	Email     string
+	// Version is the version of the user the change was made to, a version
+	// that was never read conflicts like an outdated one
+	Version int64
 }
 
 var UpdateUserValidations = func() map[string][]validation.Rule {
@@ -8,6 +8,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/pkg/i18n"
 	"github.com/mbvlabs/grafto/pkg/validation"
 )
 
@@ -27,6 +29,8 @@ type userStorage interface {
 		updatedAt time.Time,
 		email string,
 	) error
+	DeleteUser(ctx context.Context, id uuid.UUID, deletedAt time.Time, releaseEmail bool) error
+	RestoreUser(ctx context.Context, id uuid.UUID, restoredAt time.Time, deletedAfter time.Time) error
 }
 
 type authService interface {
@@ -36,10 +40,46 @@ type authService interface {
 type UserService struct {
 	storage userStorage
 	authSvc authService
+	// deletedUserRetention and emailRelease are the ones of config.Users
+	deletedUserRetention time.Duration
+	emailRelease         string
 }
 
-func NewUserService(storage userStorage, authSvc authService) UserService {
-	return UserService{storage, authSvc}
+type UserServiceOpts func(us *UserService)
+
+// WithDeletedUsers sets how long deleted users can be restored and when their
+// email is released, see config.Users. Without it users can be restored for
+// 30 days and keep their email until they are purged.
+func WithDeletedUsers(cfg config.Users) UserServiceOpts {
+	return func(us *UserService) {
+		us.deletedUserRetention = cfg.DeletedUserRetention
+		us.emailRelease = cfg.EmailRelease
+	}
+}
+
+func NewUserService(
+	storage userStorage,
+	authSvc authService,
+	opts ...UserServiceOpts,
+) UserService {
+	us := UserService{
+		storage:              storage,
+		authSvc:              authSvc,
+		deletedUserRetention: 30 * 24 * time.Hour,
+		emailRelease:         config.EmailReleasedOnPurge,
+	}
+	for _, opt := range opts {
+		opt(&us)
+	}
+
+	return us
+}
+
+// WithTx returns a copy of the service that uses the storage of an ongoing
+// transaction, see psql.Postgres.WithTx.
+func (us UserService) WithTx(storage userStorage) UserService {
+	us.storage = storage
+	return us
 }
 
 func (us UserService) ByEmail(ctx context.Context, email string) (User, error) {
@@ -55,7 +95,11 @@ func (us UserService) New(
 	ctx context.Context,
 	data CreateUserData,
 ) (User, error) {
-	if err := validation.ValidateStruct(data, CreateUserValidations(data.ConfirmPassword)); err != nil {
+	if err := validation.ValidateStruct(
+		data,
+		CreateUserValidations(data.ConfirmPassword),
+		validation.WithTranslator(i18n.FromContext(ctx)),
+	); err != nil {
 		return User{}, errors.Join(ErrFailValidation, err)
 	}
 
@@ -81,6 +125,9 @@ func (us UserService) New(
 		Name:      data.Name,
 		Email:     data.Email,
 	}, hashedPassword)
+	if errors.Is(err, ErrUserAlreadyExists) {
+		return User{}, err
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "could not insert user", "error", err)
 		return User{}, err
@@ -89,11 +136,19 @@ func (us UserService) New(
 	return newUser, nil
 }
 
+/*
+Update saves the changes in data, if data.Version is still the version of the
+user. Otherwise it fails with a UserConflictError, see ErrEditConflict.
+*/
 func (us UserService) Update(
 	ctx context.Context,
 	data UpdateUserData,
 ) (User, error) {
-	if err := validation.ValidateStruct(data, UpdateUserValidations()); err != nil {
+	if err := validation.ValidateStruct(
+		data,
+		UpdateUserValidations(),
+		validation.WithTranslator(i18n.FromContext(ctx)),
+	); err != nil {
 		return User{}, errors.Join(ErrFailValidation, err)
 	}
 
@@ -102,9 +157,13 @@ func (us UserService) Update(
 		UpdatedAt: data.UpdatedAt,
 		Name:      data.Name,
 		Email:     data.Email,
+		Version:   data.Version,
 	})
 	if err != nil {
-		slog.ErrorContext(ctx, "could not insert user", "error", err)
+		// a conflict is for the user to resolve, not an error of ours
+		if !errors.Is(err, ErrEditConflict) {
+			slog.ErrorContext(ctx, "could not update user", "error", err)
+		}
 		return User{}, err
 	}
 
@@ -112,7 +171,11 @@ func (us UserService) Update(
 }
 
 func (us UserService) ChangePassword(ctx context.Context, data ChangeUserPasswordData) error {
-	if err := validation.ValidateStruct(data, UpdateUserValidations()); err != nil {
+	if err := validation.ValidateStruct(
+		data,
+		UpdateUserValidations(),
+		validation.WithTranslator(i18n.FromContext(ctx)),
+	); err != nil {
 		return errors.Join(ErrFailValidation, err)
 	}
 
@@ -133,3 +196,34 @@ func (us UserService) VerifyEmail(ctx context.Context, email string) error {
 
 	return nil
 }
+
+// Delete soft deletes the user, releasing its email if the service is set up
+// to, see WithDeletedUsers.
+func (us UserService) Delete(ctx context.Context, id uuid.UUID) error {
+	releaseEmail := us.emailRelease == config.EmailReleasedOnDelete
+
+	if err := us.storage.DeleteUser(ctx, id, time.Now(), releaseEmail); err != nil {
+		slog.ErrorContext(ctx, "could not delete user", "error", err, "user_id", id)
+		return err
+	}
+
+	return nil
+}
+
+/*
+Restore brings back a deleted user, which is only possible within the retention
+set with WithDeletedUsers. It fails with ErrUserNotRestorable past that, and with
+ErrUserAlreadyExists if the email was released and registered again.
+*/
+func (us UserService) Restore(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+
+	err := us.storage.RestoreUser(ctx, id, now, now.Add(-us.deletedUserRetention))
+	if err != nil &&
+		!errors.Is(err, ErrUserNotRestorable) &&
+		!errors.Is(err, ErrUserAlreadyExists) {
+		slog.ErrorContext(ctx, "could not restore user", "error", err, "user_id", id)
+	}
+
+	return err
+}
@@ -0,0 +1,98 @@
+package models_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// deletionStorage records the calls to delete and restore users, anything
+// else is left to the nil interface and panics.
+type deletionStorage struct {
+	userStorage
+	releaseEmail bool
+	deletedAfter time.Time
+	restoreErr   error
+}
+
+// userStorage has the methods of the storage UserService depends on, so
+// deletionStorage only has to implement the ones the tests use.
+type userStorage interface {
+	InsertUser(ctx context.Context, arg models.User, hashedPassword string) (models.User, error)
+	QueryUserByEmail(ctx context.Context, mail string) (models.User, error)
+	QueryUserByID(ctx context.Context, id uuid.UUID) (models.User, error)
+	UpdateUser(ctx context.Context, arg models.User) (models.User, error)
+	UpdateUserPassword(ctx context.Context, userID uuid.UUID, newPassword string, updatedAt time.Time) error
+	VerifyUserEmail(ctx context.Context, updatedAt time.Time, email string) error
+}
+
+func (s *deletionStorage) DeleteUser(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAt time.Time,
+	releaseEmail bool,
+) error {
+	s.releaseEmail = releaseEmail
+	return nil
+}
+
+func (s *deletionStorage) RestoreUser(
+	ctx context.Context,
+	id uuid.UUID,
+	restoredAt time.Time,
+	deletedAfter time.Time,
+) error {
+	s.deletedAfter = deletedAfter
+	return s.restoreErr
+}
+
+func TestDeleteAndRestoreUser(t *testing.T) {
+	tests := map[string]struct {
+		opts                 []models.UserServiceOpts
+		restoreErr           error
+		expectedReleaseEmail bool
+		expectedRetention    time.Duration
+	}{
+		"should keep the email and restore for 30 days by default": {
+			expectedRetention: 30 * 24 * time.Hour,
+		},
+		"should release the email on delete when configured to": {
+			opts: []models.UserServiceOpts{models.WithDeletedUsers(config.Users{
+				DeletedUserRetention: time.Hour,
+				EmailRelease:         config.EmailReleasedOnDelete,
+			})},
+			expectedReleaseEmail: true,
+			expectedRetention:    time.Hour,
+		},
+		"should keep the email until the purge when configured to": {
+			opts: []models.UserServiceOpts{models.WithDeletedUsers(config.Users{
+				DeletedUserRetention: 7 * 24 * time.Hour,
+				EmailRelease:         config.EmailReleasedOnPurge,
+			})},
+			expectedRetention: 7 * 24 * time.Hour,
+		},
+		"should fail to restore a user past the retention": {
+			restoreErr:        models.ErrUserNotRestorable,
+			expectedRetention: 30 * 24 * time.Hour,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storage := &deletionStorage{restoreErr: test.restoreErr}
+			userSvc := models.NewUserService(storage, nil, test.opts...)
+
+			assert.NoError(t, userSvc.Delete(context.Background(), uuid.New()))
+			assert.Equal(t, test.expectedReleaseEmail, storage.releaseEmail)
+
+			err := userSvc.Restore(context.Background(), uuid.New())
+			assert.ErrorIs(t, err, test.restoreErr)
+			assert.WithinDuration(t, time.Now().Add(-test.expectedRetention), storage.deletedAfter, time.Minute)
+		})
+	}
+}
@@ -6,27 +6,21 @@ import (
 	"testing"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/mbvlabs/grafto/factory"
 	"github.com/mbvlabs/grafto/models"
 	"github.com/mbvlabs/grafto/pkg/validation"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestCreateUserValidations(t *testing.T) {
+	f := factory.New(1)
+
 	tests := map[string]struct {
 		data     models.CreateUserData
 		expected []error
 	}{
 		"should create a new user without failing validation": {
-			data: models.CreateUserData{
-				ID:              uuid.New(),
-				CreatedAt:       time.Now(),
-				UpdatedAt:       time.Now(),
-				Name:            "Jon Snow",
-				Email:           "knowseverything@gmail.com",
-				Password:        "yrgritte",
-				ConfirmPassword: "yrgritte",
-			},
+			data:     f.CreateUserData(),
 			expected: nil,
 		},
 		"should return fail validation with errors:'ErrIsRequired, ErrIsRequired, ErrValueTooShort, ErrInvalidEmail, ErrPasswordDontMatchConfirm'": {
@@ -101,17 +95,14 @@ func TestCreateUserValidations(t *testing.T) {
 }
 
 func TestUpdateUserValidations(t *testing.T) {
+	f := factory.New(1)
+
 	tests := map[string]struct {
 		data     models.UpdateUserData
 		expected []error
 	}{
 		"should update a new user without failing validation": {
-			data: models.UpdateUserData{
-				ID:        uuid.New(),
-				UpdatedAt: time.Now(),
-				Name:      "Jon Snow",
-				Email:     "knowseverything@gmail.com",
-			},
+			data:     f.UpdateUserData(f.User()),
 			expected: nil,
 		},
 		"should return fail validation with errors:'ErrIsRequired'": {
@@ -2,16 +2,89 @@ package awsses
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/mbvlabs/grafto/pkg/rawmail"
 	"github.com/mbvlabs/grafto/services"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+/*
+permanentErrorCodes are the ses error codes of messages that are rejected no
+matter how often they are sent, e.g. because of an invalid address or content.
+Throttling, a paused account or missing sender verification are resolved over
+time or by fixing the setup, so those are retried.
+*/
+var permanentErrorCodes = map[string]bool{
+	ses.ErrCodeMessageRejected: true,
+	"InvalidParameterValue":    true,
+}
+
+// errInvalidMessage is a message that could not be assembled from the payload,
+// e.g. because of a header with a line break, so sending it again fails too
+var errInvalidMessage = errors.New("could not build message")
+
+const (
+	throttlingErrorCode = "Throttling"
+	// dailyQuotaExceeded tells the daily sending quota apart from the per
+	// second sending rate, as both are reported as throttling
+	dailyQuotaExceeded = "Daily message quota exceeded"
+	// the sending rate is per second, so a short break is enough, while the
+	// daily quota frees up as the sends of the last 24 hours age out
+	sendingRateRetryAfter = 10 * time.Second
+	dailyQuotaRetryAfter  = time.Hour
+)
+
+// SendError is an email ses did not send, wrapping the error of the sdk.
+type SendError struct {
+	err error
+}
+
+func (e *SendError) Error() string { return e.err.Error() }
+
+func (e *SendError) Unwrap() error { return e.err }
+
+// Code is the ses error code, empty if the request never got a response.
+func (e *SendError) Code() string {
+	var aerr awserr.Error
+	if errors.As(e.err, &aerr) {
+		return aerr.Code()
+	}
+
+	return ""
+}
+
+// Permanent reports whether sending the same email again can not succeed.
+func (e *SendError) Permanent() bool {
+	return errors.Is(e.err, errInvalidMessage) || permanentErrorCodes[e.Code()]
+}
+
+// RetryAfter is how long to back off before sending again when ses throttled
+// the request, zero otherwise.
+func (e *SendError) RetryAfter() time.Duration {
+	if e.Code() != throttlingErrorCode {
+		return 0
+	}
+
+	var aerr awserr.Error
+	if errors.As(e.err, &aerr) && strings.Contains(aerr.Message(), dailyQuotaExceeded) {
+		return dailyQuotaRetryAfter
+	}
+
+	return sendingRateRetryAfter
+}
+
 type AwsSimpleEmailService struct {
 	client  *ses.SES
 	sender  string
@@ -23,17 +96,61 @@ func (a *AwsSimpleEmailService) SendEmail(
 	ctx context.Context,
 	payload services.EmailPayload,
 ) error {
-	from := payload.From
 	if payload.From == "" {
-		from = a.sender
+		payload.From = a.sender
+	}
+
+	// the span joins whatever trace the caller is in, e.g. the email job
+	ctx, span := trace.SpanFromContext(ctx).
+		TracerProvider().
+		Tracer("aws_ses").
+		Start(ctx, "ses.send", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	span.SetAttributes(
+		attribute.Int("email.recipients", len(payload.To)+len(payload.Cc)+len(payload.Bcc)),
+		attribute.Int("email.attachments", len(payload.Attachments)),
+	)
+
+	// SendEmail has no support for attachments or custom headers so those
+	// have to be assembled into a MIME message and sent raw
+	var err error
+	if len(payload.Attachments) > 0 || len(payload.Headers) > 0 {
+		err = a.sendRawEmail(ctx, payload)
+	} else {
+		err = a.sendEmail(ctx, payload)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		sendErr := &SendError{err: err}
+		slog.ErrorContext(
+			ctx,
+			"could not send email",
+			"error",
+			err,
+			"code",
+			sendErr.Code(),
+			"permanent",
+			sendErr.Permanent(),
+		)
+
+		return sendErr
 	}
+
+	return nil
+}
+
+func (a *AwsSimpleEmailService) sendEmail(
+	ctx context.Context,
+	payload services.EmailPayload,
+) error {
 	// Assemble the email.
 	input := &ses.SendEmailInput{
 		Destination: &ses.Destination{
-			CcAddresses: []*string{},
-			ToAddresses: []*string{
-				aws.String(payload.To),
-			},
+			ToAddresses:  aws.StringSlice(payload.To),
+			CcAddresses:  aws.StringSlice(payload.Cc),
+			BccAddresses: aws.StringSlice(payload.Bcc),
 		},
 		Message: &ses.Message{
 			Body: &ses.Body{
@@ -51,32 +168,34 @@ func (a *AwsSimpleEmailService) SendEmail(
 				Data:    aws.String(payload.Subject),
 			},
 		},
-		Source: aws.String(from),
+		ReplyToAddresses: aws.StringSlice(payload.ReplyTo),
+		Source:           aws.String(payload.From),
 	}
 
-	_, err := a.client.SendEmail(input)
+	_, err := a.client.SendEmailWithContext(ctx, input)
+	return err
+}
+
+func (a *AwsSimpleEmailService) sendRawEmail(
+	ctx context.Context,
+	payload services.EmailPayload,
+) error {
+	msg, err := rawmail.Build(payload)
 	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok {
-			switch aerr.Code() {
-			case ses.ErrCodeMessageRejected:
-				fmt.Println(ses.ErrCodeMessageRejected, aerr.Error())
-			case ses.ErrCodeMailFromDomainNotVerifiedException:
-				fmt.Println(ses.ErrCodeMailFromDomainNotVerifiedException, aerr.Error())
-			case ses.ErrCodeConfigurationSetDoesNotExistException:
-				fmt.Println(ses.ErrCodeConfigurationSetDoesNotExistException, aerr.Error())
-			default:
-				fmt.Println(aerr.Error())
-			}
-		} else {
-			// Print the error, cast err to awserr.Error to get the Code and
-			// Message from an error.
-			fmt.Println(err.Error())
-		}
-
-		return err
+		return fmt.Errorf("%w: %w", errInvalidMessage, err)
 	}
 
-	return nil
+	// bcc recipients are not part of the message headers so every recipient
+	// has to be listed explicitly
+	_, err = a.client.SendRawEmailWithContext(ctx, &ses.SendRawEmailInput{
+		Destinations: aws.StringSlice(payload.Recipients()),
+		RawMessage: &ses.RawMessage{
+			Data: msg,
+		},
+		Source: aws.String(payload.From),
+	})
+
+	return err
 }
 
 func New() AwsSimpleEmailService {
@@ -0,0 +1,88 @@
+package awsses_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ses"
+	awsses "github.com/mbvlabs/grafto/pkg/aws_ses"
+	"github.com/mbvlabs/grafto/pkg/rawmail"
+	"github.com/mbvlabs/grafto/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendErrorPermanent(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"should not retry a rejected message": {
+			err:      awserr.New(ses.ErrCodeMessageRejected, "Email address is not verified.", nil),
+			expected: true,
+		},
+		"should retry when throttled": {
+			err:      awserr.New("Throttling", "Maximum sending rate exceeded.", nil),
+			expected: false,
+		},
+		"should retry a paused account": {
+			err:      awserr.New(ses.ErrCodeAccountSendingPausedException, "Sending paused.", nil),
+			expected: false,
+		},
+		"should retry errors without a response": {
+			err:      errors.New("dial tcp: i/o timeout"),
+			expected: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, awsses.NewSendError(test.err).Permanent())
+		})
+	}
+}
+
+func TestSendErrorRetryAfter(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected time.Duration
+	}{
+		"should back off briefly when over the sending rate": {
+			err:      awserr.New("Throttling", "Maximum sending rate exceeded.", nil),
+			expected: 10 * time.Second,
+		},
+		"should back off for long when over the daily quota": {
+			err:      awserr.New("Throttling", "Daily message quota exceeded.", nil),
+			expected: time.Hour,
+		},
+		"should not hint on other errors": {
+			err:      awserr.New(ses.ErrCodeMessageRejected, "Email address is not verified.", nil),
+			expected: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, awsses.NewSendError(test.err).RetryAfter())
+		})
+	}
+}
+
+func TestSendEmailWithInvalidMessage(t *testing.T) {
+	var client awsses.AwsSimpleEmailService
+
+	err := client.SendEmail(context.Background(), services.EmailPayload{
+		To:       []string{"jon@stark.com"},
+		From:     "ned@stark.com",
+		Subject:  "Ticket",
+		TextBody: "Winter is coming",
+		Headers:  map[string]string{"X-Ticket-Id": "42\r\nBcc: spy@lannister.com"},
+	})
+
+	var sendErr *awsses.SendError
+	assert.True(t, errors.As(err, &sendErr))
+	assert.True(t, sendErr.Permanent(), "a message that can not be built is never sent")
+	assert.ErrorIs(t, err, rawmail.ErrInvalidHeader)
+}
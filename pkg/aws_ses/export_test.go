@@ -0,0 +1,5 @@
+package awsses
+
+func NewSendError(err error) *SendError {
+	return &SendError{err}
+}
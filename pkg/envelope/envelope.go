@@ -0,0 +1,143 @@
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidKey     = errors.New("key must be 32 bytes")
+	ErrUnknownKey     = errors.New("envelope is sealed with a key that is not in the keyring")
+	ErrMissingPrimary = errors.New("primary key is not in the keyring")
+	ErrMalformed      = errors.New("envelope is malformed")
+)
+
+const dataKeySize = 32
+
+/*
+Envelope is a value sealed with envelope encryption. The value is encrypted
+with a random data key using AES-256-GCM, and the data key is in turn
+encrypted with a key from the keyring, identified by KeyID. Rotating the
+keyring therefore never requires re-encrypting the values themselves.
+*/
+type Envelope struct {
+	KeyID      string `json:"kid"`
+	DataKey    []byte `json:"dek"`
+	Ciphertext []byte `json:"ct"`
+}
+
+/*
+Keyring holds the key encryption keys by id. New envelopes are sealed with the
+primary key while envelopes sealed with any key in the ring can be opened, so
+a key is rotated by adding a new primary and keeping the old key around until
+nothing sealed with it is left.
+*/
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+func NewKeyring(primary string, keys map[string][]byte) (*Keyring, error) {
+	keyring := &Keyring{
+		primary: primary,
+		keys:    make(map[string]cipher.AEAD, len(keys)),
+	}
+
+	for id, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+
+		keyring.keys[id] = aead
+	}
+
+	if _, ok := keyring.keys[primary]; !ok {
+		return nil, ErrMissingPrimary
+	}
+
+	return keyring, nil
+}
+
+func (k *Keyring) Seal(plaintext []byte) (Envelope, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return Envelope{}, err
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	ciphertext, err := seal(dataAEAD, plaintext)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	wrappedKey, err := seal(k.keys[k.primary], dataKey)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{
+		KeyID:      k.primary,
+		DataKey:    wrappedKey,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+func (k *Keyring) Open(envelope Envelope) ([]byte, error) {
+	keyAEAD, ok := k.keys[envelope.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, envelope.KeyID)
+	}
+
+	dataKey, err := open(keyAEAD, envelope.DataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(dataAEAD, envelope.Ciphertext)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != dataKeySize {
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal prefixes the ciphertext with the random nonce it was sealed with
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,93 @@
+package envelope_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mbvlabs/grafto/pkg/envelope"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	oldKey = bytes.Repeat([]byte{1}, 32)
+	newKey = bytes.Repeat([]byte{2}, 32)
+)
+
+func TestSealAndOpen(t *testing.T) {
+	keyring, err := envelope.NewKeyring("old", map[string][]byte{"old": oldKey})
+	assert.NoError(t, err)
+
+	sealed, err := keyring.Seal([]byte("https://grafto.com/reset-password?token=secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, "old", sealed.KeyID)
+	assert.NotContains(t, string(sealed.Ciphertext), "secret")
+
+	opened, err := keyring.Open(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://grafto.com/reset-password?token=secret", string(opened))
+}
+
+func TestRotation(t *testing.T) {
+	before, err := envelope.NewKeyring("old", map[string][]byte{"old": oldKey})
+	assert.NoError(t, err)
+	sealedBefore, err := before.Seal([]byte("before rotation"))
+	assert.NoError(t, err)
+
+	rotated, err := envelope.NewKeyring("new", map[string][]byte{
+		"old": oldKey,
+		"new": newKey,
+	})
+	assert.NoError(t, err)
+
+	sealedAfter, err := rotated.Seal([]byte("after rotation"))
+	assert.NoError(t, err)
+	assert.Equal(t, "new", sealedAfter.KeyID)
+
+	opened, err := rotated.Open(sealedBefore)
+	assert.NoError(t, err)
+	assert.Equal(t, "before rotation", string(opened))
+
+	retired, err := envelope.NewKeyring("new", map[string][]byte{"new": newKey})
+	assert.NoError(t, err)
+
+	_, err = retired.Open(sealedBefore)
+	assert.ErrorIs(t, err, envelope.ErrUnknownKey)
+}
+
+func TestOpenTampered(t *testing.T) {
+	keyring, err := envelope.NewKeyring("old", map[string][]byte{"old": oldKey})
+	assert.NoError(t, err)
+
+	sealed, err := keyring.Seal([]byte("value"))
+	assert.NoError(t, err)
+	sealed.Ciphertext[len(sealed.Ciphertext)-1] ^= 1
+
+	_, err = keyring.Open(sealed)
+	assert.Error(t, err)
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := map[string]struct {
+		primary     string
+		keys        map[string][]byte
+		expectedErr error
+	}{
+		"should reject keys that are not 256 bit": {
+			primary:     "short",
+			keys:        map[string][]byte{"short": []byte("too short")},
+			expectedErr: envelope.ErrInvalidKey,
+		},
+		"should reject a primary key that is not in the ring": {
+			primary:     "missing",
+			keys:        map[string][]byte{"old": oldKey},
+			expectedErr: envelope.ErrMissingPrimary,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := envelope.NewKeyring(test.primary, test.keys)
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+//go:embed locales/*.json
+var Locales embed.FS
+
+const DefaultLocale = "en"
+
+var (
+	ErrMalformedCatalogue = errors.New("the catalogue could not be parsed")
+	ErrUnknownPluralForm  = errors.New("unknown plural form in catalogue")
+)
+
+var pluralForms = map[string]plural.Form{
+	"zero":  plural.Zero,
+	"one":   plural.One,
+	"two":   plural.Two,
+	"few":   plural.Few,
+	"many":  plural.Many,
+	"other": plural.Other,
+}
+
+/*
+Message is a single entry in a catalogue. It is either a plain string or an
+object of CLDR plural forms, e.g. {"one": "%d day", "other": "%d days"}, where
+the first integer argument passed to T decides the form.
+*/
+type Message struct {
+	Forms map[plural.Form]string
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var plain string
+	if err := json.Unmarshal(data, &plain); err == nil {
+		m.Forms = map[plural.Form]string{plural.Other: plain}
+		return nil
+	}
+
+	var forms map[string]string
+	if err := json.Unmarshal(data, &forms); err != nil {
+		return errors.Join(ErrMalformedCatalogue, err)
+	}
+
+	m.Forms = make(map[plural.Form]string, len(forms))
+	for name, text := range forms {
+		form, ok := pluralForms[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownPluralForm, name)
+		}
+		m.Forms[form] = text
+	}
+
+	if _, ok := m.Forms[plural.Other]; !ok {
+		return fmt.Errorf("%w: 'other' form is required", ErrMalformedCatalogue)
+	}
+
+	return nil
+}
+
+func (m Message) IsPlural() bool {
+	return len(m.Forms) > 1
+}
+
+type Catalogue struct {
+	Tag      language.Tag
+	Messages map[string]Message
+}
+
+type Catalogues map[language.Tag]Catalogue
+
+/*
+LoadCatalogues reads every <locale>.json file in the locales directory of
+fsys. Keys missing from a locale fall back to the DefaultLocale catalogue.
+*/
+func LoadCatalogues(fsys fs.FS) (Catalogues, error) {
+	files, err := fs.ReadDir(fsys, "locales")
+	if err != nil {
+		return nil, err
+	}
+
+	catalogues := make(Catalogues, len(files))
+	for _, f := range files {
+		locale, ok := strings.CutSuffix(f.Name(), ".json")
+		if !ok {
+			continue
+		}
+
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join("locales", f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var messages map[string]Message
+		if err := json.Unmarshal(content, &messages); err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+
+		catalogues[tag] = Catalogue{tag, messages}
+	}
+
+	if _, ok := catalogues[language.Make(DefaultLocale)]; !ok {
+		return nil, fmt.Errorf(
+			"%w: missing default locale '%s'",
+			ErrMalformedCatalogue,
+			DefaultLocale,
+		)
+	}
+
+	return catalogues, nil
+}
+
+var (
+	catalogues Catalogues
+	supported  []language.Tag
+	matcher    language.Matcher
+)
+
+func init() {
+	var err error
+	catalogues, err = LoadCatalogues(Locales)
+	if err != nil {
+		panic(err)
+	}
+
+	// the default locale must come first as the matcher falls back to it
+	supported = append(supported, language.Make(DefaultLocale))
+	for tag := range catalogues {
+		if tag != supported[0] {
+			supported = append(supported, tag)
+		}
+	}
+
+	matcher = language.NewMatcher(supported)
+}
+
+func Supported() []language.Tag {
+	return supported
+}
+
+/*
+Match returns the best supported locale for the provided preferences. Each
+preference can be a single tag or a full Accept-Language header value, and the
+preferences are tried in order so callers decide the precedence, e.g. cookie,
+then user setting, then the browser.
+*/
+func Match(preferences ...string) language.Tag {
+	for _, preference := range preferences {
+		if preference == "" {
+			continue
+		}
+
+		tags, _, err := language.ParseAcceptLanguage(preference)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+
+		_, idx, confidence := matcher.Match(tags...)
+		if confidence != language.No {
+			return supported[idx]
+		}
+	}
+
+	return supported[0]
+}
+
+type Translator struct {
+	tag       language.Tag
+	catalogue Catalogue
+	fallback  Catalogue
+}
+
+func NewTranslator(locale string) Translator {
+	tag := Match(locale)
+
+	return Translator{
+		tag,
+		catalogues[tag],
+		catalogues[language.Make(DefaultLocale)],
+	}
+}
+
+func (t Translator) Locale() language.Tag {
+	return t.tag
+}
+
+/*
+T looks up key in the catalogue and formats it with args. Plural messages
+select their form from the first integer argument. Unknown keys are returned
+as is so a missing translation is visible instead of blank.
+*/
+func (t Translator) T(key string, args ...any) string {
+	msg, ok := t.catalogue.Messages[key]
+	if !ok {
+		msg, ok = t.fallback.Messages[key]
+	}
+	if !ok {
+		return key
+	}
+
+	text := msg.Forms[plural.Other]
+	if msg.IsPlural() {
+		if form, ok := msg.Forms[t.pluralForm(args)]; ok {
+			text = form
+		}
+	}
+
+	if len(args) == 0 {
+		return text
+	}
+
+	return fmt.Sprintf(text, args...)
+}
+
+func (t Translator) pluralForm(args []any) plural.Form {
+	for _, arg := range args {
+		var n int
+		switch v := arg.(type) {
+		case int:
+			n = v
+		case int32:
+			n = int(v)
+		case int64:
+			n = int(v)
+		default:
+			continue
+		}
+
+		if n < 0 {
+			n = -n
+		}
+
+		return plural.Cardinal.MatchPlural(t.tag, n, 0, 0, 0, 0)
+	}
+
+	return plural.Other
+}
+
+// FuncMap exposes T as 't' for text/template and html/template.
+func (t Translator) FuncMap() map[string]any {
+	return map[string]any{
+		"t": t.T,
+	}
+}
+
+type translatorCtxKey struct{}
+
+func WithTranslator(ctx context.Context, t Translator) context.Context {
+	return context.WithValue(ctx, translatorCtxKey{}, t)
+}
+
+// FromContext returns the translator set on ctx or one for the DefaultLocale.
+func FromContext(ctx context.Context) Translator {
+	if t, ok := ctx.Value(translatorCtxKey{}).(Translator); ok {
+		return t
+	}
+
+	return NewTranslator(DefaultLocale)
+}
+
+// T is a shorthand for FromContext(ctx).T, mostly meant for templ components.
+func T(ctx context.Context, key string, args ...any) string {
+	return FromContext(ctx).T(key, args...)
+}
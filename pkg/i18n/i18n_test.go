@@ -0,0 +1,95 @@
+package i18n_test
+
+import (
+	"testing"
+
+	"github.com/mbvlabs/grafto/pkg/i18n"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func TestCataloguesAreComplete(t *testing.T) {
+	catalogues, err := i18n.LoadCatalogues(i18n.Locales)
+	if err != nil {
+		t.Fatalf("could not load catalogues: %v", err)
+	}
+
+	base := catalogues[language.Make(i18n.DefaultLocale)]
+
+	for tag, catalogue := range catalogues {
+		if tag == base.Tag {
+			continue
+		}
+
+		t.Run(tag.String(), func(t *testing.T) {
+			for key, msg := range base.Messages {
+				translated, ok := catalogue.Messages[key]
+				if !ok {
+					t.Errorf("catalogue '%s' is missing key: '%s'", tag, key)
+					continue
+				}
+
+				if msg.IsPlural() && !translated.IsPlural() {
+					t.Errorf(
+						"catalogue '%s' has no plural forms for key: '%s'",
+						tag,
+						key,
+					)
+				}
+			}
+
+			for key := range catalogue.Messages {
+				if _, ok := base.Messages[key]; !ok {
+					t.Errorf(
+						"catalogue '%s' has key: '%s' not present in '%s'",
+						tag,
+						key,
+						i18n.DefaultLocale,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := map[string]struct {
+		preferences []string
+		expected    string
+	}{
+		"should fall back to the default locale": {
+			preferences: []string{"", "fr-FR,fr;q=0.9"},
+			expected:    i18n.DefaultLocale,
+		},
+		"should use the first preference that can be matched": {
+			preferences: []string{"", "da", "en-US"},
+			expected:    "da",
+		},
+		"should match regional variants from an accept-language header": {
+			preferences: []string{"fr;q=0.9,da-DK;q=0.8"},
+			expected:    "da",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, i18n.Match(test.preferences...).String())
+		})
+	}
+}
+
+func TestTranslatorPlurals(t *testing.T) {
+	tr := i18n.NewTranslator("en")
+
+	assert.Equal(
+		t,
+		"needs to be longer than: '1' character",
+		tr.T("validation.min_length", 1),
+	)
+	assert.Equal(
+		t,
+		"needs to be longer than: '50000' characters",
+		tr.T("validation.min_length", 50000),
+	)
+	assert.Equal(t, "unknown.key", tr.T("unknown.key"))
+}
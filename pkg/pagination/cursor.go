@@ -0,0 +1,83 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Cursor points at the row a keyset page continues from.
+type Cursor struct {
+	Sort Sort
+	// SortValue and KeyValue are the values of the sort field and the key of
+	// the row
+	SortValue any
+	KeyValue  any
+	// Before pages to the rows before the row instead of after it
+	Before bool
+}
+
+// encodedCursor is what goes in the query string. It is encoded, not signed,
+// since a made up cursor only gets the client a different page.
+type encodedCursor struct {
+	Sort   string    `json:"s"`
+	Values [2]string `json:"v"`
+	Before bool      `json:"b,omitempty"`
+}
+
+func (p Params) encodeCursor(key [2]any, before bool) (string, error) {
+	sortValue, err := p.SortField.Type.format(key[0])
+	if err != nil {
+		return "", err
+	}
+	keyValue, err := p.Key.Type.format(key[1])
+	if err != nil {
+		return "", err
+	}
+
+	encoded, err := json.Marshal(encodedCursor{
+		Sort:   p.Sort.String(),
+		Values: [2]string{sortValue, keyValue},
+		Before: before,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// decodeCursor reads a cursor made by encodeCursor. A cursor holds the sort
+// it was made for, so a sort given along with it must be the same.
+func (p Params) decodeCursor(cursor string, sortGiven bool) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	var encoded encodedCursor
+	if err := json.Unmarshal(raw, &encoded); err != nil {
+		return Cursor{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	sort := parseSort(encoded.Sort)
+	if sortGiven && sort != p.Sort {
+		return Cursor{}, fmt.Errorf("%w: made for sort %s", ErrInvalidCursor, sort)
+	}
+
+	sortField, ok := p.spec.Sorts[sort.Name]
+	if !ok {
+		return Cursor{}, fmt.Errorf("%w: %w: %s", ErrInvalidCursor, ErrInvalidSort, sort.Name)
+	}
+
+	sortValue, err := sortField.Type.parse(encoded.Values[0])
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	keyValue, err := p.Key.Type.parse(encoded.Values[1])
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	return Cursor{sort, sortValue, keyValue, encoded.Before}, nil
+}
@@ -0,0 +1,83 @@
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Links are the urls of the pages around a page, empty when there is no such
+// page. Last is only known in offset mode.
+type Links struct {
+	First string
+	Prev  string
+	Next  string
+	Last  string
+}
+
+/*
+Links returns the urls of the pages around m on path, keeping the limit, sort
+and filters of the request. Parameters the spec does not know are dropped, so
+they cannot be used to smuggle anything into the links.
+*/
+func (m Meta) Links(path string) Links {
+	link := func(param string, value string) string {
+		query := url.Values{}
+		for name, values := range m.params.query {
+			query[name] = values
+		}
+		if param != "" {
+			query.Set(param, value)
+		}
+
+		if len(query) == 0 {
+			return path
+		}
+
+		return path + "?" + query.Encode()
+	}
+
+	links := Links{First: link("", "")}
+
+	if m.Mode == Offset {
+		if m.HasPrev() {
+			links.Prev = link(PageParam, strconv.Itoa(m.Page-1))
+		}
+		if m.HasNext() {
+			links.Next = link(PageParam, strconv.Itoa(m.Page+1))
+		}
+		links.Last = link(PageParam, strconv.Itoa(m.TotalPages))
+
+		return links
+	}
+
+	if m.PrevCursor != "" {
+		links.Prev = link(CursorParam, m.PrevCursor)
+	}
+	if m.NextCursor != "" {
+		links.Next = link(CursorParam, m.NextCursor)
+	}
+
+	return links
+}
+
+// Header formats the links as a Link header, see RFC 8288.
+func (l Links) Header() string {
+	var header []string
+	for _, link := range []struct {
+		rel string
+		url string
+	}{
+		{"first", l.First},
+		{"prev", l.Prev},
+		{"next", l.Next},
+		{"last", l.Last},
+	} {
+		if link.url != "" {
+			header = append(header, fmt.Sprintf(`<%s>; rel="%s"`, link.url, link.rel))
+		}
+	}
+
+	return strings.Join(header, ", ")
+}
@@ -0,0 +1,415 @@
+// Package pagination pages through lists, either with keyset cursors or with
+// page numbers, sorted by whitelisted fields and narrowed by typed filters
+// taken from the query string.
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidLimit   = errors.New("limit must be a positive number")
+	ErrInvalidPage    = errors.New("page must be a positive number")
+	ErrInvalidSort    = errors.New("list cannot be sorted by that field")
+	ErrInvalidFilter  = errors.New("invalid filter value")
+	ErrInvalidCursor  = errors.New("invalid cursor")
+	ErrCursorWithPage = errors.New("cursor and page cannot be combined")
+)
+
+// The query parameters Parse reads besides the filters of the Spec.
+const (
+	LimitParam  = "limit"
+	SortParam   = "sort"
+	CursorParam = "cursor"
+	PageParam   = "page"
+)
+
+const (
+	defaultLimit = 25
+	maxLimit     = 100
+)
+
+// Mode is how a list is paged through.
+type Mode int
+
+const (
+	// Keyset continues from the last row of the previous page, which stays
+	// fast and stable on large lists that change while being read
+	Keyset Mode = iota
+	// Offset skips to a numbered page, which lets people jump between pages
+	Offset
+)
+
+// Type is the type of a column that is sorted or filtered by, and decides how
+// its values are parsed from the query string.
+type Type int
+
+const (
+	String Type = iota
+	Bool
+	Int
+	Time
+	UUID
+)
+
+func (t Type) parse(value string) (any, error) {
+	switch t {
+	case Bool:
+		return strconv.ParseBool(value)
+	case Int:
+		return strconv.ParseInt(value, 10, 64)
+	case Time:
+		return time.Parse(time.RFC3339Nano, value)
+	case UUID:
+		return uuid.Parse(value)
+	default:
+		return value, nil
+	}
+}
+
+// format is the opposite of parse, for values read back from the database.
+func (t Type) format(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case uuid.UUID:
+		return v.String(), nil
+	case [16]byte:
+		return uuid.UUID(v).String(), nil
+	default:
+		return "", fmt.Errorf("cannot format %T as a cursor value", value)
+	}
+}
+
+// Field is a column of the list. Columns that are sorted by must not be
+// nullable, as rows with null would be skipped by the cursor.
+type Field struct {
+	Column string
+	Type   Type
+}
+
+// Op is how a filter compares the column with the value.
+type Op int
+
+const (
+	// Equals matches rows where the column is the value
+	Equals Op = iota
+	// Contains matches rows where the column contains the value, ignoring
+	// case
+	Contains
+	// AtLeast matches rows where the column is the value or greater
+	AtLeast
+	// Before matches rows where the column is less than the value
+	Before
+)
+
+type FilterField struct {
+	Field
+	Op Op
+}
+
+// Filter is a filter from the query string with its value parsed.
+type Filter struct {
+	Name   string
+	Column string
+	Op     Op
+	Value  any
+}
+
+// Sort names a field of Spec.Sorts and its direction.
+type Sort struct {
+	Name string
+	Desc bool
+}
+
+// String formats the sort the way the sort parameter takes it, with a leading
+// minus for descending.
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Name
+	}
+
+	return s.Name
+}
+
+func parseSort(value string) Sort {
+	if name, ok := strings.CutPrefix(value, "-"); ok {
+		return Sort{name, true}
+	}
+
+	return Sort{value, false}
+}
+
+/*
+Spec declares what a list can be sorted and filtered by. The names are the
+ones used in the query string and the columns are what they map to, so only
+columns listed here ever end up in a query.
+*/
+type Spec struct {
+	// Mode is used when the request has neither a cursor nor a page
+	Mode        Mode
+	Sorts       map[string]Field
+	DefaultSort Sort
+	// Key is a unique column that orders rows with the same sort value, so
+	// a cursor points at exactly one row
+	Key Field
+	// Filters are keyed by their query parameter
+	Filters map[string]FilterField
+	// DefaultLimit and MaxLimit default to 25 and 100
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Params is a request for a page of a list, see Parse.
+type Params struct {
+	Mode    Mode
+	Limit   int
+	Sort    Sort
+	Filters []Filter
+	// SortField is the field Sort names
+	SortField Field
+	Key       Field
+	// Cursor is where a keyset page continues from, nil on the first page
+	Cursor *Cursor
+	// Page is the page to show in offset mode, starting from 1
+	Page int
+
+	spec  Spec
+	query url.Values
+}
+
+/*
+Parse reads the limit, sort, cursor, page and filters from the query string.
+Values that cannot be parsed fail with one of the Err values, which means the
+request is bad. Parameters that are not part of the spec are ignored.
+*/
+func Parse(query url.Values, spec Spec) (Params, error) {
+	if spec.DefaultLimit == 0 {
+		spec.DefaultLimit = defaultLimit
+	}
+	if spec.MaxLimit == 0 {
+		spec.MaxLimit = maxLimit
+	}
+
+	params := Params{
+		Mode:  spec.Mode,
+		Limit: spec.DefaultLimit,
+		Sort:  spec.DefaultSort,
+		Key:   spec.Key,
+		spec:  spec,
+		query: url.Values{},
+	}
+
+	if limit := query.Get(LimitParam); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 {
+			return Params{}, ErrInvalidLimit
+		}
+
+		params.Limit = min(parsed, spec.MaxLimit)
+		params.query.Set(LimitParam, strconv.Itoa(params.Limit))
+	}
+
+	if sort := query.Get(SortParam); sort != "" {
+		params.Sort = parseSort(sort)
+		params.query.Set(SortParam, params.Sort.String())
+	}
+	sortField, ok := spec.Sorts[params.Sort.Name]
+	if !ok {
+		return Params{}, fmt.Errorf("%w: %s", ErrInvalidSort, params.Sort.Name)
+	}
+	params.SortField = sortField
+
+	// in a fixed order, so the same request makes the same query
+	names := make([]string, 0, len(spec.Filters))
+	for name := range spec.Filters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		filter := spec.Filters[name]
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := filter.Type.parse(value)
+		if err != nil {
+			return Params{}, fmt.Errorf("%w: %s: %w", ErrInvalidFilter, name, err)
+		}
+
+		params.Filters = append(params.Filters, Filter{name, filter.Column, filter.Op, parsed})
+		params.query.Set(name, value)
+	}
+
+	cursor, page := query.Get(CursorParam), query.Get(PageParam)
+	switch {
+	case cursor != "" && page != "":
+		return Params{}, ErrCursorWithPage
+	case cursor != "":
+		decoded, err := params.decodeCursor(cursor, query.Has(SortParam))
+		if err != nil {
+			return Params{}, err
+		}
+
+		params.Mode = Keyset
+		params.Cursor = &decoded
+		params.Sort = decoded.Sort
+		params.SortField = spec.Sorts[decoded.Sort.Name]
+		params.query.Set(SortParam, decoded.Sort.String())
+	case page != "":
+		parsed, err := strconv.Atoi(page)
+		if err != nil || parsed < 1 {
+			return Params{}, ErrInvalidPage
+		}
+
+		params.Mode = Offset
+		params.Page = parsed
+	}
+
+	if params.Mode == Offset && params.Page == 0 {
+		params.Page = 1
+	}
+
+	return params, nil
+}
+
+// Offset is the number of rows before the page in offset mode.
+func (p Params) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// Meta describes a page without its items.
+type Meta struct {
+	Mode  Mode
+	Limit int
+	Total int64
+	// Page and TotalPages are set in offset mode
+	Page       int
+	TotalPages int
+	// NextCursor and PrevCursor are set in keyset mode when there is a page
+	// in that direction
+	NextCursor string
+	PrevCursor string
+
+	params Params
+}
+
+func (m Meta) HasNext() bool {
+	if m.Mode == Offset {
+		return m.Page < m.TotalPages
+	}
+
+	return m.NextCursor != ""
+}
+
+func (m Meta) HasPrev() bool {
+	if m.Mode == Offset {
+		return m.Page > 1
+	}
+
+	return m.PrevCursor != ""
+}
+
+type Page[T any] struct {
+	Meta
+	Items []T
+}
+
+/*
+NewPage builds the page from the rows a query fetched for params, along with
+the sort value and key of each row. In keyset mode the query must fetch one
+row more than the limit, which tells whether there is a page after it, and the
+rows of a cursor that points backwards come in reverse order.
+*/
+func NewPage[T any](params Params, items []T, keys [][2]any, total int64) (Page[T], error) {
+	page := Page[T]{
+		Meta: Meta{
+			Mode:   params.Mode,
+			Limit:  params.Limit,
+			Total:  total,
+			params: params,
+		},
+		Items: items,
+	}
+
+	if params.Mode == Offset {
+		page.Page = params.Page
+		page.TotalPages = max(1, int((total+int64(params.Limit)-1)/int64(params.Limit)))
+
+		return page, nil
+	}
+
+	if len(items) != len(keys) {
+		return Page[T]{}, fmt.Errorf("got %d keys for %d rows", len(keys), len(items))
+	}
+
+	hasMore := len(items) > params.Limit
+	if hasMore {
+		items, keys = items[:params.Limit], keys[:params.Limit]
+	}
+
+	backwards := params.Cursor != nil && params.Cursor.Before
+	if backwards {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+	page.Items = items
+
+	if len(items) == 0 {
+		return page, nil
+	}
+
+	// a page reached going forwards has one before it, and the other way
+	// around
+	hasNext := hasMore || backwards
+	hasPrev := (params.Cursor != nil && !backwards) || (backwards && hasMore)
+
+	if hasNext {
+		next, err := params.encodeCursor(keys[len(keys)-1], false)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		page.NextCursor = next
+	}
+	if hasPrev {
+		prev, err := params.encodeCursor(keys[0], true)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		page.PrevCursor = prev
+	}
+
+	return page, nil
+}
+
+// MapItems converts the items of page, usually from database rows to models.
+func MapItems[T any, U any](page Page[T], fn func(item T) U) Page[U] {
+	items := make([]U, len(page.Items))
+	for i, item := range page.Items {
+		items[i] = fn(item)
+	}
+
+	return Page[U]{page.Meta, items}
+}
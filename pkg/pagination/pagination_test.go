@@ -0,0 +1,232 @@
+package pagination_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mbvlabs/grafto/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+)
+
+var spec = pagination.Spec{
+	Sorts: map[string]pagination.Field{
+		"created_at": {Column: "created_at", Type: pagination.Time},
+		"name":       {Column: "name", Type: pagination.String},
+	},
+	DefaultSort: pagination.Sort{Name: "created_at", Desc: true},
+	Key:         pagination.Field{Column: "id", Type: pagination.UUID},
+	Filters: map[string]pagination.FilterField{
+		"email":    {Field: pagination.Field{Column: "email", Type: pagination.String}, Op: pagination.Contains},
+		"is_admin": {Field: pagination.Field{Column: "is_admin", Type: pagination.Bool}, Op: pagination.Equals},
+	},
+	DefaultLimit: 2,
+	MaxLimit:     10,
+}
+
+func parse(t *testing.T, query string, spec pagination.Spec) pagination.Params {
+	t.Helper()
+
+	values, err := url.ParseQuery(query)
+	assert.NoError(t, err)
+
+	params, err := pagination.Parse(values, spec)
+	assert.NoError(t, err)
+
+	return params
+}
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		query           string
+		expectedErr     error
+		expectedMode    pagination.Mode
+		expectedLimit   int
+		expectedSort    pagination.Sort
+		expectedPage    int
+		expectedFilters []pagination.Filter
+	}{
+		"should use the defaults of the spec": {
+			expectedMode:  pagination.Keyset,
+			expectedLimit: 2,
+			expectedSort:  pagination.Sort{Name: "created_at", Desc: true},
+		},
+		"should parse limit, sort and typed filters": {
+			query:         "limit=5&sort=name&email=%40example.com&is_admin=true&unknown=1",
+			expectedMode:  pagination.Keyset,
+			expectedLimit: 5,
+			expectedSort:  pagination.Sort{Name: "name"},
+			expectedFilters: []pagination.Filter{
+				{Name: "email", Column: "email", Op: pagination.Contains, Value: "@example.com"},
+				{Name: "is_admin", Column: "is_admin", Op: pagination.Equals, Value: true},
+			},
+		},
+		"should cap the limit": {
+			query:         "limit=500",
+			expectedMode:  pagination.Keyset,
+			expectedLimit: 10,
+			expectedSort:  pagination.Sort{Name: "created_at", Desc: true},
+		},
+		"should switch to offset mode with a page": {
+			query:         "page=3",
+			expectedMode:  pagination.Offset,
+			expectedLimit: 2,
+			expectedSort:  pagination.Sort{Name: "created_at", Desc: true},
+			expectedPage:  3,
+		},
+		"should reject a sort that is not whitelisted": {
+			query:       "sort=password",
+			expectedErr: pagination.ErrInvalidSort,
+		},
+		"should reject a filter of the wrong type": {
+			query:       "is_admin=maybe",
+			expectedErr: pagination.ErrInvalidFilter,
+		},
+		"should reject a limit that is not positive": {
+			query:       "limit=0",
+			expectedErr: pagination.ErrInvalidLimit,
+		},
+		"should reject a page that is not positive": {
+			query:       "page=-1",
+			expectedErr: pagination.ErrInvalidPage,
+		},
+		"should reject a cursor that cannot be decoded": {
+			query:       "cursor=not-a-cursor",
+			expectedErr: pagination.ErrInvalidCursor,
+		},
+		"should reject a cursor combined with a page": {
+			query:       "cursor=abc&page=2",
+			expectedErr: pagination.ErrCursorWithPage,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			values, err := url.ParseQuery(test.query)
+			assert.NoError(t, err)
+
+			params, err := pagination.Parse(values, spec)
+			assert.ErrorIs(t, err, test.expectedErr)
+			if test.expectedErr != nil {
+				return
+			}
+
+			assert.Equal(t, test.expectedMode, params.Mode)
+			assert.Equal(t, test.expectedLimit, params.Limit)
+			assert.Equal(t, test.expectedSort, params.Sort)
+			assert.Equal(t, test.expectedPage, params.Page)
+			assert.Equal(t, test.expectedFilters, params.Filters)
+		})
+	}
+}
+
+func TestOffsetModeFromSpec(t *testing.T) {
+	offsetSpec := spec
+	offsetSpec.Mode = pagination.Offset
+
+	params := parse(t, "", offsetSpec)
+	assert.Equal(t, pagination.Offset, params.Mode)
+	assert.Equal(t, 1, params.Page)
+	assert.Equal(t, 0, params.Offset())
+
+	params = parse(t, "page=4", offsetSpec)
+	assert.Equal(t, 6, params.Offset())
+}
+
+func TestKeysetPages(t *testing.T) {
+	createdAt := time.Date(2026, 10, 19, 12, 30, 0, 123456000, time.UTC)
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	keys := [][2]any{
+		{createdAt, [16]byte(ids[0])},
+		{createdAt.Add(-time.Hour), [16]byte(ids[1])},
+		{createdAt.Add(-2 * time.Hour), [16]byte(ids[2])},
+	}
+
+	first := parse(t, "email=example&sort=-created_at", spec)
+	page, err := pagination.NewPage(first, []string{"a", "b", "c"}, keys, 5)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"a", "b"}, page.Items)
+	assert.Equal(t, int64(5), page.Total)
+	assert.True(t, page.HasNext())
+	assert.False(t, page.HasPrev())
+
+	next := parse(t, "email=example&cursor="+page.NextCursor, spec)
+	assert.Equal(t, &pagination.Cursor{
+		Sort:      pagination.Sort{Name: "created_at", Desc: true},
+		SortValue: createdAt.Add(-time.Hour),
+		KeyValue:  ids[1],
+	}, next.Cursor)
+	assert.Equal(t, []pagination.Filter{
+		{Name: "email", Column: "email", Op: pagination.Contains, Value: "example"},
+	}, next.Filters)
+
+	// the last page, fetched without a row to spare
+	page, err = pagination.NewPage(next, []string{"c"}, keys[2:], 5)
+	assert.NoError(t, err)
+	assert.False(t, page.HasNext())
+	assert.True(t, page.HasPrev())
+
+	prev := parse(t, "cursor="+page.PrevCursor, spec)
+	assert.True(t, prev.Cursor.Before)
+	assert.Equal(t, ids[2], prev.Cursor.KeyValue)
+
+	// going backwards the rows come newest last
+	page, err = pagination.NewPage(prev, []string{"b", "a"}, [][2]any{keys[1], keys[0]}, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, page.Items)
+	assert.True(t, page.HasNext())
+	assert.False(t, page.HasPrev())
+}
+
+func TestCursorMustMatchSort(t *testing.T) {
+	params := parse(t, "sort=name", spec)
+	page, err := pagination.NewPage(
+		params,
+		[]string{"a", "b", "c"},
+		[][2]any{{"a", uuid.New()}, {"b", uuid.New()}, {"c", uuid.New()}},
+		3,
+	)
+	assert.NoError(t, err)
+
+	_, err = pagination.Parse(url.Values{
+		"cursor": {page.NextCursor},
+		"sort":   {"created_at"},
+	}, spec)
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	params = parse(t, "cursor="+page.NextCursor, spec)
+	assert.Equal(t, pagination.Sort{Name: "name"}, params.Sort)
+}
+
+func TestLinks(t *testing.T) {
+	offsetSpec := spec
+	offsetSpec.Mode = pagination.Offset
+
+	params := parse(t, "page=2&email=a%26b&limit=2&unknown=1", offsetSpec)
+	page, err := pagination.NewPage(params, []string{"c", "d"}, nil, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, page.TotalPages)
+
+	links := page.Links("/users")
+	assert.Equal(t, pagination.Links{
+		First: "/users?email=a%26b&limit=2",
+		Prev:  "/users?email=a%26b&limit=2&page=1",
+		Next:  "/users?email=a%26b&limit=2&page=3",
+		Last:  "/users?email=a%26b&limit=2&page=3",
+	}, links)
+	assert.Equal(
+		t,
+		`</users?email=a%26b&limit=2>; rel="first", `+
+			`</users?email=a%26b&limit=2&page=1>; rel="prev", `+
+			`</users?email=a%26b&limit=2&page=3>; rel="next", `+
+			`</users?email=a%26b&limit=2&page=3>; rel="last"`,
+		links.Header(),
+	)
+
+	params = parse(t, "", spec)
+	page, err = pagination.NewPage(params, []string{}, [][2]any{}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, pagination.Links{First: "/users"}, page.Links("/users"))
+}
@@ -9,6 +9,8 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mbvlabs/grafto/services"
@@ -19,6 +21,75 @@ var (
 	ErrNotAuthorized = errors.New("Unauthorized")
 )
 
+/*
+permanentErrorCodes are the postmark error codes of requests that fail the
+same way no matter how often they are retried, see
+https://postmarkapp.com/developer/api/overview#error-codes. Codes caused by
+the account or server setup are left out as they are resolved by fixing the
+setup, after which a retry succeeds.
+*/
+var permanentErrorCodes = map[int]bool{
+	300: true, // invalid email request
+	406: true, // inactive recipient
+	409: true, // json required
+	411: true, // forbidden attachment type
+}
+
+// throttledRetryAfter is how long to back off when postmark rate limits a
+// request without saying for how long
+const throttledRetryAfter = time.Minute
+
+// APIError is a request postmark did not accept. It wraps ErrCouldNotSend, or
+// ErrNotAuthorized when the server token was rejected.
+type APIError struct {
+	StatusCode int
+	ErrorCode  int    `json:"ErrorCode"`
+	Message    string `json:"Message"`
+	// RetryAfterDelay is the Retry-After header of the response, if any
+	RetryAfterDelay time.Duration `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf(
+		"postmark responded with status %d, error code %d: %s",
+		e.StatusCode,
+		e.ErrorCode,
+		e.Message,
+	)
+}
+
+func (e *APIError) Unwrap() error {
+	if e.StatusCode == http.StatusUnauthorized {
+		return ErrNotAuthorized
+	}
+
+	return ErrCouldNotSend
+}
+
+// Permanent reports whether retrying the request can not succeed. Rate limits
+// and server errors are retryable, as is anything postmark did not explain.
+func (e *APIError) Permanent() bool {
+	if e.StatusCode == http.StatusRequestEntityTooLarge {
+		return true
+	}
+
+	return e.StatusCode == http.StatusUnprocessableEntity &&
+		permanentErrorCodes[e.ErrorCode]
+}
+
+// RetryAfter is how long to wait before sending again when postmark asked to
+// back off, zero otherwise.
+func (e *APIError) RetryAfter() time.Duration {
+	if e.RetryAfterDelay > 0 {
+		return e.RetryAfterDelay
+	}
+	if e.StatusCode == http.StatusTooManyRequests {
+		return throttledRetryAfter
+	}
+
+	return 0
+}
+
 type Postmark struct {
 	client  http.Client
 	token   string
@@ -39,29 +110,67 @@ func New(token string) Postmark {
 
 var _ services.EmailClient = (*Postmark)(nil)
 
+type mailHeader struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+type mailAttachment struct {
+	Name        string `json:"Name"`
+	Content     []byte `json:"Content"`
+	ContentType string `json:"ContentType"`
+}
+
 type mailBody struct {
-	From     string `json:"From"`
-	To       string `json:"To"`
-	Subject  string `json:"Subject"`
-	HtmlBody string `json:"HtmlBody"`
-	TextBody string `json:"TextBody"`
+	From        string           `json:"From"`
+	To          string           `json:"To"`
+	Cc          string           `json:"Cc,omitempty"`
+	Bcc         string           `json:"Bcc,omitempty"`
+	ReplyTo     string           `json:"ReplyTo,omitempty"`
+	Subject     string           `json:"Subject"`
+	HtmlBody    string           `json:"HtmlBody"`
+	TextBody    string           `json:"TextBody"`
+	Headers     []mailHeader     `json:"Headers,omitempty"`
+	Attachments []mailAttachment `json:"Attachments,omitempty"`
 }
 
 // SendEmail implements services.EmailClient.
 func (p *Postmark) SendEmail(ctx context.Context, payload services.EmailPayload) error {
+	headers := make([]mailHeader, 0, len(payload.Headers))
+	for name, value := range payload.Headers {
+		headers = append(headers, mailHeader{name, value})
+	}
+
+	// postmark expects the content base64 encoded which encoding/json does
+	// for byte slices
+	attachments := make([]mailAttachment, 0, len(payload.Attachments))
+	for _, attachment := range payload.Attachments {
+		attachments = append(attachments, mailAttachment{
+			Name:        attachment.Filename,
+			Content:     attachment.Content,
+			ContentType: attachment.ContentType,
+		})
+	}
+
 	byt, err := json.Marshal(mailBody{
-		From:     payload.From,
-		To:       payload.To,
-		Subject:  payload.Subject,
-		HtmlBody: payload.HtmlBody,
-		TextBody: payload.TextBody,
+		From:        payload.From,
+		To:          strings.Join(payload.To, ","),
+		Cc:          strings.Join(payload.Cc, ","),
+		Bcc:         strings.Join(payload.Bcc, ","),
+		ReplyTo:     strings.Join(payload.ReplyTo, ","),
+		Subject:     payload.Subject,
+		HtmlBody:    payload.HtmlBody,
+		TextBody:    payload.TextBody,
+		Headers:     headers,
+		Attachments: attachments,
 	})
 	if err != nil {
 		slog.Error("could not marshal email payload", "error", err)
 		return err
 	}
 
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodPost,
 		fmt.Sprintf("%s/email", p.baseUrl),
 		bytes.NewBuffer(byt),
@@ -79,10 +188,7 @@ func (p *Postmark) SendEmail(ctx context.Context, payload services.EmailPayload)
 		return err
 	}
 
-	if res.StatusCode == http.StatusUnauthorized {
-		slog.Error("received unauthorized status code", "error", err)
-		return ErrNotAuthorized
-	}
+	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
 		body, err := io.ReadAll(res.Body)
@@ -90,17 +196,37 @@ func (p *Postmark) SendEmail(ctx context.Context, payload services.EmailPayload)
 			return err
 		}
 
-		slog.Error(
+		apiErr := &APIError{
+			StatusCode:      res.StatusCode,
+			RetryAfterDelay: parseRetryAfter(res.Header.Get("Retry-After")),
+		}
+		// the body is only informational, a status without one is still an
+		// error
+		_ = json.Unmarshal(body, apiErr)
+
+		slog.ErrorContext(
+			ctx,
 			"received non ok status code",
 			"error",
-			err,
+			apiErr,
 			"status",
 			res.StatusCode,
 			"body",
 			string(body),
 		)
-		return ErrCouldNotSend
+		return apiErr
 	}
 
 	return nil
 }
+
+// parseRetryAfter reads a Retry-After header given in seconds, the http date
+// form is not used by postmark.
+func parseRetryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,77 @@
+package postmark_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mbvlabs/grafto/pkg/postmark"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIError(t *testing.T) {
+	tests := map[string]struct {
+		err               *postmark.APIError
+		expectedPermanent bool
+		expectedErr       error
+	}{
+		"should not retry an inactive recipient": {
+			err:               &postmark.APIError{StatusCode: http.StatusUnprocessableEntity, ErrorCode: 406},
+			expectedPermanent: true,
+			expectedErr:       postmark.ErrCouldNotSend,
+		},
+		"should retry an unconfirmed sender signature": {
+			err:         &postmark.APIError{StatusCode: http.StatusUnprocessableEntity, ErrorCode: 401},
+			expectedErr: postmark.ErrCouldNotSend,
+		},
+		"should retry when rate limited": {
+			err:         &postmark.APIError{StatusCode: http.StatusTooManyRequests},
+			expectedErr: postmark.ErrCouldNotSend,
+		},
+		"should retry server errors": {
+			err:         &postmark.APIError{StatusCode: http.StatusInternalServerError},
+			expectedErr: postmark.ErrCouldNotSend,
+		},
+		"should retry a rejected server token": {
+			err:         &postmark.APIError{StatusCode: http.StatusUnauthorized, ErrorCode: 10},
+			expectedErr: postmark.ErrNotAuthorized,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expectedPermanent, test.err.Permanent())
+			assert.True(t, errors.Is(test.err, test.expectedErr))
+		})
+	}
+}
+
+func TestAPIErrorRetryAfter(t *testing.T) {
+	tests := map[string]struct {
+		err      *postmark.APIError
+		expected time.Duration
+	}{
+		"should honour the Retry-After header": {
+			err: &postmark.APIError{
+				StatusCode:      http.StatusTooManyRequests,
+				RetryAfterDelay: 30 * time.Second,
+			},
+			expected: 30 * time.Second,
+		},
+		"should back off when rate limited without a header": {
+			err:      &postmark.APIError{StatusCode: http.StatusTooManyRequests},
+			expected: time.Minute,
+		},
+		"should not hint on other errors": {
+			err:      &postmark.APIError{StatusCode: http.StatusInternalServerError},
+			expected: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.err.RetryAfter())
+		})
+	}
+}
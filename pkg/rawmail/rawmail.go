@@ -0,0 +1,211 @@
+package rawmail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mbvlabs/grafto/services"
+)
+
+var ErrInvalidHeader = errors.New("header contains a line break")
+
+// base64 encoded lines must not be longer than 76 characters, see RFC 2045
+const base64LineLength = 76
+
+type header struct {
+	key   string
+	value string
+}
+
+/*
+Build assembles an RFC 5322 message with a multipart/alternative body for the
+text and html versions, wrapped in multipart/mixed when there are attachments.
+Bcc recipients are left out of the headers; pass payload.Recipients() to the
+transport instead.
+*/
+func Build(payload services.EmailPayload) ([]byte, error) {
+	var body bytes.Buffer
+	contentType, err := writeBody(&body, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := []header{
+		{"From", payload.From},
+		{"To", strings.Join(payload.To, ", ")},
+		{"Cc", strings.Join(payload.Cc, ", ")},
+		{"Reply-To", strings.Join(payload.ReplyTo, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", payload.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+	}
+
+	customKeys := make([]string, 0, len(payload.Headers))
+	for key := range payload.Headers {
+		customKeys = append(customKeys, key)
+	}
+	sort.Strings(customKeys)
+
+	for _, key := range customKeys {
+		headers = append(headers, header{
+			textproto.CanonicalMIMEHeaderKey(key),
+			payload.Headers[key],
+		})
+	}
+
+	var msg bytes.Buffer
+	for _, h := range headers {
+		if h.value == "" {
+			continue
+		}
+
+		if strings.ContainsAny(h.key+h.value, "\r\n") {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidHeader, h.key)
+		}
+
+		fmt.Fprintf(&msg, "%s: %s\r\n", h.key, h.value)
+	}
+
+	fmt.Fprintf(&msg, "Content-Type: %s\r\n\r\n", contentType)
+	if _, err := body.WriteTo(&msg); err != nil {
+		return nil, err
+	}
+
+	return msg.Bytes(), nil
+}
+
+func writeBody(w io.Writer, payload services.EmailPayload) (string, error) {
+	if len(payload.Attachments) == 0 {
+		return writeAlternative(w, payload)
+	}
+
+	mixed := multipart.NewWriter(w)
+
+	var alternative bytes.Buffer
+	alternativeType, err := writeAlternative(&alternative, payload)
+	if err != nil {
+		return "", err
+	}
+
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {alternativeType},
+	})
+	if err != nil {
+		return "", err
+	}
+	if _, err := alternative.WriteTo(part); err != nil {
+		return "", err
+	}
+
+	for _, attachment := range payload.Attachments {
+		if err := writeAttachment(mixed, attachment); err != nil {
+			return "", err
+		}
+	}
+
+	if err := mixed.Close(); err != nil {
+		return "", err
+	}
+
+	return mime.FormatMediaType(
+		"multipart/mixed",
+		map[string]string{"boundary": mixed.Boundary()},
+	), nil
+}
+
+func writeAlternative(w io.Writer, payload services.EmailPayload) (string, error) {
+	alternative := multipart.NewWriter(w)
+
+	bodies := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain", payload.TextBody},
+		{"text/html", payload.HtmlBody},
+	}
+
+	for _, body := range bodies {
+		if body.content == "" {
+			continue
+		}
+
+		part, err := alternative.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {
+				mime.FormatMediaType(
+					body.contentType,
+					map[string]string{"charset": "UTF-8"},
+				),
+			},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return "", err
+		}
+
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(body.content)); err != nil {
+			return "", err
+		}
+		if err := qp.Close(); err != nil {
+			return "", err
+		}
+	}
+
+	if err := alternative.Close(); err != nil {
+		return "", err
+	}
+
+	return mime.FormatMediaType(
+		"multipart/alternative",
+		map[string]string{"boundary": alternative.Boundary()},
+	), nil
+}
+
+func writeAttachment(w *multipart.Writer, attachment services.Attachment) error {
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {
+			mime.FormatMediaType(
+				contentType,
+				map[string]string{"name": attachment.Filename},
+			),
+		},
+		"Content-Disposition": {
+			mime.FormatMediaType(
+				"attachment",
+				map[string]string{"filename": attachment.Filename},
+			),
+		},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(attachment.Content)
+	for len(encoded) > base64LineLength {
+		if _, err := fmt.Fprintf(part, "%s\r\n", encoded[:base64LineLength]); err != nil {
+			return err
+		}
+		encoded = encoded[base64LineLength:]
+	}
+	if _, err := fmt.Fprintf(part, "%s\r\n", encoded); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package rawmail_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/mbvlabs/grafto/pkg/rawmail"
+	"github.com/mbvlabs/grafto/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild(t *testing.T) {
+	payload := services.EmailPayload{
+		To:       []string{"jon@stark.com", "arya@stark.com"},
+		Cc:       []string{"sansa@stark.com"},
+		Bcc:      []string{"archive@stark.com"},
+		ReplyTo:  []string{"support@stark.com"},
+		From:     "ned@stark.com",
+		Subject:  "Winter is coming",
+		HtmlBody: "<p>Winter is coming</p>",
+		TextBody: "Winter is coming",
+		Headers:  map[string]string{"x-ticket-id": "42"},
+		Attachments: []services.Attachment{
+			{
+				Filename:    "invoice.pdf",
+				ContentType: "application/pdf",
+				Content:     bytes.Repeat([]byte("%PDF"), 100),
+			},
+		},
+	}
+
+	raw, err := rawmail.Build(payload)
+	if err != nil {
+		t.Fatalf("could not build message: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("could not parse message: %v", err)
+	}
+
+	assert.Equal(t, "jon@stark.com, arya@stark.com", msg.Header.Get("To"))
+	assert.Equal(t, "sansa@stark.com", msg.Header.Get("Cc"))
+	assert.Equal(t, "support@stark.com", msg.Header.Get("Reply-To"))
+	assert.Equal(t, "42", msg.Header.Get("X-Ticket-Id"))
+	assert.Empty(t, msg.Header.Get("Bcc"), "bcc must never be part of the headers")
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("could not parse content type: %v", err)
+	}
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+
+	alternative, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("could not read alternative part: %v", err)
+	}
+	alternativeType, _, _ := mime.ParseMediaType(alternative.Header.Get("Content-Type"))
+	assert.Equal(t, "multipart/alternative", alternativeType)
+
+	attachment, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("could not read attachment part: %v", err)
+	}
+	assert.Equal(t, "invoice.pdf", attachment.FileName())
+
+	// multipart.Reader only decodes quoted-printable so base64 is reversed here
+	encoded, err := io.ReadAll(attachment)
+	if err != nil {
+		t.Fatalf("could not read attachment: %v", err)
+	}
+	content, err := base64.StdEncoding.DecodeString(
+		strings.ReplaceAll(string(encoded), "\r\n", ""),
+	)
+	if err != nil {
+		t.Fatalf("could not decode attachment: %v", err)
+	}
+	assert.Equal(t, payload.Attachments[0].Content, content)
+
+	assert.Equal(
+		t,
+		[]string{
+			"jon@stark.com",
+			"arya@stark.com",
+			"sansa@stark.com",
+			"archive@stark.com",
+		},
+		payload.Recipients(),
+	)
+}
+
+func TestBuildRejectsHeaderInjection(t *testing.T) {
+	_, err := rawmail.Build(services.EmailPayload{
+		To:       []string{"jon@stark.com"},
+		From:     "ned@stark.com",
+		Subject:  "Winter is coming",
+		TextBody: "Winter is coming",
+		Headers:  map[string]string{"X-Ticket-Id": "42\r\nBcc: spy@lannister.com"},
+	})
+
+	assert.True(t, errors.Is(err, rawmail.ErrInvalidHeader))
+}
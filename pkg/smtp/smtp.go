@@ -0,0 +1,67 @@
+package smtp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+
+	"github.com/mbvlabs/grafto/pkg/rawmail"
+	"github.com/mbvlabs/grafto/services"
+)
+
+var ErrNoRecipients = errors.New("email has no recipients")
+
+type Smtp struct {
+	addr string
+	auth smtp.Auth
+}
+
+func New(host, port, username, password string) Smtp {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return Smtp{
+		net.JoinHostPort(host, port),
+		auth,
+	}
+}
+
+var _ services.EmailClient = (*Smtp)(nil)
+
+// SendEmail implements services.EmailClient.
+func (s *Smtp) SendEmail(ctx context.Context, payload services.EmailPayload) error {
+	recipients := payload.Recipients()
+	if len(recipients) == 0 {
+		return ErrNoRecipients
+	}
+
+	msg, err := rawmail.Build(payload)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not build email message", "error", err)
+		return err
+	}
+
+	// smtp.SendMail has no context support so the send is raced against the
+	// context instead
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(s.addr, s.auth, payload.From, recipients, msg)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		if err != nil {
+			slog.ErrorContext(ctx, "could not send email", "error", err)
+			return fmt.Errorf("smtp send to %s: %w", s.addr, err)
+		}
+
+		return nil
+	}
+}
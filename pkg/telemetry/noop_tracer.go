@@ -5,9 +5,9 @@ import (
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
-	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
 	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type NoopSpan struct {
@@ -54,7 +54,7 @@ func (n NoopSpan) SpanContext() trace.SpanContext {
 
 // TracerProvider implements trace.Span.
 func (n NoopSpan) TracerProvider() trace.TracerProvider {
-	return tracesdk.NewTracerProvider()
+	return noop.NewTracerProvider()
 }
 
 var _ trace.Span = new(NoopSpan)
@@ -69,7 +69,7 @@ func (n NoopTracer) Start(
 	spanName string,
 	opts ...trace.SpanStartOption,
 ) (context.Context, trace.Span) {
-	return context.Background(), NoopSpan{}
+	return ctx, NoopSpan{}
 }
 
 var _ trace.Tracer = new(NoopTracer)
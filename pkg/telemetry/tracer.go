@@ -36,13 +36,10 @@ func NewOtel(cfg config.Config) Otel {
 }
 
 func (o Otel) NewTracer(name string) Tracer {
-	var t trace.Tracer
+	var t trace.Tracer = NoopTracer{}
 	if o.cfg.App.Environment == config.PROD_ENVIRONMENT {
 		t = o.traceProvider.Tracer(name)
 	}
-	if o.cfg.App.Environment == config.DEV_ENVIRONMENT {
-		t = NoopTracer{}
-	}
 
 	return Tracer{
 		t,
@@ -57,8 +54,9 @@ func (o Otel) Shutdown() error {
 func (t Tracer) CreateSpan(
 	ctx context.Context,
 	name string,
+	opts ...trace.SpanStartOption,
 ) (context.Context, trace.Span) {
-	return t.tracer.Start(ctx, name)
+	return t.tracer.Start(ctx, name, opts...)
 }
 
 func (t Tracer) CreateChildSpan(
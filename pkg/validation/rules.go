@@ -11,7 +11,7 @@ type Rule interface {
 	IsViolated(val any) bool
 	Violation() error
 	// TODO: should maybe be named HumanExplanation/HumanDescription/Description?
-	ViolationForHumans(val string) string
+	ViolationForHumans(t Translator, val string) string
 }
 
 func PasswordMatchConfirmRule(confirm string) passwordMatchConfirm {
@@ -37,8 +37,8 @@ func (p passwordMatchConfirm) Violation() error {
 }
 
 // ViolationForHumans implements Rule.
-func (p passwordMatchConfirm) ViolationForHumans(val string) string {
-	return "password and confirm password must match"
+func (p passwordMatchConfirm) ViolationForHumans(t Translator, val string) string {
+	return t.T("validation.password_match")
 }
 
 var RequiredRule required
@@ -46,8 +46,8 @@ var RequiredRule required
 type required struct{}
 
 // ViolationForHumans implements Rule.
-func (r required) ViolationForHumans(val string) string {
-	return "must be provided"
+func (r required) ViolationForHumans(t Translator, val string) string {
+	return t.T("validation.required")
 }
 
 // IsViolated implements Rule.
@@ -86,11 +86,8 @@ func (m minLength) Violation() error {
 }
 
 // ViolationForHumans implements Rule.
-func (m minLength) ViolationForHumans(val string) string {
-	return fmt.Sprintf(
-		"needs to be longer than: '%v' characters",
-		m.minimum,
-	)
+func (m minLength) ViolationForHumans(t Translator, val string) string {
+	return t.T("validation.min_length", m.minimum)
 }
 
 func MaxLengthRule(length int) maxLength {
@@ -119,11 +116,8 @@ func (m maxLength) Violation() error {
 }
 
 // ViolationForHumans implements Rule.
-func (m maxLength) ViolationForHumans(val string) string {
-	return fmt.Sprintf(
-		"can max be: '%v' characters",
-		m.maximum,
-	)
+func (m maxLength) ViolationForHumans(t Translator, val string) string {
+	return t.T("validation.max_length", m.maximum)
 }
 
 var emailRegex = regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`)
@@ -152,8 +146,8 @@ func (v validEmail) Violation() error {
 }
 
 // ViolationForHumans implements Rule.
-func (v validEmail) ViolationForHumans(val string) string {
-	return fmt.Sprintf("the provided email: '%v' is not valid", val)
+func (v validEmail) ViolationForHumans(t Translator, val string) string {
+	return t.T("validation.valid_email", val)
 }
 
 var (
@@ -3,8 +3,27 @@ package validation
 import (
 	"fmt"
 	"reflect"
+
+	"github.com/mbvlabs/grafto/pkg/i18n"
 )
 
+// Translator turns a catalogue key into a message in the reader's language.
+type Translator interface {
+	T(key string, args ...any) string
+}
+
+type validateCfg struct {
+	translator Translator
+}
+
+type ValidateOpts func(cfg *validateCfg)
+
+func WithTranslator(translator Translator) ValidateOpts {
+	return func(cfg *validateCfg) {
+		cfg.translator = translator
+	}
+}
+
 type ValidationError interface {
 	Error() string
 	GetFieldName() string
@@ -89,7 +108,24 @@ func getFieldValue(fieldValue reflect.Value) any {
 	return fieldValue.Interface()
 }
 
-func ValidateStruct(structToValidate any, validationMap map[string][]Rule) error {
+/*
+ValidateStruct runs the rules in validationMap against the matching fields of
+structToValidate. Human readable violations use the default locale unless a
+translator is provided through WithTranslator.
+*/
+func ValidateStruct(
+	structToValidate any,
+	validationMap map[string][]Rule,
+	opts ...ValidateOpts,
+) error {
+	cfg := &validateCfg{
+		translator: i18n.NewTranslator(i18n.DefaultLocale),
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	val := reflect.ValueOf(structToValidate)
 	typ := reflect.TypeOf(structToValidate)
 
@@ -116,7 +152,7 @@ func ValidateStruct(structToValidate any, validationMap map[string][]Rule) error
 				)
 				errVal.ViolationsForHumans = append(
 					errVal.ViolationsForHumans,
-					rule.ViolationForHumans(fieldVal),
+					rule.ViolationForHumans(cfg.translator, fieldVal),
 				)
 			}
 		}
@@ -0,0 +1,131 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: dead_letter_jobs.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countDeadLetterJobs = `-- name: CountDeadLetterJobs :one
+select count(*) from dead_letter_jobs
+where $1::bool or resolved_at is null
+`
+
+func (q *Queries) CountDeadLetterJobs(ctx context.Context, includeResolved bool) (int64, error) {
+	row := q.db.QueryRow(ctx, countDeadLetterJobs, includeResolved)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const insertDeadLetterJob = `-- name: InsertDeadLetterJob :exec
+insert into dead_letter_jobs (job_id, kind, queue, args, reason, error, attempt)
+values ($1, $2, $3, $4, $5, $6, $7)
+on conflict (job_id) do update
+    set reason=excluded.reason,
+        error=excluded.error,
+        attempt=excluded.attempt,
+        failed_at=now(),
+        resolved_at=null
+`
+
+type InsertDeadLetterJobParams struct {
+	JobID   int64
+	Kind    string
+	Queue   string
+	Args    []byte
+	Reason  string
+	Error   string
+	Attempt int16
+}
+
+func (q *Queries) InsertDeadLetterJob(ctx context.Context, arg InsertDeadLetterJobParams) error {
+	_, err := q.db.Exec(ctx, insertDeadLetterJob,
+		arg.JobID,
+		arg.Kind,
+		arg.Queue,
+		arg.Args,
+		arg.Reason,
+		arg.Error,
+		arg.Attempt,
+	)
+	return err
+}
+
+const queryDeadLetterJobs = `-- name: QueryDeadLetterJobs :many
+select job_id, kind, queue, args, reason, error, attempt, failed_at, resolved_at from dead_letter_jobs
+where $1::bool or resolved_at is null
+order by failed_at desc
+limit $3 offset $2
+`
+
+type QueryDeadLetterJobsParams struct {
+	IncludeResolved bool
+	Offset          int32
+	Limit           int32
+}
+
+func (q *Queries) QueryDeadLetterJobs(ctx context.Context, arg QueryDeadLetterJobsParams) ([]DeadLetterJob, error) {
+	rows, err := q.db.Query(ctx, queryDeadLetterJobs, arg.IncludeResolved, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeadLetterJob
+	for rows.Next() {
+		var i DeadLetterJob
+		if err := rows.Scan(
+			&i.JobID,
+			&i.Kind,
+			&i.Queue,
+			&i.Args,
+			&i.Reason,
+			&i.Error,
+			&i.Attempt,
+			&i.FailedAt,
+			&i.ResolvedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryDeadLetterRate = `-- name: QueryDeadLetterRate :one
+select
+    (select count(*) from dead_letter_jobs where dead_letter_jobs.failed_at > $1::timestamptz) as dead_lettered,
+    (select count(*) from river_job where river_job.finalized_at > $1::timestamptz) as finalized
+`
+
+type QueryDeadLetterRateRow struct {
+	DeadLettered int64
+	Finalized    int64
+}
+
+func (q *Queries) QueryDeadLetterRate(ctx context.Context, since pgtype.Timestamptz) (QueryDeadLetterRateRow, error) {
+	row := q.db.QueryRow(ctx, queryDeadLetterRate, since)
+	var i QueryDeadLetterRateRow
+	err := row.Scan(&i.DeadLettered, &i.Finalized)
+	return i, err
+}
+
+const resolveDeadLetterJob = `-- name: ResolveDeadLetterJob :execrows
+update dead_letter_jobs set resolved_at=now() where job_id=$1 and resolved_at is null
+`
+
+func (q *Queries) ResolveDeadLetterJob(ctx context.Context, jobID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, resolveDeadLetterJob, jobID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
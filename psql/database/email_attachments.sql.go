@@ -0,0 +1,93 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: email_attachments.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteEmailAttachment = `-- name: DeleteEmailAttachment :exec
+delete from email_attachments where id=$1
+`
+
+func (q *Queries) DeleteEmailAttachment(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteEmailAttachment, id)
+	return err
+}
+
+const deleteOrphanedEmailAttachments = `-- name: DeleteOrphanedEmailAttachments :execrows
+delete from email_attachments
+where email_attachments.created_at < $1
+    and not exists (select 1 from river_job where river_job.id = email_attachments.job_id)
+`
+
+func (q *Queries) DeleteOrphanedEmailAttachments(ctx context.Context, createdBefore pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteOrphanedEmailAttachments, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const insertEmailAttachment = `-- name: InsertEmailAttachment :exec
+insert into email_attachments
+    (id, created_at, filename, content_type, content) values ($1, $2, $3, $4, $5)
+`
+
+type InsertEmailAttachmentParams struct {
+	ID          uuid.UUID
+	CreatedAt   pgtype.Timestamptz
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+func (q *Queries) InsertEmailAttachment(ctx context.Context, arg InsertEmailAttachmentParams) error {
+	_, err := q.db.Exec(ctx, insertEmailAttachment,
+		arg.ID,
+		arg.CreatedAt,
+		arg.Filename,
+		arg.ContentType,
+		arg.Content,
+	)
+	return err
+}
+
+const queryEmailAttachmentByID = `-- name: QueryEmailAttachmentByID :one
+select id, created_at, filename, content_type, content, job_id from email_attachments where id=$1
+`
+
+func (q *Queries) QueryEmailAttachmentByID(ctx context.Context, id uuid.UUID) (EmailAttachment, error) {
+	row := q.db.QueryRow(ctx, queryEmailAttachmentByID, id)
+	var i EmailAttachment
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.Filename,
+		&i.ContentType,
+		&i.Content,
+		&i.JobID,
+	)
+	return i, err
+}
+
+const setEmailAttachmentsJob = `-- name: SetEmailAttachmentsJob :exec
+update email_attachments set job_id=$1 where id = any($2::uuid[])
+`
+
+type SetEmailAttachmentsJobParams struct {
+	JobID sql.NullInt64
+	Ids   []uuid.UUID
+}
+
+func (q *Queries) SetEmailAttachmentsJob(ctx context.Context, arg SetEmailAttachmentsJobParams) error {
+	_, err := q.db.Exec(ctx, setEmailAttachmentsJob, arg.JobID, arg.Ids)
+	return err
+}
@@ -5,6 +5,7 @@
 package database
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"fmt"
 
@@ -60,6 +61,54 @@ func (ns NullRiverJobState) Value() (driver.Value, error) {
 	return string(ns.RiverJobState), nil
 }
 
+type DeadLetterJob struct {
+	JobID      int64
+	Kind       string
+	Queue      string
+	Args       []byte
+	Reason     string
+	Error      string
+	Attempt    int16
+	FailedAt   pgtype.Timestamptz
+	ResolvedAt pgtype.Timestamptz
+}
+
+type EmailAttachment struct {
+	ID          uuid.UUID
+	CreatedAt   pgtype.Timestamptz
+	Filename    string
+	ContentType string
+	Content     []byte
+	JobID       sql.NullInt64
+}
+
+type IdempotencyKey struct {
+	Key                 string
+	CreatedAt           pgtype.Timestamptz
+	ExpiresAt           pgtype.Timestamptz
+	RequestHash         sql.NullString
+	JobID               sql.NullInt64
+	ResponseStatus      sql.NullInt32
+	ResponseContentType sql.NullString
+	ResponseBody        []byte
+}
+
+type JobSchedule struct {
+	Name        string
+	Enabled     bool
+	LastJobID   sql.NullInt64
+	LastRunAt   pgtype.Timestamptz
+	LastOutcome sql.NullString
+	LastError   sql.NullString
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type RateLimit struct {
+	Name      string
+	Tokens    float64
+	UpdatedAt pgtype.Timestamptz
+}
+
 type RiverJob struct {
 	ID          int64
 	State       RiverJobState
@@ -116,4 +165,9 @@ type User struct {
 	Email           string
 	EmailVerifiedAt pgtype.Timestamptz
 	Password        string
+	Locale          string
+	IsAdmin         bool
+	Version         int64
+	DeletedAt       pgtype.Timestamptz
+	EmailReleased   bool
 }
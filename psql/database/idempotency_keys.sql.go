@@ -0,0 +1,119 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: idempotency_keys.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimIdempotencyKey = `-- name: ClaimIdempotencyKey :execrows
+insert into idempotency_keys (key, created_at, expires_at, request_hash)
+    values ($1, now(), $2, $3)
+on conflict (key) do update
+    set created_at=now(),
+        expires_at=excluded.expires_at,
+        request_hash=excluded.request_hash,
+        job_id=null,
+        response_status=null,
+        response_content_type=null,
+        response_body=null
+    where idempotency_keys.expires_at <= now()
+`
+
+type ClaimIdempotencyKeyParams struct {
+	Key         string
+	ExpiresAt   pgtype.Timestamptz
+	RequestHash sql.NullString
+}
+
+func (q *Queries) ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, claimIdempotencyKey, arg.Key, arg.ExpiresAt, arg.RequestHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :execrows
+delete from idempotency_keys where expires_at <= now()
+`
+
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredIdempotencyKeys)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteIdempotencyKey = `-- name: DeleteIdempotencyKey :exec
+delete from idempotency_keys where key=$1
+`
+
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	_, err := q.db.Exec(ctx, deleteIdempotencyKey, key)
+	return err
+}
+
+const queryIdempotencyKey = `-- name: QueryIdempotencyKey :one
+select key, created_at, expires_at, request_hash, job_id, response_status, response_content_type, response_body from idempotency_keys where key=$1
+`
+
+func (q *Queries) QueryIdempotencyKey(ctx context.Context, key string) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, queryIdempotencyKey, key)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Key,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+		&i.RequestHash,
+		&i.JobID,
+		&i.ResponseStatus,
+		&i.ResponseContentType,
+		&i.ResponseBody,
+	)
+	return i, err
+}
+
+const setIdempotencyKeyJob = `-- name: SetIdempotencyKeyJob :exec
+update idempotency_keys set job_id=$2 where key=$1
+`
+
+type SetIdempotencyKeyJobParams struct {
+	Key   string
+	JobID sql.NullInt64
+}
+
+func (q *Queries) SetIdempotencyKeyJob(ctx context.Context, arg SetIdempotencyKeyJobParams) error {
+	_, err := q.db.Exec(ctx, setIdempotencyKeyJob, arg.Key, arg.JobID)
+	return err
+}
+
+const setIdempotencyKeyResponse = `-- name: SetIdempotencyKeyResponse :exec
+update idempotency_keys
+    set response_status=$2, response_content_type=$3, response_body=$4
+where key=$1
+`
+
+type SetIdempotencyKeyResponseParams struct {
+	Key                 string
+	ResponseStatus      sql.NullInt32
+	ResponseContentType sql.NullString
+	ResponseBody        []byte
+}
+
+func (q *Queries) SetIdempotencyKeyResponse(ctx context.Context, arg SetIdempotencyKeyResponseParams) error {
+	_, err := q.db.Exec(ctx, setIdempotencyKeyResponse,
+		arg.Key,
+		arg.ResponseStatus,
+		arg.ResponseContentType,
+		arg.ResponseBody,
+	)
+	return err
+}
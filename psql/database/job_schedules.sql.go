@@ -0,0 +1,100 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: job_schedules.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+const queryJobScheduleEnabled = `-- name: QueryJobScheduleEnabled :one
+select enabled from job_schedules where name=$1
+`
+
+func (q *Queries) QueryJobScheduleEnabled(ctx context.Context, name string) (bool, error) {
+	row := q.db.QueryRow(ctx, queryJobScheduleEnabled, name)
+	var enabled bool
+	err := row.Scan(&enabled)
+	return enabled, err
+}
+
+const queryJobSchedules = `-- name: QueryJobSchedules :many
+select name, enabled, last_job_id, last_run_at, last_outcome, last_error, updated_at from job_schedules order by name
+`
+
+func (q *Queries) QueryJobSchedules(ctx context.Context) ([]JobSchedule, error) {
+	rows, err := q.db.Query(ctx, queryJobSchedules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []JobSchedule
+	for rows.Next() {
+		var i JobSchedule
+		if err := rows.Scan(
+			&i.Name,
+			&i.Enabled,
+			&i.LastJobID,
+			&i.LastRunAt,
+			&i.LastOutcome,
+			&i.LastError,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordJobScheduleRun = `-- name: RecordJobScheduleRun :exec
+insert into job_schedules (name, last_job_id, last_run_at, last_outcome, last_error)
+values ($1, $2, now(), $3, $4)
+on conflict (name) do update
+    set last_job_id=excluded.last_job_id,
+        last_run_at=excluded.last_run_at,
+        last_outcome=excluded.last_outcome,
+        last_error=excluded.last_error,
+        updated_at=now()
+`
+
+type RecordJobScheduleRunParams struct {
+	Name        string
+	LastJobID   sql.NullInt64
+	LastOutcome sql.NullString
+	LastError   sql.NullString
+}
+
+func (q *Queries) RecordJobScheduleRun(ctx context.Context, arg RecordJobScheduleRunParams) error {
+	_, err := q.db.Exec(ctx, recordJobScheduleRun,
+		arg.Name,
+		arg.LastJobID,
+		arg.LastOutcome,
+		arg.LastError,
+	)
+	return err
+}
+
+const setJobScheduleEnabled = `-- name: SetJobScheduleEnabled :exec
+insert into job_schedules (name, enabled)
+values ($1, $2)
+on conflict (name) do update
+    set enabled=excluded.enabled,
+        updated_at=now()
+`
+
+type SetJobScheduleEnabledParams struct {
+	Name    string
+	Enabled bool
+}
+
+func (q *Queries) SetJobScheduleEnabled(ctx context.Context, arg SetJobScheduleEnabledParams) error {
+	_, err := q.db.Exec(ctx, setJobScheduleEnabled, arg.Name, arg.Enabled)
+	return err
+}
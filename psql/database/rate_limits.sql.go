@@ -0,0 +1,50 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: rate_limits.sql
+
+package database
+
+import (
+	"context"
+)
+
+const takeRateLimitTokens = `-- name: TakeRateLimitTokens :one
+insert into rate_limits as limits (name, tokens, updated_at)
+values ($1, $2::float8 - $3::float8, now())
+on conflict (name) do update
+    set tokens = least(
+            $2::float8,
+            limits.tokens + extract(epoch from now() - limits.updated_at)::float8 * $4::float8
+        ) - $3::float8,
+        updated_at = now()
+    where least(
+            $2::float8,
+            limits.tokens + extract(epoch from now() - limits.updated_at)::float8 * $4::float8
+        ) >= $3::float8 + $5::float8
+returning tokens
+`
+
+type TakeRateLimitTokensParams struct {
+	Name    string
+	Burst   float64
+	Cost    float64
+	Rate    float64
+	Reserve float64
+}
+
+// refills the bucket for the time since it was last taken from and takes cost
+// tokens, leaving at least reserve tokens behind; no row is returned when
+// there are not enough tokens
+func (q *Queries) TakeRateLimitTokens(ctx context.Context, arg TakeRateLimitTokensParams) (float64, error) {
+	row := q.db.QueryRow(ctx, takeRateLimitTokens,
+		arg.Name,
+		arg.Burst,
+		arg.Cost,
+		arg.Rate,
+		arg.Reserve,
+	)
+	var tokens float64
+	err := row.Scan(&tokens)
+	return tokens, err
+}
@@ -0,0 +1,26 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: river.sql
+
+package database
+
+import (
+	"context"
+)
+
+const queryRiverLeader = `-- name: QueryRiverLeader :one
+select elected_at, expires_at, leader_id, name from river_leader where name = $1
+`
+
+func (q *Queries) QueryRiverLeader(ctx context.Context, name string) (RiverLeader, error) {
+	row := q.db.QueryRow(ctx, queryRiverLeader, name)
+	var i RiverLeader
+	err := row.Scan(
+		&i.ElectedAt,
+		&i.ExpiresAt,
+		&i.LeaderID,
+		&i.Name,
+	)
+	return i, err
+}
@@ -0,0 +1,255 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: river_jobs.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+const countJobs = `-- name: CountJobs :one
+select count(*) from river_job
+where
+    ($1::river_job_state is null or state = $1::river_job_state)
+    and ($2::text is null or queue = $2::text)
+    and ($3::text is null or kind = $3::text)
+`
+
+type CountJobsParams struct {
+	State NullRiverJobState
+	Queue sql.NullString
+	Kind  sql.NullString
+}
+
+func (q *Queries) CountJobs(ctx context.Context, arg CountJobsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countJobs, arg.State, arg.Queue, arg.Kind)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteJob = `-- name: DeleteJob :execrows
+delete from river_job where id=$1 and state <> 'running'
+`
+
+func (q *Queries) DeleteJob(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteJob, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const queryJobByID = `-- name: QueryJobByID :one
+select id, state, attempt, max_attempts, attempted_at, created_at, finalized_at, scheduled_at, priority, args, attempted_by, errors, kind, metadata, queue, tags from river_job where id=$1
+`
+
+func (q *Queries) QueryJobByID(ctx context.Context, id int64) (RiverJob, error) {
+	row := q.db.QueryRow(ctx, queryJobByID, id)
+	var i RiverJob
+	err := row.Scan(
+		&i.ID,
+		&i.State,
+		&i.Attempt,
+		&i.MaxAttempts,
+		&i.AttemptedAt,
+		&i.CreatedAt,
+		&i.FinalizedAt,
+		&i.ScheduledAt,
+		&i.Priority,
+		&i.Args,
+		&i.AttemptedBy,
+		&i.Errors,
+		&i.Kind,
+		&i.Metadata,
+		&i.Queue,
+		&i.Tags,
+	)
+	return i, err
+}
+
+const queryJobKinds = `-- name: QueryJobKinds :many
+select distinct kind from river_job order by kind
+`
+
+func (q *Queries) QueryJobKinds(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, queryJobKinds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var kind string
+		if err := rows.Scan(&kind); err != nil {
+			return nil, err
+		}
+		items = append(items, kind)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryJobQueueStats = `-- name: QueryJobQueueStats :many
+select queue, state, count(*) as total from river_job
+group by queue, state
+order by queue, state
+`
+
+type QueryJobQueueStatsRow struct {
+	Queue string
+	State RiverJobState
+	Total int64
+}
+
+func (q *Queries) QueryJobQueueStats(ctx context.Context) ([]QueryJobQueueStatsRow, error) {
+	rows, err := q.db.Query(ctx, queryJobQueueStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryJobQueueStatsRow
+	for rows.Next() {
+		var i QueryJobQueueStatsRow
+		if err := rows.Scan(&i.Queue, &i.State, &i.Total); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryJobs = `-- name: QueryJobs :many
+select id, state, attempt, max_attempts, attempted_at, created_at, finalized_at, scheduled_at, priority, args, attempted_by, errors, kind, metadata, queue, tags from river_job
+where
+    ($1::river_job_state is null or state = $1::river_job_state)
+    and ($2::text is null or queue = $2::text)
+    and ($3::text is null or kind = $3::text)
+order by id desc
+limit $5 offset $4
+`
+
+type QueryJobsParams struct {
+	State  NullRiverJobState
+	Queue  sql.NullString
+	Kind   sql.NullString
+	Offset int32
+	Limit  int32
+}
+
+func (q *Queries) QueryJobs(ctx context.Context, arg QueryJobsParams) ([]RiverJob, error) {
+	rows, err := q.db.Query(ctx, queryJobs,
+		arg.State,
+		arg.Queue,
+		arg.Kind,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RiverJob
+	for rows.Next() {
+		var i RiverJob
+		if err := rows.Scan(
+			&i.ID,
+			&i.State,
+			&i.Attempt,
+			&i.MaxAttempts,
+			&i.AttemptedAt,
+			&i.CreatedAt,
+			&i.FinalizedAt,
+			&i.ScheduledAt,
+			&i.Priority,
+			&i.Args,
+			&i.AttemptedBy,
+			&i.Errors,
+			&i.Kind,
+			&i.Metadata,
+			&i.Queue,
+			&i.Tags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const queryOldestAvailableJobAges = `-- name: QueryOldestAvailableJobAges :many
+select queue, extract(epoch from now() - min(scheduled_at))::float8 as age_seconds
+from river_job
+where state = 'available' and scheduled_at <= now()
+group by queue
+`
+
+type QueryOldestAvailableJobAgesRow struct {
+	Queue      string
+	AgeSeconds float64
+}
+
+func (q *Queries) QueryOldestAvailableJobAges(ctx context.Context) ([]QueryOldestAvailableJobAgesRow, error) {
+	rows, err := q.db.Query(ctx, queryOldestAvailableJobAges)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QueryOldestAvailableJobAgesRow
+	for rows.Next() {
+		var i QueryOldestAvailableJobAgesRow
+		if err := rows.Scan(&i.Queue, &i.AgeSeconds); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retryJob = `-- name: RetryJob :one
+update river_job
+    set state='available',
+        scheduled_at=now(),
+        finalized_at=null,
+        max_attempts=greatest(max_attempts, attempt + 1)
+where id=$1 and state <> 'running'
+returning id, state, attempt, max_attempts, attempted_at, created_at, finalized_at, scheduled_at, priority, args, attempted_by, errors, kind, metadata, queue, tags
+`
+
+func (q *Queries) RetryJob(ctx context.Context, id int64) (RiverJob, error) {
+	row := q.db.QueryRow(ctx, retryJob, id)
+	var i RiverJob
+	err := row.Scan(
+		&i.ID,
+		&i.State,
+		&i.Attempt,
+		&i.MaxAttempts,
+		&i.AttemptedAt,
+		&i.CreatedAt,
+		&i.FinalizedAt,
+		&i.ScheduledAt,
+		&i.Priority,
+		&i.Args,
+		&i.AttemptedBy,
+		&i.Errors,
+		&i.Kind,
+		&i.Metadata,
+		&i.Queue,
+		&i.Tags,
+	)
+	return i, err
+}
@@ -0,0 +1,70 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: river_queues.sql
+
+package database
+
+import (
+	"context"
+)
+
+const pauseRiverQueue = `-- name: PauseRiverQueue :exec
+insert into river_queue (name, paused_at, updated_at) values ($1, now(), now())
+on conflict (name) do update set paused_at=now(), updated_at=now()
+`
+
+func (q *Queries) PauseRiverQueue(ctx context.Context, name string) error {
+	_, err := q.db.Exec(ctx, pauseRiverQueue, name)
+	return err
+}
+
+const queryRiverQueuePaused = `-- name: QueryRiverQueuePaused :one
+select exists(select 1 from river_queue where name=$1 and paused_at is not null)
+`
+
+func (q *Queries) QueryRiverQueuePaused(ctx context.Context, name string) (bool, error) {
+	row := q.db.QueryRow(ctx, queryRiverQueuePaused, name)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const queryRiverQueues = `-- name: QueryRiverQueues :many
+select name, created_at, metadata, paused_at, updated_at from river_queue order by name
+`
+
+func (q *Queries) QueryRiverQueues(ctx context.Context) ([]RiverQueue, error) {
+	rows, err := q.db.Query(ctx, queryRiverQueues)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RiverQueue
+	for rows.Next() {
+		var i RiverQueue
+		if err := rows.Scan(
+			&i.Name,
+			&i.CreatedAt,
+			&i.Metadata,
+			&i.PausedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resumeRiverQueue = `-- name: ResumeRiverQueue :exec
+update river_queue set paused_at=null, updated_at=now() where name=$1
+`
+
+func (q *Queries) ResumeRiverQueue(ctx context.Context, name string) error {
+	_, err := q.db.Exec(ctx, resumeRiverQueue, name)
+	return err
+}
@@ -13,7 +13,7 @@ import (
 )
 
 const changeUserPassword = `-- name: ChangeUserPassword :exec
-update users set updated_at=$2, password=$3 where id=$1
+update users set updated_at=$2, password=$3, version=version + 1 where id=$1 and deleted_at is null
 `
 
 type ChangeUserPasswordParams struct {
@@ -27,13 +27,24 @@ func (q *Queries) ChangeUserPassword(ctx context.Context, arg ChangeUserPassword
 	return err
 }
 
-const deleteUser = `-- name: DeleteUser :exec
-delete from users where id=$1
+const deleteUser = `-- name: DeleteUser :execrows
+update users
+    set deleted_at=$2, updated_at=$2, email_released=$3, version=version + 1
+where id=$1 and deleted_at is null
 `
 
-func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteUser, id)
-	return err
+type DeleteUserParams struct {
+	ID            uuid.UUID
+	DeletedAt     pgtype.Timestamptz
+	EmailReleased bool
+}
+
+func (q *Queries) DeleteUser(ctx context.Context, arg DeleteUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUser, arg.ID, arg.DeletedAt, arg.EmailReleased)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const insertUser = `-- name: InsertUser :one
@@ -41,7 +52,7 @@ insert into
     users (id, created_at, updated_at, name, email, password)
 values
     ($1, $2, $3, $4, $5, $6)
-returning id, created_at, updated_at, name, email, email_verified_at, password
+returning id, created_at, updated_at, name, email, email_verified_at, password, locale, is_admin, version, deleted_at, email_released
 `
 
 type InsertUserParams struct {
@@ -71,12 +82,66 @@ func (q *Queries) InsertUser(ctx context.Context, arg InsertUserParams) (User, e
 		&i.Email,
 		&i.EmailVerifiedAt,
 		&i.Password,
+		&i.Locale,
+		&i.IsAdmin,
+		&i.Version,
+		&i.DeletedAt,
+		&i.EmailReleased,
 	)
 	return i, err
 }
 
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :execrows
+delete from users where deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, deletedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedUsers, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const queryDeletedUsers = `-- name: QueryDeletedUsers :many
+select id, created_at, updated_at, name, email, email_verified_at, password, locale, is_admin, version, deleted_at, email_released from users where deleted_at is not null
+`
+
+func (q *Queries) QueryDeletedUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.db.Query(ctx, queryDeletedUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Email,
+			&i.EmailVerifiedAt,
+			&i.Password,
+			&i.Locale,
+			&i.IsAdmin,
+			&i.Version,
+			&i.DeletedAt,
+			&i.EmailReleased,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const queryUserByEmail = `-- name: QueryUserByEmail :one
-select id, created_at, updated_at, name, email, email_verified_at, password from users where email=$1
+select id, created_at, updated_at, name, email, email_verified_at, password, locale, is_admin, version, deleted_at, email_released from users where email=$1 and deleted_at is null
 `
 
 func (q *Queries) QueryUserByEmail(ctx context.Context, email string) (User, error) {
@@ -90,12 +155,17 @@ func (q *Queries) QueryUserByEmail(ctx context.Context, email string) (User, err
 		&i.Email,
 		&i.EmailVerifiedAt,
 		&i.Password,
+		&i.Locale,
+		&i.IsAdmin,
+		&i.Version,
+		&i.DeletedAt,
+		&i.EmailReleased,
 	)
 	return i, err
 }
 
 const queryUserByID = `-- name: QueryUserByID :one
-select id, created_at, updated_at, name, email, email_verified_at, password from users where id=$1
+select id, created_at, updated_at, name, email, email_verified_at, password, locale, is_admin, version, deleted_at, email_released from users where id=$1 and deleted_at is null
 `
 
 func (q *Queries) QueryUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -109,12 +179,17 @@ func (q *Queries) QueryUserByID(ctx context.Context, id uuid.UUID) (User, error)
 		&i.Email,
 		&i.EmailVerifiedAt,
 		&i.Password,
+		&i.Locale,
+		&i.IsAdmin,
+		&i.Version,
+		&i.DeletedAt,
+		&i.EmailReleased,
 	)
 	return i, err
 }
 
 const queryUsers = `-- name: QueryUsers :many
-select id, created_at, updated_at, name, email, email_verified_at, password from users
+select id, created_at, updated_at, name, email, email_verified_at, password, locale, is_admin, version, deleted_at, email_released from users where deleted_at is null
 `
 
 func (q *Queries) QueryUsers(ctx context.Context) ([]User, error) {
@@ -134,6 +209,102 @@ func (q *Queries) QueryUsers(ctx context.Context) ([]User, error) {
 			&i.Email,
 			&i.EmailVerifiedAt,
 			&i.Password,
+			&i.Locale,
+			&i.IsAdmin,
+			&i.Version,
+			&i.DeletedAt,
+			&i.EmailReleased,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreUser = `-- name: RestoreUser :execrows
+update users
+    set deleted_at=null, updated_at=$2, email_released=false, version=version + 1
+where id=$1 and deleted_at >= $3
+`
+
+type RestoreUserParams struct {
+	ID        uuid.UUID
+	UpdatedAt pgtype.Timestamptz
+	DeletedAt pgtype.Timestamptz
+}
+
+func (q *Queries) RestoreUser(ctx context.Context, arg RestoreUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreUser, arg.ID, arg.UpdatedAt, arg.DeletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const searchUsers = `-- name: SearchUsers :many
+select
+    users.id, users.created_at, users.updated_at, users.name, users.email, users.email_verified_at, users.password, users.locale, users.is_admin, users.version, users.deleted_at, users.email_released,
+    greatest(
+        ts_rank(
+            setweight(to_tsvector('simple', name), 'A') ||
+            setweight(to_tsvector('simple', replace(replace(email, '@', ' '), '.', ' ')), 'B'),
+            websearch_to_tsquery('simple', $1::text)
+        ),
+        word_similarity($1::text, name),
+        word_similarity($1::text, email)
+    )::real as rank
+from users
+where deleted_at is null and (
+    (
+        setweight(to_tsvector('simple', name), 'A') ||
+        setweight(to_tsvector('simple', replace(replace(email, '@', ' '), '.', ' ')), 'B')
+    ) @@ websearch_to_tsquery('simple', $1::text)
+    or $1::text <% name
+    or $1::text <% email
+)
+order by rank desc, created_at desc, id
+limit $2::int
+`
+
+type SearchUsersParams struct {
+	Query      string
+	MaxResults int32
+}
+
+type SearchUsersRow struct {
+	User User
+	Rank float32
+}
+
+// the search vector is the expression of users_search_idx, which only
+// serves the query as long as the two are the same
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]SearchUsersRow, error) {
+	rows, err := q.db.Query(ctx, searchUsers, arg.Query, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchUsersRow
+	for rows.Next() {
+		var i SearchUsersRow
+		if err := rows.Scan(
+			&i.User.ID,
+			&i.User.CreatedAt,
+			&i.User.UpdatedAt,
+			&i.User.Name,
+			&i.User.Email,
+			&i.User.EmailVerifiedAt,
+			&i.User.Password,
+			&i.User.Locale,
+			&i.User.IsAdmin,
+			&i.User.Version,
+			&i.User.DeletedAt,
+			&i.User.EmailReleased,
+			&i.Rank,
 		); err != nil {
 			return nil, err
 		}
@@ -145,11 +316,28 @@ func (q *Queries) QueryUsers(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
+const setUserAdmin = `-- name: SetUserAdmin :exec
+update users
+    set is_admin=$2, updated_at=$3, version=version + 1
+where id=$1 and deleted_at is null
+`
+
+type SetUserAdminParams struct {
+	ID        uuid.UUID
+	IsAdmin   bool
+	UpdatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) error {
+	_, err := q.db.Exec(ctx, setUserAdmin, arg.ID, arg.IsAdmin, arg.UpdatedAt)
+	return err
+}
+
 const updateUser = `-- name: UpdateUser :one
 update users
-    set updated_at=$2, name=$3, email=$4, password=$5
-where id = $1
-returning id, created_at, updated_at, name, email, email_verified_at, password
+    set updated_at=$2, name=$3, email=$4, version=version + 1
+where id = $1 and version = $5 and deleted_at is null
+returning id, created_at, updated_at, name, email, email_verified_at, password, locale, is_admin, version, deleted_at, email_released
 `
 
 type UpdateUserParams struct {
@@ -157,7 +345,7 @@ type UpdateUserParams struct {
 	UpdatedAt pgtype.Timestamptz
 	Name      string
 	Email     string
-	Password  string
+	Version   int64
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
@@ -166,7 +354,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.UpdatedAt,
 		arg.Name,
 		arg.Email,
-		arg.Password,
+		arg.Version,
 	)
 	var i User
 	err := row.Scan(
@@ -177,12 +365,32 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.EmailVerifiedAt,
 		&i.Password,
+		&i.Locale,
+		&i.IsAdmin,
+		&i.Version,
+		&i.DeletedAt,
+		&i.EmailReleased,
 	)
 	return i, err
 }
 
+const updateUserLocale = `-- name: UpdateUserLocale :exec
+update users set updated_at=$2, locale=$3, version=version + 1 where id=$1 and deleted_at is null
+`
+
+type UpdateUserLocaleParams struct {
+	ID        uuid.UUID
+	UpdatedAt pgtype.Timestamptz
+	Locale    string
+}
+
+func (q *Queries) UpdateUserLocale(ctx context.Context, arg UpdateUserLocaleParams) error {
+	_, err := q.db.Exec(ctx, updateUserLocale, arg.ID, arg.UpdatedAt, arg.Locale)
+	return err
+}
+
 const verifyUserEmail = `-- name: VerifyUserEmail :exec
-update users set updated_at=$2, email_verified_at=$3 where email=$1
+update users set updated_at=$2, email_verified_at=$3, version=version + 1 where email=$1 and deleted_at is null
 `
 
 type VerifyUserEmailParams struct {
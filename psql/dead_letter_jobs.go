@@ -0,0 +1,56 @@
+package psql
+
+import (
+	"context"
+
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/psql/database"
+)
+
+func (p Postgres) QueryDeadLetterJobs(
+	ctx context.Context,
+	includeResolved bool,
+	limit int,
+	offset int,
+) ([]models.DeadLetterJob, error) {
+	rows, err := p.Queries.QueryDeadLetterJobs(ctx, database.QueryDeadLetterJobsParams{
+		IncludeResolved: includeResolved,
+		Limit:           int32(limit),
+		Offset:          int32(offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters := make([]models.DeadLetterJob, len(rows))
+	for i, row := range rows {
+		deadLetters[i] = models.DeadLetterJob{
+			JobID:      row.JobID,
+			Kind:       row.Kind,
+			Queue:      row.Queue,
+			Args:       row.Args,
+			Reason:     row.Reason,
+			Error:      row.Error,
+			Attempt:    int(row.Attempt),
+			FailedAt:   row.FailedAt.Time,
+			ResolvedAt: row.ResolvedAt.Time,
+		}
+	}
+
+	return deadLetters, nil
+}
+
+// ResolveDeadLetterJob marks a dead-lettered job as reviewed, hiding it from
+// the list of jobs that need attention.
+func (p Postgres) ResolveDeadLetterJob(ctx context.Context, jobID int64) error {
+	resolved, err := p.Queries.ResolveDeadLetterJob(ctx, jobID)
+	if err != nil {
+		return err
+	}
+
+	if resolved == 0 {
+		return ErrNoRowWithIdentifier
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/mbvlabs/grafto/psql/database"
+)
+
+func (p Postgres) InsertEmailAttachment(
+	ctx context.Context,
+	id uuid.UUID,
+	filename string,
+	contentType string,
+	content []byte,
+) error {
+	return p.Queries.InsertEmailAttachment(ctx, database.InsertEmailAttachmentParams{
+		ID: id,
+		CreatedAt: pgtype.Timestamptz{
+			Time:  time.Now(),
+			Valid: true,
+		},
+		Filename:    filename,
+		ContentType: contentType,
+		Content:     content,
+	})
+}
+
+// SetEmailAttachmentsJob records the job that sends the stored attachments,
+// so they are kept for as long as the job is, see
+// DeleteOrphanedEmailAttachments.
+func (p Postgres) SetEmailAttachmentsJob(
+	ctx context.Context,
+	jobID int64,
+	ids []uuid.UUID,
+) error {
+	return p.Queries.SetEmailAttachmentsJob(ctx, database.SetEmailAttachmentsJobParams{
+		JobID: sql.NullInt64{Int64: jobID, Valid: true},
+		Ids:   ids,
+	})
+}
@@ -0,0 +1,33 @@
+package psql
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewQueryTracerWithClock logs to logger and takes the time from now, so the
+// duration of a query is predictable.
+func NewQueryTracerWithClock(
+	tracer telemetry.Tracer,
+	slowQueryThreshold time.Duration,
+	logger *slog.Logger,
+	now func() time.Time,
+) *QueryTracer {
+	return &QueryTracer{tracer, slowQueryThreshold, logger, now}
+}
+
+func NewPoolCollector(pool *pgxpool.Pool, name string) prometheus.Collector {
+	return newPoolCollector(pool, name)
+}
+
+var (
+	QueryName = queryName
+	IsWrite   = isWrite
+	PageSQL   = pageSQL
+
+	QueryErrors = queryErrors
+)
@@ -0,0 +1,70 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/psql/database"
+)
+
+// ClaimIdempotencyKey reports whether the key was claimed, which it is unless
+// it is already in use and has not expired.
+func (p Postgres) ClaimIdempotencyKey(
+	ctx context.Context,
+	key string,
+	requestHash string,
+	expiresAt time.Time,
+) (bool, error) {
+	claimed, err := p.Queries.ClaimIdempotencyKey(ctx, database.ClaimIdempotencyKeyParams{
+		Key: key,
+		ExpiresAt: pgtype.Timestamptz{
+			Time:  expiresAt,
+			Valid: true,
+		},
+		RequestHash: sql.NullString{String: requestHash, Valid: requestHash != ""},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return claimed > 0, nil
+}
+
+func (p Postgres) QueryIdempotencyKey(
+	ctx context.Context,
+	key string,
+) (models.IdempotencyKey, error) {
+	idempotencyKey, err := p.Queries.QueryIdempotencyKey(ctx, key)
+	if err != nil {
+		return models.IdempotencyKey{}, err
+	}
+
+	return models.IdempotencyKey{
+		Key:                 idempotencyKey.Key,
+		CreatedAt:           idempotencyKey.CreatedAt.Time,
+		ExpiresAt:           idempotencyKey.ExpiresAt.Time,
+		RequestHash:         idempotencyKey.RequestHash.String,
+		JobID:               idempotencyKey.JobID.Int64,
+		ResponseStatus:      int(idempotencyKey.ResponseStatus.Int32),
+		ResponseContentType: idempotencyKey.ResponseContentType.String,
+		ResponseBody:        idempotencyKey.ResponseBody,
+	}, nil
+}
+
+func (p Postgres) StoreIdempotentResponse(
+	ctx context.Context,
+	key string,
+	status int,
+	contentType string,
+	body []byte,
+) error {
+	return p.Queries.SetIdempotencyKeyResponse(ctx, database.SetIdempotencyKeyResponseParams{
+		Key:                 key,
+		ResponseStatus:      sql.NullInt32{Int32: int32(status), Valid: true},
+		ResponseContentType: sql.NullString{String: contentType, Valid: contentType != ""},
+		ResponseBody:        body,
+	})
+}
@@ -0,0 +1,38 @@
+package psql
+
+import (
+	"context"
+
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/psql/database"
+)
+
+// QueryJobScheduleStates returns the schedules that have been disabled or
+// have run, schedules without a row are enabled and have never run.
+func (p Postgres) QueryJobScheduleStates(ctx context.Context) ([]models.JobScheduleState, error) {
+	rows, err := p.Queries.QueryJobSchedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]models.JobScheduleState, len(rows))
+	for i, row := range rows {
+		states[i] = models.JobScheduleState{
+			Name:        row.Name,
+			Enabled:     row.Enabled,
+			LastJobID:   row.LastJobID.Int64,
+			LastRunAt:   row.LastRunAt.Time,
+			LastOutcome: row.LastOutcome.String,
+			LastError:   row.LastError.String,
+		}
+	}
+
+	return states, nil
+}
+
+func (p Postgres) SetJobScheduleEnabled(ctx context.Context, name string, enabled bool) error {
+	return p.Queries.SetJobScheduleEnabled(ctx, database.SetJobScheduleEnabledParams{
+		Name:    name,
+		Enabled: enabled,
+	})
+}
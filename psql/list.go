@@ -0,0 +1,217 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mbvlabs/grafto/pkg/pagination"
+	"github.com/mbvlabs/grafto/psql/database"
+)
+
+var errListQueryNotRun = errors.New("list query did not run a query")
+
+/*
+List runs a sqlc :many query for one page of params. The query runs as sqlc
+wrote it, with its own args and scanning, but wrapped in a subquery that the
+filters, sort and limit of params are applied to, so list queries stay plain
+selects in psql/queries. The total comes from counting the filtered rows.
+*/
+func List[T any](
+	ctx context.Context,
+	db database.DBTX,
+	params pagination.Params,
+	query func(q *database.Queries) ([]T, error),
+) (pagination.Page[T], error) {
+	lister := &listDB{DBTX: db, params: params}
+
+	items, err := query(database.New(lister))
+	if err != nil {
+		return pagination.Page[T]{}, err
+	}
+	if lister.countSQL == "" {
+		return pagination.Page[T]{}, errListQueryNotRun
+	}
+
+	var total int64
+	if err := db.QueryRow(ctx, lister.countSQL, lister.countArgs...).Scan(&total); err != nil {
+		return pagination.Page[T]{}, err
+	}
+
+	return pagination.NewPage(params, items, lister.keys, total)
+}
+
+// listDB rewrites the query sqlc sends into one for a page, and records the
+// sort value and key of every row for the cursors.
+type listDB struct {
+	database.DBTX
+	params pagination.Params
+
+	countSQL  string
+	countArgs []any
+	keys      [][2]any
+}
+
+func (l *listDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	listSQL, listArgs, countSQL, countArgs := pageSQL(sql, args, l.params)
+	l.countSQL, l.countArgs = countSQL, countArgs
+
+	rows, err := l.DBTX.Query(ctx, listSQL, listArgs...)
+	if err != nil {
+		return nil, err
+	}
+	if l.params.Mode == pagination.Offset {
+		return rows, nil
+	}
+
+	return &keyRows{Rows: rows, list: l, sortIndex: -1, keyIndex: -1}, nil
+}
+
+type keyRows struct {
+	pgx.Rows
+	list      *listDB
+	sortIndex int
+	keyIndex  int
+}
+
+func (r *keyRows) Scan(dest ...any) error {
+	if r.sortIndex < 0 || r.keyIndex < 0 {
+		for i, field := range r.FieldDescriptions() {
+			if field.Name == r.list.params.SortField.Column {
+				r.sortIndex = i
+			}
+			if field.Name == r.list.params.Key.Column {
+				r.keyIndex = i
+			}
+		}
+		if r.sortIndex < 0 || r.keyIndex < 0 {
+			return fmt.Errorf(
+				"list query does not select %s and %s",
+				r.list.params.SortField.Column,
+				r.list.params.Key.Column,
+			)
+		}
+	}
+
+	values, err := r.Values()
+	if err != nil {
+		return err
+	}
+	r.list.keys = append(r.list.keys, [2]any{values[r.sortIndex], values[r.keyIndex]})
+
+	return r.Rows.Scan(dest...)
+}
+
+/*
+pageSQL wraps the sql of a sqlc query in the query for a page and the query
+that counts the rows of every page. The name sqlc gives the query is kept in
+front, so query tracing still knows what ran. Columns only ever come from the
+spec, values are passed as args numbered after the ones of the query.
+*/
+func pageSQL(
+	sql string,
+	args []any,
+	params pagination.Params,
+) (string, []any, string, []any) {
+	name, body := "", strings.TrimSpace(sql)
+	if strings.HasPrefix(body, sqlcNamePrefix) {
+		name, body, _ = strings.Cut(body, "\n")
+		name += "\n"
+	}
+
+	listArgs := append([]any{}, args...)
+	arg := func(value any) string {
+		listArgs = append(listArgs, value)
+		return "$" + strconv.Itoa(len(listArgs))
+	}
+
+	var conditions []string
+	for _, filter := range params.Filters {
+		column := pgx.Identifier{filter.Column}.Sanitize()
+
+		switch filter.Op {
+		case pagination.Contains:
+			conditions = append(conditions, column+" ilike "+arg(containsPattern(filter.Value)))
+		case pagination.AtLeast:
+			conditions = append(conditions, column+" >= "+arg(filter.Value))
+		case pagination.Before:
+			conditions = append(conditions, column+" < "+arg(filter.Value))
+		default:
+			conditions = append(conditions, column+" = "+arg(filter.Value))
+		}
+	}
+
+	from := "select * from (\n" + body + "\n) as list"
+	if len(conditions) > 0 {
+		from += " where " + strings.Join(conditions, " and ")
+	}
+
+	countSQL := strings.TrimSuffix(name, " :many\n")
+	if countSQL != "" {
+		countSQL += "Count :one\n"
+	}
+	countSQL += strings.Replace(from, "select *", "select count(*)", 1)
+	countArgs := slices.Clone(listArgs)
+
+	sortColumn := pgx.Identifier{params.SortField.Column}.Sanitize()
+	keyColumn := pgx.Identifier{params.Key.Column}.Sanitize()
+
+	// a cursor that points backwards reads the rows before it in reverse,
+	// NewPage puts them back in order
+	desc := params.Sort.Desc
+	if params.Cursor != nil && params.Cursor.Before {
+		desc = !desc
+	}
+	direction, comparison := "asc", ">"
+	if desc {
+		direction, comparison = "desc", "<"
+	}
+
+	listSQL := from
+	if params.Cursor != nil {
+		var keyset string
+		if params.SortField.Column == params.Key.Column {
+			keyset = fmt.Sprintf("%s %s %s", keyColumn, comparison, arg(params.Cursor.KeyValue))
+		} else {
+			keyset = fmt.Sprintf(
+				"(%s, %s) %s (%s, %s)",
+				sortColumn,
+				keyColumn,
+				comparison,
+				arg(params.Cursor.SortValue),
+				arg(params.Cursor.KeyValue),
+			)
+		}
+
+		if len(conditions) > 0 {
+			listSQL += " and " + keyset
+		} else {
+			listSQL += " where " + keyset
+		}
+	}
+
+	listSQL += fmt.Sprintf(" order by %s %s", sortColumn, direction)
+	if params.SortField.Column != params.Key.Column {
+		listSQL += fmt.Sprintf(", %s %s", keyColumn, direction)
+	}
+
+	if params.Mode == pagination.Offset {
+		listSQL += fmt.Sprintf(" limit %s offset %s", arg(params.Limit), arg(params.Offset()))
+	} else {
+		// one row more than the page tells whether there is a next one
+		listSQL += fmt.Sprintf(" limit %s", arg(params.Limit+1))
+	}
+
+	return name + listSQL, listArgs, countSQL, countArgs
+}
+
+// containsPattern matches value anywhere in a column with ilike, escaping the
+// wildcards ilike would otherwise treat value as.
+func containsPattern(value any) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(fmt.Sprint(value))
+	return "%" + escaped + "%"
+}
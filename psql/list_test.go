@@ -0,0 +1,125 @@
+package psql_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mbvlabs/grafto/pkg/pagination"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/stretchr/testify/assert"
+)
+
+const queryUsersByLocale = `-- name: QueryUsersByLocale :many
+select id, created_at, name, email from users where locale=$1
+`
+
+func TestPageSQL(t *testing.T) {
+	nextCursor := func(sort string) string {
+		params := mustParse(t, "limit=1&sort="+sort, psql.UserListSpec)
+		page, err := pagination.NewPage(
+			params,
+			[]string{"a", "b"},
+			[][2]any{{"Ada", uuid.Nil}, {"Bob", uuid.Nil}},
+			2,
+		)
+		assert.NoError(t, err)
+
+		return page.NextCursor
+	}
+
+	tests := map[string]struct {
+		query             string
+		expectedListSQL   string
+		expectedListArgs  []any
+		expectedCountSQL  string
+		expectedCountArgs []any
+	}{
+		"should sort the first page and fetch a row to spare": {
+			query: "",
+			expectedListSQL: "-- name: QueryUsersByLocale :many\n" +
+				"select * from (\nselect id, created_at, name, email from users where locale=$1\n) as list" +
+				` order by "created_at" desc, "id" desc limit $2`,
+			expectedListArgs: []any{"da", 26},
+			expectedCountSQL: "-- name: QueryUsersByLocaleCount :one\n" +
+				"select count(*) from (\nselect id, created_at, name, email from users where locale=$1\n) as list",
+			expectedCountArgs: []any{"da"},
+		},
+		"should filter with args numbered after the ones of the query": {
+			query: "email=a_b&is_admin=true&limit=10&sort=email",
+			expectedListSQL: "-- name: QueryUsersByLocale :many\n" +
+				"select * from (\nselect id, created_at, name, email from users where locale=$1\n) as list" +
+				` where "email" ilike $2 and "is_admin" = $3 order by "email" asc, "id" asc limit $4`,
+			expectedListArgs: []any{"da", `%a\_b%`, true, 11},
+			expectedCountSQL: "-- name: QueryUsersByLocaleCount :one\n" +
+				"select count(*) from (\nselect id, created_at, name, email from users where locale=$1\n) as list" +
+				` where "email" ilike $2 and "is_admin" = $3`,
+			expectedCountArgs: []any{"da", `%a\_b%`, true},
+		},
+		"should continue after the cursor": {
+			query: "is_admin=false&cursor=" + nextCursor("-name"),
+			expectedListSQL: "-- name: QueryUsersByLocale :many\n" +
+				"select * from (\nselect id, created_at, name, email from users where locale=$1\n) as list" +
+				` where "is_admin" = $2 and ("name", "id") < ($3, $4) order by "name" desc, "id" desc limit $5`,
+			expectedListArgs: []any{"da", false, "Ada", uuid.Nil, 26},
+			expectedCountSQL: "-- name: QueryUsersByLocaleCount :one\n" +
+				"select count(*) from (\nselect id, created_at, name, email from users where locale=$1\n) as list" +
+				` where "is_admin" = $2`,
+			expectedCountArgs: []any{"da", false},
+		},
+		"should skip to the page in offset mode": {
+			query: "page=3&limit=10",
+			expectedListSQL: "-- name: QueryUsersByLocale :many\n" +
+				"select * from (\nselect id, created_at, name, email from users where locale=$1\n) as list" +
+				` order by "created_at" desc, "id" desc limit $2 offset $3`,
+			expectedListArgs: []any{"da", 10, 20},
+			expectedCountSQL: "-- name: QueryUsersByLocaleCount :one\n" +
+				"select count(*) from (\nselect id, created_at, name, email from users where locale=$1\n) as list",
+			expectedCountArgs: []any{"da"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			params := mustParse(t, test.query, psql.UserListSpec)
+
+			listSQL, listArgs, countSQL, countArgs := psql.PageSQL(
+				queryUsersByLocale,
+				[]any{"da"},
+				params,
+			)
+
+			assert.Equal(t, test.expectedListSQL, listSQL)
+			assert.Equal(t, test.expectedListArgs, listArgs)
+			assert.Equal(t, test.expectedCountSQL, countSQL)
+			assert.Equal(t, test.expectedCountArgs, countArgs)
+		})
+	}
+}
+
+func TestPageSQLBackwards(t *testing.T) {
+	params := mustParse(t, "sort=name", psql.UserListSpec)
+	params.Cursor = &pagination.Cursor{
+		Sort:      params.Sort,
+		SortValue: "Bob",
+		KeyValue:  uuid.Nil,
+		Before:    true,
+	}
+
+	listSQL, _, _, _ := psql.PageSQL(queryUsersByLocale, []any{"da"}, params)
+
+	// the rows before the cursor are read in reverse, closest first
+	assert.Contains(t, listSQL, `where ("name", "id") < ($2, $3) order by "name" desc, "id" desc`)
+}
+
+func mustParse(t *testing.T, query string, spec pagination.Spec) pagination.Params {
+	t.Helper()
+
+	values, err := url.ParseQuery(query)
+	assert.NoError(t, err)
+
+	params, err := pagination.Parse(values, spec)
+	assert.NoError(t, err)
+
+	return params
+}
@@ -0,0 +1,93 @@
+package psql
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "db"
+
+var (
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "query_duration_seconds",
+		Help:      "Time spent on a query, by the name sqlc gives it.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 16),
+	}, []string{"query"})
+
+	queryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "query_errors_total",
+		Help:      "Queries that failed, by the name sqlc gives them.",
+	}, []string{"query"})
+)
+
+type poolStater interface {
+	Stat() *pgxpool.Stat
+}
+
+/*
+poolCollector exports the statistics of a pool when prometheus scrapes them,
+labelled with the name of the pool so a read replica can be told apart from
+the primary.
+*/
+type poolCollector struct {
+	pool poolStater
+
+	acquiredConns    *prometheus.Desc
+	idleConns        *prometheus.Desc
+	totalConns       *prometheus.Desc
+	maxConns         *prometheus.Desc
+	acquires         *prometheus.Desc
+	emptyAcquires    *prometheus.Desc
+	canceledAcquires *prometheus.Desc
+	acquireWait      *prometheus.Desc
+}
+
+func newPoolCollector(pool poolStater, name string) *poolCollector {
+	desc := func(metric string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "pool", metric),
+			help,
+			nil,
+			prometheus.Labels{"pool": name},
+		)
+	}
+
+	return &poolCollector{
+		pool:             pool,
+		acquiredConns:    desc("acquired_connections", "Connections in use."),
+		idleConns:        desc("idle_connections", "Connections waiting to be used."),
+		totalConns:       desc("connections", "Connections open, including ones being established."),
+		maxConns:         desc("max_connections", "Connections the pool opens at most."),
+		acquires:         desc("acquires_total", "Connections acquired from the pool."),
+		emptyAcquires:    desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		canceledAcquires: desc("canceled_acquires_total", "Acquires cancelled before they got a connection."),
+		acquireWait:      desc("acquire_wait_seconds_total", "Time spent acquiring connections."),
+	}
+}
+
+func (c *poolCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.acquiredConns
+	descs <- c.idleConns
+	descs <- c.totalConns
+	descs <- c.maxConns
+	descs <- c.acquires
+	descs <- c.emptyAcquires
+	descs <- c.canceledAcquires
+	descs <- c.acquireWait
+}
+
+func (c *poolCollector) Collect(metrics chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	metrics <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	metrics <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	metrics <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	metrics <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	metrics <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	metrics <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	metrics <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	metrics <- prometheus.MustNewConstMetric(c.acquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
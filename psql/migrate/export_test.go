@@ -0,0 +1,6 @@
+package migrate
+
+func (m Migration) Up() []string   { return m.up }
+func (m Migration) Down() []string { return m.down }
+
+func (m Migration) NoTransaction() bool { return m.noTransaction }
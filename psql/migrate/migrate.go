@@ -0,0 +1,335 @@
+// Package migrate applies the migrations embedded in the binary, keeping track
+// of them in the same goose_db_version table the goose cli uses.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrNoMigrationToRollBack = errors.New("no applied migration to roll back")
+	ErrPendingMigrations     = errors.New("database has pending migrations")
+	ErrUnknownStartupMode    = errors.New("unknown migration startup mode")
+)
+
+// The ways the binaries can treat migrations when they start, see
+// config.Migrations.
+const (
+	StartupOff   = "off"
+	StartupCheck = "check"
+	StartupApply = "apply"
+)
+
+// lockID is the key of the advisory lock held while migrating, it spells
+// grafto
+const lockID int64 = 0x67726166746f
+
+// Status is a migration and when it was applied, if it has been.
+type Status struct {
+	Migration
+	AppliedAt time.Time
+}
+
+func (s Status) IsApplied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// New reads the migrations in fsys, usually migrations.FS.
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Parse(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{pool, migrations}, nil
+}
+
+/*
+Up applies every migration that has not been applied, oldest first. It
+includes migrations older than the latest applied one, which happens when
+branches with migrations are merged out of order.
+*/
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		if err := createVersionTable(ctx, conn); err != nil {
+			return err
+		}
+
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+
+			if err := run(ctx, conn, migration, migration.up, insertVersion); err != nil {
+				return fmt.Errorf("could not apply migration %s: %w", migration.Name, err)
+			}
+			slog.InfoContext(ctx, "applied migration", "migration", migration.Name)
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// Down rolls back the latest applied migration.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	var rolledBack Migration
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		if err := createVersionTable(ctx, conn); err != nil {
+			return err
+		}
+
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		var latest int64
+		for version := range versions {
+			latest = max(latest, version)
+		}
+		if latest == 0 {
+			return ErrNoMigrationToRollBack
+		}
+
+		migration, ok := m.migration(latest)
+		if !ok {
+			return fmt.Errorf("latest applied migration %d is not in this binary", latest)
+		}
+
+		if err := run(ctx, conn, migration, migration.down, deleteVersion); err != nil {
+			return fmt.Errorf("could not roll back migration %s: %w", migration.Name, err)
+		}
+		slog.InfoContext(ctx, "rolled back migration", "migration", migration.Name)
+
+		rolledBack = migration
+		return nil
+	})
+	if err != nil {
+		return Migration{}, err
+	}
+
+	return rolledBack, nil
+}
+
+// Status lists every migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var exists bool
+	if err := m.pool.QueryRow(
+		ctx,
+		"select to_regclass('goose_db_version') is not null",
+	).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	versions := map[int64]time.Time{}
+	if exists {
+		var err error
+		versions, err = appliedVersions(ctx, m.pool)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{migration, versions[migration.Version]}
+	}
+
+	return statuses, nil
+}
+
+/*
+OnStartup runs when a binary starts. StartupCheck fails with
+ErrPendingMigrations when a migration has not been applied, StartupApply
+applies them. The advisory lock makes instances that start together wait for
+the one that migrates instead of migrating at the same time.
+*/
+func (m *Migrator) OnStartup(ctx context.Context, mode string) error {
+	switch mode {
+	case StartupOff:
+		return nil
+	case StartupCheck:
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		var pending []string
+		for _, status := range statuses {
+			if !status.IsApplied() {
+				pending = append(pending, status.Name)
+			}
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%w: %v", ErrPendingMigrations, pending)
+		}
+
+		return nil
+	case StartupApply:
+		_, err := m.Up(ctx)
+		return err
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownStartupMode, mode)
+	}
+}
+
+func (m *Migrator) migration(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+// withLock runs fn on a single connection that holds the migration advisory
+// lock, waiting for other instances to release it first.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "select pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("could not take migration lock: %w", err)
+	}
+	defer func() {
+		// unlock even when ctx is done, the lock would otherwise stay with the
+		// connection in the pool
+		if _, err := conn.Exec(
+			context.WithoutCancel(ctx),
+			"select pg_advisory_unlock($1)",
+			lockID,
+		); err != nil {
+			slog.ErrorContext(ctx, "could not release migration lock", "error", err)
+			conn.Conn().Close(context.WithoutCancel(ctx))
+		}
+	}()
+
+	return fn(conn.Conn())
+}
+
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// createVersionTable creates the table the way goose does, including the row
+// for version 0 goose expects.
+func createVersionTable(ctx context.Context, conn *pgx.Conn) error {
+	if _, err := conn.Exec(ctx, `create table if not exists goose_db_version (
+		id integer primary key generated by default as identity,
+		version_id bigint not null,
+		is_applied boolean not null,
+		tstamp timestamp not null default now()
+	)`); err != nil {
+		return err
+	}
+
+	_, err := conn.Exec(ctx, `insert into goose_db_version (version_id, is_applied)
+		select 0, true where not exists (select 1 from goose_db_version)`)
+
+	return err
+}
+
+// appliedVersions returns when each applied migration was applied. Older
+// versions of goose recorded roll backs as rows with is_applied false, so the
+// latest row of a version decides.
+func appliedVersions(ctx context.Context, db querier) (map[int64]time.Time, error) {
+	rows, err := db.Query(ctx, `select distinct on (version_id) version_id, is_applied, tstamp
+		from goose_db_version
+		order by version_id, id desc`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := map[int64]time.Time{}
+	for rows.Next() {
+		var (
+			version   int64
+			isApplied bool
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &isApplied, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		if isApplied && version > 0 {
+			versions[version] = appliedAt
+		}
+	}
+
+	return versions, rows.Err()
+}
+
+func insertVersion(ctx context.Context, db querier, version int64) error {
+	_, err := db.Exec(
+		ctx,
+		"insert into goose_db_version (version_id, is_applied) values ($1, true)",
+		version,
+	)
+	return err
+}
+
+func deleteVersion(ctx context.Context, db querier, version int64) error {
+	_, err := db.Exec(ctx, "delete from goose_db_version where version_id=$1", version)
+	return err
+}
+
+// run executes the statements and records the version in one transaction,
+// unless the migration opted out of it.
+func run(
+	ctx context.Context,
+	conn *pgx.Conn,
+	migration Migration,
+	statements []string,
+	record func(ctx context.Context, db querier, version int64) error,
+) error {
+	exec := func(db querier) error {
+		for _, statement := range statements {
+			if _, err := db.Exec(ctx, statement); err != nil {
+				return err
+			}
+		}
+
+		return record(ctx, db, migration.Version)
+	}
+
+	if migration.noTransaction {
+		return exec(conn)
+	}
+
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		return exec(tx)
+	})
+}
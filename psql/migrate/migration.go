@@ -0,0 +1,167 @@
+package migrate
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidMigration = errors.New("invalid migration")
+
+// annotationPrefix starts the lines goose reads instructions from
+const annotationPrefix = "-- +goose "
+
+/*
+Migration is a sql file in the format goose uses, so files created with
+`goose create` keep working. Its version is the prefix of the file name and it
+runs in a transaction unless it is annotated with NO TRANSACTION.
+*/
+type Migration struct {
+	Version int64
+	Name    string
+
+	up            []string
+	down          []string
+	noTransaction bool
+}
+
+// Parse reads the migrations in the root of fsys, ordered by version.
+func Parse(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, err := parseMigration(name, content)
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, migration)
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf(
+				"%w: %s and %s have the same version",
+				ErrInvalidMigration,
+				migrations[i-1].Name,
+				migrations[i].Name,
+			)
+		}
+	}
+
+	return migrations, nil
+}
+
+func parseMigration(fileName string, content []byte) (Migration, error) {
+	name := strings.TrimSuffix(path.Base(fileName), ".sql")
+
+	prefix, _, _ := strings.Cut(name, "_")
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || version < 1 {
+		return Migration{}, fmt.Errorf("%w: %s does not start with a version", ErrInvalidMigration, fileName)
+	}
+
+	migration := Migration{Version: version, Name: name}
+
+	var (
+		section   *[]string
+		inBlock   bool
+		statement strings.Builder
+		hasUp     bool
+	)
+	flush := func() {
+		if hasSQL(statement.String()) {
+			*section = append(*section, statement.String())
+		}
+		statement.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if annotation, ok := strings.CutPrefix(trimmed, annotationPrefix); ok {
+			switch strings.ToUpper(strings.TrimSpace(annotation)) {
+			case "UP":
+				section, hasUp = &migration.up, true
+			case "DOWN":
+				if section == nil || inBlock || hasSQL(statement.String()) {
+					return Migration{}, fmt.Errorf("%w: %s has an unfinished up statement", ErrInvalidMigration, fileName)
+				}
+				section = &migration.down
+				statement.Reset()
+			case "STATEMENTBEGIN":
+				inBlock = true
+			case "STATEMENTEND":
+				if !inBlock || section == nil {
+					return Migration{}, fmt.Errorf("%w: %s ends a statement it did not begin", ErrInvalidMigration, fileName)
+				}
+				flush()
+				inBlock = false
+			case "NO TRANSACTION":
+				migration.noTransaction = true
+			default:
+				return Migration{}, fmt.Errorf("%w: %s has unknown annotation %q", ErrInvalidMigration, fileName, annotation)
+			}
+
+			continue
+		}
+
+		if section == nil || (statement.Len() == 0 && trimmed == "") {
+			continue
+		}
+
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Migration{}, err
+	}
+
+	switch {
+	case !hasUp:
+		return Migration{}, fmt.Errorf("%w: %s has no up section", ErrInvalidMigration, fileName)
+	case inBlock:
+		return Migration{}, fmt.Errorf("%w: %s does not end its last statement", ErrInvalidMigration, fileName)
+	case hasSQL(statement.String()):
+		return Migration{}, fmt.Errorf("%w: %s has a statement without a semicolon", ErrInvalidMigration, fileName)
+	}
+
+	return migration, nil
+}
+
+// hasSQL reports whether statement has anything besides comments and blank
+// lines.
+func hasSQL(statement string) bool {
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return true
+		}
+	}
+
+	return false
+}
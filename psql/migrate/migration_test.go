@@ -0,0 +1,169 @@
+package migrate_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mbvlabs/grafto/migrations"
+	"github.com/mbvlabs/grafto/psql/migrate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		content               string
+		expectedUp            []string
+		expectedDown          []string
+		expectedNoTransaction bool
+		expectedErr           error
+	}{
+		"should keep statement blocks whole": {
+			content: `-- +goose Up
+-- +goose StatementBegin
+create table users (id uuid);
+create index on users (id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table users;
+-- +goose StatementEnd
+`,
+			expectedUp:   []string{"create table users (id uuid);\ncreate index on users (id);\n"},
+			expectedDown: []string{"drop table users;\n"},
+		},
+		"should split statements outside blocks on semicolons": {
+			content: `-- +goose Up
+-- comments are kept with the statement
+alter table users
+    add column name text;
+alter table users add column email text;
+
+-- +goose Down
+alter table users drop column email;
+alter table users drop column name;
+`,
+			expectedUp: []string{
+				"-- comments are kept with the statement\nalter table users\n    add column name text;\n",
+				"alter table users add column email text;\n",
+			},
+			expectedDown: []string{
+				"alter table users drop column email;\n",
+				"alter table users drop column name;\n",
+			},
+		},
+		"should opt out of transactions": {
+			content: `-- +goose NO TRANSACTION
+-- +goose Up
+create index concurrently on users (email);
+`,
+			expectedUp:            []string{"create index concurrently on users (email);\n"},
+			expectedNoTransaction: true,
+		},
+		"should reject files without an up section": {
+			content:     "create table users (id uuid);\n",
+			expectedErr: migrate.ErrInvalidMigration,
+		},
+		"should reject unfinished blocks": {
+			content: `-- +goose Up
+-- +goose StatementBegin
+create table users (id uuid);
+`,
+			expectedErr: migrate.ErrInvalidMigration,
+		},
+		"should reject statements without a semicolon": {
+			content: `-- +goose Up
+create table users (id uuid)
+`,
+			expectedErr: migrate.ErrInvalidMigration,
+		},
+		"should reject unknown annotations": {
+			content: `-- +goose Up
+-- +goose ENVSUB ON
+create table users (id uuid);
+`,
+			expectedErr: migrate.ErrInvalidMigration,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := migrate.Parse(fstest.MapFS{
+				"20240101120000_create_users_table.sql": {Data: []byte(test.content)},
+			})
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, parsed, 1)
+
+			migration := parsed[0]
+			assert.Equal(t, int64(20240101120000), migration.Version)
+			assert.Equal(t, "20240101120000_create_users_table", migration.Name)
+			assert.Equal(t, test.expectedUp, migration.Up())
+			assert.Equal(t, test.expectedDown, migration.Down())
+			assert.Equal(t, test.expectedNoTransaction, migration.NoTransaction())
+		})
+	}
+}
+
+func TestParseOrdersByVersion(t *testing.T) {
+	content := []byte("-- +goose Up\nselect 1;\n")
+
+	parsed, err := migrate.Parse(fstest.MapFS{
+		"3_third.sql":  {Data: content},
+		"10_tenth.sql": {Data: content},
+		"1_first.sql":  {Data: content},
+		"README.md":    {Data: []byte("not a migration")},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 3, 10}, []int64{parsed[0].Version, parsed[1].Version, parsed[2].Version})
+}
+
+func TestParseRejectsInvalidNames(t *testing.T) {
+	content := []byte("-- +goose Up\nselect 1;\n")
+
+	tests := map[string]fstest.MapFS{
+		"should reject names without a version": {
+			"create_users.sql": {Data: content},
+		},
+		"should reject versions used twice": {
+			"1_create_users.sql":  {Data: content},
+			"01_create_token.sql": {Data: content},
+		},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := migrate.Parse(fsys)
+
+			assert.ErrorIs(t, err, migrate.ErrInvalidMigration)
+		})
+	}
+}
+
+func TestEmbeddedMigrationsParse(t *testing.T) {
+	parsed, err := migrate.Parse(migrations.FS)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, parsed)
+	for _, migration := range parsed {
+		assert.NotEmpty(t, migration.Up(), migration.Name)
+		assert.NotEmpty(t, migration.Down(), migration.Name)
+	}
+}
+
+func TestOnStartupWithoutDatabase(t *testing.T) {
+	migrator, err := migrate.New(nil, migrations.FS)
+	assert.NoError(t, err)
+
+	assert.NoError(t, migrator.OnStartup(context.Background(), migrate.StartupOff))
+	assert.ErrorIs(
+		t,
+		migrator.OnStartup(context.Background(), "sometimes"),
+		migrate.ErrUnknownStartupMode,
+	)
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mbvlabs/grafto/config"
 	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var (
@@ -19,31 +23,281 @@ var (
 	)
 )
 
+const (
+	pgerrSerializationFailure = "40001"
+	pgerrDeadlockDetected     = "40P01"
+	pgerrUniqueViolation      = "23505"
+)
+
+// Pool is the part of *pgxpool.Pool that Postgres depends on.
+type Pool interface {
+	database.DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 type Postgres struct {
 	*database.Queries
-	tx *pgxpool.Pool
+	pool    Pool
+	tx      pgx.Tx
+	replica database.DBTX
 }
 
-func NewPostgres(dbPool *pgxpool.Pool) Postgres {
-	return Postgres{
-		database.New(dbPool),
+type PostgresOpts func(p *Postgres)
+
+// WithReplica sends the reads a replica can serve to replica, see
+// WithReadYourWrites.
+func WithReplica(replica database.DBTX) PostgresOpts {
+	return func(p *Postgres) {
+		p.replica = replica
+	}
+}
+
+func NewPostgres(dbPool Pool, opts ...PostgresOpts) Postgres {
+	p := Postgres{
+		database.New(writeTracker{dbPool}),
 		dbPool,
+		nil,
+		nil,
 	}
+
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	return p
 }
 
+// BeginTx starts a transaction, or a savepoint when p is already part of one.
 func (p Postgres) BeginTx(ctx context.Context) (pgx.Tx, error) {
-	return p.tx.Begin(ctx)
+	if p.tx != nil {
+		return p.tx.Begin(ctx)
+	}
+
+	return p.pool.Begin(ctx)
+}
+
+// Tx returns the transaction p runs its queries in, or nil outside of WithTx.
+func (p Postgres) Tx() pgx.Tx {
+	return p.tx
+}
+
+const (
+	// maxTxAttempts is how many times WithTx runs a transaction that fails to
+	// serialize or deadlocks before giving up
+	maxTxAttempts = 3
+	// txRetryBackoff is how long WithTx waits before the first retry, it grows
+	// with every attempt
+	txRetryBackoff = 20 * time.Millisecond
+)
+
+/*
+WithTx runs fn as a single unit of work. The Postgres handed to fn runs every
+query in the same pgx.Tx and exposes it through Tx, so services that enqueue
+jobs can use river's InsertTx and have the job committed together with the
+rows it depends on. The transaction is committed when fn returns nil and
+rolled back when it returns an error or panics.
+
+Calling WithTx on the Postgres handed to fn runs the inner fn in a savepoint,
+so its error only rolls back what the inner fn did.
+
+When the transaction fails to serialize or deadlocks, the outermost WithTx
+runs fn again in a new transaction, so fn must not have effects outside the
+transaction besides what can safely happen twice.
+*/
+func (p Postgres) WithTx(
+	ctx context.Context,
+	fn func(tx Postgres) error,
+) error {
+	// a serialization failure aborts the whole transaction, so a savepoint
+	// can not be retried on its own
+	if p.tx != nil {
+		return p.runTx(ctx, fn)
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = p.runTx(ctx, fn)
+		if !isRetryableTxErr(err) || attempt == maxTxAttempts {
+			return err
+		}
+
+		slog.WarnContext(ctx, "retrying transaction", "error", err, "attempt", attempt)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * txRetryBackoff):
+		}
+	}
+
+	return err
+}
+
+func (p Postgres) runTx(ctx context.Context, fn func(tx Postgres) error) error {
+	// reads after the transaction should see what it wrote
+	markWritten(ctx)
+
+	tx, err := p.BeginTx(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not begin transaction", "error", err)
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			rollback(ctx, tx)
+			panic(r)
+		}
+	}()
+
+	if err := fn(Postgres{p.Queries.WithTx(tx), p.pool, tx, p.replica}); err != nil {
+		rollback(ctx, tx)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "could not commit transaction", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// isRetryableTxErr reports whether err is a serialization failure or a
+// deadlock, which postgres expects the client to retry.
+func isRetryableTxErr(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == pgerrSerializationFailure || pgErr.Code == pgerrDeadlockDetected
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgerrUniqueViolation
+}
+
+func rollback(ctx context.Context, tx pgx.Tx) {
+	if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		slog.ErrorContext(ctx, "could not roll back transaction", "error", err)
+	}
+}
+
+/*
+poolCfg holds the settings CreatePooledConnection applies on top of the
+database url. Zero values keep the defaults of pgxpool.
+*/
+type poolCfg struct {
+	maxConns          int32
+	minConns          int32
+	maxConnLifetime   time.Duration
+	maxConnIdleTime   time.Duration
+	healthCheckPeriod time.Duration
+	metricsName       string
+	tracer            pgx.QueryTracer
+}
+
+type PoolCfgOpts func(cfg *poolCfg)
+
+// WithPoolSize keeps at least minConns connections open and opens at most
+// maxConns.
+func WithPoolSize(minConns int32, maxConns int32) PoolCfgOpts {
+	return func(cfg *poolCfg) {
+		cfg.minConns = minConns
+		cfg.maxConns = maxConns
+	}
+}
+
+// WithConnLifetime closes connections that are older than maxLifetime or have
+// been idle for longer than maxIdleTime.
+func WithConnLifetime(maxLifetime time.Duration, maxIdleTime time.Duration) PoolCfgOpts {
+	return func(cfg *poolCfg) {
+		cfg.maxConnLifetime = maxLifetime
+		cfg.maxConnIdleTime = maxIdleTime
+	}
+}
+
+// WithHealthCheckPeriod sets how often idle connections are checked and
+// closed when they have expired or broken.
+func WithHealthCheckPeriod(period time.Duration) PoolCfgOpts {
+	return func(cfg *poolCfg) {
+		cfg.healthCheckPeriod = period
+	}
+}
+
+// WithPoolSettings applies the pool settings of cfg.
+func WithPoolSettings(cfg config.Database) PoolCfgOpts {
+	return func(poolCfg *poolCfg) {
+		WithPoolSize(cfg.MinConns, cfg.MaxConns)(poolCfg)
+		WithConnLifetime(cfg.MaxConnLifetime, cfg.MaxConnIdleTime)(poolCfg)
+		WithHealthCheckPeriod(cfg.HealthCheckPeriod)(poolCfg)
+	}
+}
+
+// WithPoolMetrics exports the statistics of the pool to prometheus, labelled
+// with name.
+func WithPoolMetrics(name string) PoolCfgOpts {
+	return func(cfg *poolCfg) {
+		cfg.metricsName = name
+	}
+}
+
+// WithQueryTracer traces every query made through the pool, see QueryTracer.
+func WithQueryTracer(tracer pgx.QueryTracer) PoolCfgOpts {
+	return func(cfg *poolCfg) {
+		cfg.tracer = tracer
+	}
 }
 
 func CreatePooledConnection(
 	ctx context.Context,
 	uri string,
+	opts ...PoolCfgOpts,
 ) (*pgxpool.Pool, error) {
-	dbpool, err := pgxpool.New(ctx, uri)
+	cfg := &poolCfg{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(uri)
+	if err != nil {
+		slog.Error("could not parse database url", "error", err)
+		return nil, err
+	}
+	if cfg.maxConns > 0 {
+		poolConfig.MaxConns = cfg.maxConns
+	}
+	if cfg.minConns > 0 {
+		poolConfig.MinConns = cfg.minConns
+	}
+	if cfg.maxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.maxConnLifetime
+	}
+	if cfg.maxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.maxConnIdleTime
+	}
+	if cfg.healthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = cfg.healthCheckPeriod
+	}
+	if cfg.tracer != nil {
+		poolConfig.ConnConfig.Tracer = cfg.tracer
+	}
+
+	dbpool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		slog.Error("could not establish connection to database", "error", err)
 		return nil, err
 	}
 
+	if cfg.metricsName != "" {
+		if err := prometheus.Register(newPoolCollector(dbpool, cfg.metricsName)); err != nil {
+			dbpool.Close()
+			slog.Error("could not register pool metrics", "error", err, "pool", cfg.metricsName)
+			return nil, err
+		}
+	}
+
 	return dbpool, nil
 }
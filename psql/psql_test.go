@@ -0,0 +1,205 @@
+package psql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errSerialization = &pgconn.PgError{Code: "40001", Message: "could not serialize access"}
+	errDeadlock      = &pgconn.PgError{Code: "40P01", Message: "deadlock detected"}
+	errUniqueEmail   = &pgconn.PgError{Code: "23505", Message: "duplicate key value"}
+)
+
+// fakeTx embeds pgx.Tx so only the methods WithTx uses need an
+// implementation; anything else panics.
+type fakeTx struct {
+	pgx.Tx
+	commitErr  error
+	committed  bool
+	rolledBack bool
+	savepoints []*fakeTx
+}
+
+func (tx *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	savepoint := &fakeTx{}
+	tx.savepoints = append(tx.savepoints, savepoint)
+	return savepoint, nil
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	if tx.commitErr != nil {
+		return tx.commitErr
+	}
+
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	tx.rolledBack = true
+	return nil
+}
+
+type fakePool struct {
+	psql.Pool
+	commitErrs []error
+	begun      []*fakeTx
+}
+
+func (p *fakePool) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx := &fakeTx{}
+	if len(p.commitErrs) > 0 {
+		tx.commitErr, p.commitErrs = p.commitErrs[0], p.commitErrs[1:]
+	}
+	p.begun = append(p.begun, tx)
+	return tx, nil
+}
+
+func TestWithTxRetries(t *testing.T) {
+	tests := map[string]struct {
+		fnErrs            []error
+		commitErrs        []error
+		expectedErr       error
+		expectedAttempts  int
+		expectedCommitted bool
+	}{
+		"should commit when fn succeeds": {
+			expectedAttempts:  1,
+			expectedCommitted: true,
+		},
+		"should retry a serialization failure": {
+			fnErrs:            []error{errSerialization},
+			expectedAttempts:  2,
+			expectedCommitted: true,
+		},
+		"should retry a deadlock": {
+			fnErrs:            []error{errDeadlock},
+			expectedAttempts:  2,
+			expectedCommitted: true,
+		},
+		"should retry a serialization failure wrapped by fn": {
+			fnErrs:            []error{errors.Join(errors.New("could not insert user"), errSerialization)},
+			expectedAttempts:  2,
+			expectedCommitted: true,
+		},
+		"should retry a serialization failure on commit": {
+			commitErrs:        []error{errSerialization},
+			expectedAttempts:  2,
+			expectedCommitted: true,
+		},
+		"should give up after the last attempt": {
+			fnErrs:           []error{errSerialization, errSerialization, errSerialization},
+			expectedErr:      errSerialization,
+			expectedAttempts: 3,
+		},
+		"should not retry other errors": {
+			fnErrs:           []error{errUniqueEmail},
+			expectedErr:      errUniqueEmail,
+			expectedAttempts: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pool := &fakePool{commitErrs: test.commitErrs}
+			db := psql.NewPostgres(pool)
+
+			attempts := 0
+			err := db.WithTx(context.Background(), func(tx psql.Postgres) error {
+				attempts++
+				if attempts <= len(test.fnErrs) {
+					return test.fnErrs[attempts-1]
+				}
+
+				return nil
+			})
+
+			assert.ErrorIs(t, err, test.expectedErr)
+			assert.Equal(t, test.expectedAttempts, attempts)
+			assert.Len(t, pool.begun, test.expectedAttempts)
+			last := pool.begun[len(pool.begun)-1]
+			assert.Equal(t, test.expectedCommitted, last.committed)
+			for _, tx := range pool.begun[:len(pool.begun)-1] {
+				assert.True(t, tx.rolledBack || tx.commitErr != nil)
+			}
+		})
+	}
+}
+
+func TestWithTxNested(t *testing.T) {
+	tests := map[string]struct {
+		innerErr             error
+		expectedInnerCommit  bool
+		expectedInnerRolled  bool
+		expectedOuterAttempt int
+	}{
+		"should release the savepoint when the inner fn succeeds": {
+			expectedInnerCommit:  true,
+			expectedOuterAttempt: 1,
+		},
+		"should only roll back the savepoint when the inner fn fails": {
+			innerErr:             errUniqueEmail,
+			expectedInnerRolled:  true,
+			expectedOuterAttempt: 1,
+		},
+		"should leave retrying a serialization failure to the outermost transaction": {
+			innerErr:             errSerialization,
+			expectedInnerRolled:  true,
+			expectedOuterAttempt: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			pool := &fakePool{}
+			db := psql.NewPostgres(pool)
+
+			outerAttempts, innerAttempts := 0, 0
+			err := db.WithTx(context.Background(), func(tx psql.Postgres) error {
+				outerAttempts++
+
+				innerErr := tx.WithTx(context.Background(), func(tx psql.Postgres) error {
+					innerAttempts++
+					return test.innerErr
+				})
+				assert.ErrorIs(t, innerErr, test.innerErr)
+
+				// the caller decides whether the inner failure fails the
+				// whole unit of work
+				return nil
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedOuterAttempt, outerAttempts)
+			assert.Equal(t, 1, innerAttempts)
+
+			outer := pool.begun[0]
+			assert.True(t, outer.committed)
+			assert.Len(t, outer.savepoints, 1)
+			assert.Equal(t, test.expectedInnerCommit, outer.savepoints[0].committed)
+			assert.Equal(t, test.expectedInnerRolled, outer.savepoints[0].rolledBack)
+		})
+	}
+}
+
+func TestWithTxRollsBackOnPanic(t *testing.T) {
+	pool := &fakePool{}
+	db := psql.NewPostgres(pool)
+
+	assert.Panics(t, func() {
+		_ = db.WithTx(context.Background(), func(tx psql.Postgres) error {
+			panic("boom")
+		})
+	})
+
+	assert.Len(t, pool.begun, 1)
+	assert.True(t, pool.begun[0].rolledBack)
+	assert.False(t, pool.begun[0].committed)
+}
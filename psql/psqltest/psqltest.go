@@ -0,0 +1,176 @@
+/*
+Package psqltest runs tests against a real Postgres, given by the
+TEST_DATABASE_URL env var. Every test package gets a database of its own with
+the migrations applied, and every test a transaction that is rolled back when
+it ends, so tests neither see nor leave behind each other's rows. Tests skip
+when TEST_DATABASE_URL is not set.
+
+A test package sets it up in TestMain:
+
+	func TestMain(m *testing.M) {
+		os.Exit(psqltest.Main(m))
+	}
+*/
+package psqltest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mbvlabs/grafto/migrations"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/mbvlabs/grafto/psql/migrate"
+)
+
+// DatabaseURLEnv names the env var with the url of the Postgres to test
+// against. The user must be allowed to create databases.
+const DatabaseURLEnv = "TEST_DATABASE_URL"
+
+// pool is connected to the database of the test package by Main.
+var pool *pgxpool.Pool
+
+/*
+Main creates a database for the test package, applies the migrations to it,
+runs the tests and drops the database again. It returns the exit code for
+os.Exit. Without TEST_DATABASE_URL the tests run without a database, and the
+ones that need it skip.
+*/
+func Main(m *testing.M) int {
+	url := os.Getenv(DatabaseURLEnv)
+	if url == "" {
+		return m.Run()
+	}
+
+	ctx := context.Background()
+
+	admin, err := pgx.Connect(ctx, url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "psqltest: could not connect to %s: %v\n", DatabaseURLEnv, err)
+		return 1
+	}
+	defer admin.Close(ctx)
+
+	name, err := databaseName()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "psqltest: %v\n", err)
+		return 1
+	}
+	identifier := pgx.Identifier{name}.Sanitize()
+
+	if _, err := admin.Exec(ctx, "create database "+identifier); err != nil {
+		fmt.Fprintf(os.Stderr, "psqltest: could not create database %s: %v\n", name, err)
+		return 1
+	}
+	defer func() {
+		// with force, as a test that failed may have left a connection open
+		if _, err := admin.Exec(ctx, "drop database if exists "+identifier+" with (force)"); err != nil {
+			fmt.Fprintf(os.Stderr, "psqltest: could not drop database %s: %v\n", name, err)
+		}
+	}()
+
+	pool, err = connect(ctx, url, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "psqltest: %v\n", err)
+		return 1
+	}
+	defer pool.Close()
+
+	return m.Run()
+}
+
+// databaseName names the database after the test binary, so a database left
+// behind by a killed run can be told apart, with a random suffix so packages
+// tested in parallel do not clash.
+func databaseName() (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	binary := strings.TrimSuffix(filepath.Base(os.Args[0]), ".test")
+	binary = regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(binary), "_")
+
+	return fmt.Sprintf("grafto_test_%s_%s", binary, hex.EncodeToString(suffix)), nil
+}
+
+func connect(ctx context.Context, url string, database string) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ConnConfig.Database = database
+
+	testPool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %w", database, err)
+	}
+
+	migrator, err := migrate.New(testPool, migrations.FS)
+	if err == nil {
+		_, err = migrator.Up(ctx)
+	}
+	if err != nil {
+		testPool.Close()
+		return nil, fmt.Errorf("could not migrate %s: %w", database, err)
+	}
+
+	return testPool, nil
+}
+
+// Pool returns the pool of the database of the test package, and skips the
+// test when there is none. Rows written through it are kept until the
+// package is done, prefer DB or Tx.
+func Pool(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+
+	if os.Getenv(DatabaseURLEnv) == "" {
+		t.Skipf("%s is not set", DatabaseURLEnv)
+	}
+	if pool == nil {
+		t.Fatal("psqltest.Main must be run from TestMain")
+	}
+
+	return pool
+}
+
+/*
+Tx begins a transaction that is rolled back when the test ends. A statement
+that fails aborts the transaction along with the rest of the test, so run
+statements that are expected to fail in psql.Postgres.WithTx, which only rolls
+back to a savepoint.
+*/
+func Tx(t testing.TB) pgx.Tx {
+	t.Helper()
+
+	tx, err := Pool(t).Begin(context.Background())
+	if err != nil {
+		t.Fatalf("could not begin transaction: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := tx.Rollback(context.Background()); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			t.Errorf("could not roll back transaction: %v", err)
+		}
+	})
+
+	return tx
+}
+
+// DB returns a psql.Postgres that runs every query in the transaction of Tx.
+// Its WithTx uses savepoints, so the code under test can commit and still be
+// rolled back.
+func DB(t testing.TB, opts ...psql.PostgresOpts) psql.Postgres {
+	t.Helper()
+
+	return psql.NewPostgres(Tx(t), opts...)
+}
@@ -0,0 +1,27 @@
+-- name: InsertDeadLetterJob :exec
+insert into dead_letter_jobs (job_id, kind, queue, args, reason, error, attempt)
+values ($1, $2, $3, $4, $5, $6, $7)
+on conflict (job_id) do update
+    set reason=excluded.reason,
+        error=excluded.error,
+        attempt=excluded.attempt,
+        failed_at=now(),
+        resolved_at=null;
+
+-- name: QueryDeadLetterJobs :many
+select * from dead_letter_jobs
+where sqlc.arg('include_resolved')::bool or resolved_at is null
+order by failed_at desc
+limit sqlc.arg('limit') offset sqlc.arg('offset');
+
+-- name: CountDeadLetterJobs :one
+select count(*) from dead_letter_jobs
+where sqlc.arg('include_resolved')::bool or resolved_at is null;
+
+-- name: ResolveDeadLetterJob :execrows
+update dead_letter_jobs set resolved_at=now() where job_id=$1 and resolved_at is null;
+
+-- name: QueryDeadLetterRate :one
+select
+    (select count(*) from dead_letter_jobs where dead_letter_jobs.failed_at > sqlc.arg('since')::timestamptz) as dead_lettered,
+    (select count(*) from river_job where river_job.finalized_at > sqlc.arg('since')::timestamptz) as finalized;
@@ -0,0 +1,17 @@
+-- name: InsertEmailAttachment :exec
+insert into email_attachments
+    (id, created_at, filename, content_type, content) values ($1, $2, $3, $4, $5);
+
+-- name: QueryEmailAttachmentByID :one
+select * from email_attachments where id=$1;
+
+-- name: DeleteEmailAttachment :exec
+delete from email_attachments where id=$1;
+
+-- name: SetEmailAttachmentsJob :exec
+update email_attachments set job_id=$1 where id = any(sqlc.arg(ids)::uuid[]);
+
+-- name: DeleteOrphanedEmailAttachments :execrows
+delete from email_attachments
+where email_attachments.created_at < sqlc.arg(created_before)
+    and not exists (select 1 from river_job where river_job.id = email_attachments.job_id);
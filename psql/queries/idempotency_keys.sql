@@ -0,0 +1,29 @@
+-- name: ClaimIdempotencyKey :execrows
+insert into idempotency_keys (key, created_at, expires_at, request_hash)
+    values ($1, now(), $2, $3)
+on conflict (key) do update
+    set created_at=now(),
+        expires_at=excluded.expires_at,
+        request_hash=excluded.request_hash,
+        job_id=null,
+        response_status=null,
+        response_content_type=null,
+        response_body=null
+    where idempotency_keys.expires_at <= now();
+
+-- name: QueryIdempotencyKey :one
+select * from idempotency_keys where key=$1;
+
+-- name: SetIdempotencyKeyJob :exec
+update idempotency_keys set job_id=$2 where key=$1;
+
+-- name: SetIdempotencyKeyResponse :exec
+update idempotency_keys
+    set response_status=$2, response_content_type=$3, response_body=$4
+where key=$1;
+
+-- name: DeleteIdempotencyKey :exec
+delete from idempotency_keys where key=$1;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+delete from idempotency_keys where expires_at <= now();
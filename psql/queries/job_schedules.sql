@@ -0,0 +1,22 @@
+-- name: QueryJobSchedules :many
+select * from job_schedules order by name;
+
+-- name: QueryJobScheduleEnabled :one
+select enabled from job_schedules where name=$1;
+
+-- name: SetJobScheduleEnabled :exec
+insert into job_schedules (name, enabled)
+values ($1, $2)
+on conflict (name) do update
+    set enabled=excluded.enabled,
+        updated_at=now();
+
+-- name: RecordJobScheduleRun :exec
+insert into job_schedules (name, last_job_id, last_run_at, last_outcome, last_error)
+values ($1, $2, now(), $3, $4)
+on conflict (name) do update
+    set last_job_id=excluded.last_job_id,
+        last_run_at=excluded.last_run_at,
+        last_outcome=excluded.last_outcome,
+        last_error=excluded.last_error,
+        updated_at=now();
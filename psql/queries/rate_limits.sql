@@ -0,0 +1,17 @@
+-- name: TakeRateLimitTokens :one
+-- refills the bucket for the time since it was last taken from and takes cost
+-- tokens, leaving at least reserve tokens behind; no row is returned when
+-- there are not enough tokens
+insert into rate_limits as limits (name, tokens, updated_at)
+values (sqlc.arg('name'), sqlc.arg('burst')::float8 - sqlc.arg('cost')::float8, now())
+on conflict (name) do update
+    set tokens = least(
+            sqlc.arg('burst')::float8,
+            limits.tokens + extract(epoch from now() - limits.updated_at)::float8 * sqlc.arg('rate')::float8
+        ) - sqlc.arg('cost')::float8,
+        updated_at = now()
+    where least(
+            sqlc.arg('burst')::float8,
+            limits.tokens + extract(epoch from now() - limits.updated_at)::float8 * sqlc.arg('rate')::float8
+        ) >= sqlc.arg('cost')::float8 + sqlc.arg('reserve')::float8
+returning tokens;
@@ -0,0 +1,2 @@
+-- name: QueryRiverLeader :one
+select * from river_leader where name = $1;
@@ -0,0 +1,44 @@
+-- name: QueryJobs :many
+select * from river_job
+where
+    (sqlc.narg('state')::river_job_state is null or state = sqlc.narg('state')::river_job_state)
+    and (sqlc.narg('queue')::text is null or queue = sqlc.narg('queue')::text)
+    and (sqlc.narg('kind')::text is null or kind = sqlc.narg('kind')::text)
+order by id desc
+limit sqlc.arg('limit') offset sqlc.arg('offset');
+
+-- name: CountJobs :one
+select count(*) from river_job
+where
+    (sqlc.narg('state')::river_job_state is null or state = sqlc.narg('state')::river_job_state)
+    and (sqlc.narg('queue')::text is null or queue = sqlc.narg('queue')::text)
+    and (sqlc.narg('kind')::text is null or kind = sqlc.narg('kind')::text);
+
+-- name: QueryJobByID :one
+select * from river_job where id=$1;
+
+-- name: QueryJobKinds :many
+select distinct kind from river_job order by kind;
+
+-- name: QueryJobQueueStats :many
+select queue, state, count(*) as total from river_job
+group by queue, state
+order by queue, state;
+
+-- name: RetryJob :one
+update river_job
+    set state='available',
+        scheduled_at=now(),
+        finalized_at=null,
+        max_attempts=greatest(max_attempts, attempt + 1)
+where id=$1 and state <> 'running'
+returning *;
+
+-- name: DeleteJob :execrows
+delete from river_job where id=$1 and state <> 'running';
+
+-- name: QueryOldestAvailableJobAges :many
+select queue, extract(epoch from now() - min(scheduled_at))::float8 as age_seconds
+from river_job
+where state = 'available' and scheduled_at <= now()
+group by queue;
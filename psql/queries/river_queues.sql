@@ -0,0 +1,12 @@
+-- name: QueryRiverQueues :many
+select * from river_queue order by name;
+
+-- name: PauseRiverQueue :exec
+insert into river_queue (name, paused_at, updated_at) values ($1, now(), now())
+on conflict (name) do update set paused_at=now(), updated_at=now();
+
+-- name: ResumeRiverQueue :exec
+update river_queue set paused_at=null, updated_at=now() where name=$1;
+
+-- name: QueryRiverQueuePaused :one
+select exists(select 1 from river_queue where name=$1 and paused_at is not null);
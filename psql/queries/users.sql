@@ -1,11 +1,14 @@
 -- name: QueryUserByID :one
-select * from users where id=$1;
+select * from users where id=$1 and deleted_at is null;
 
 -- name: QueryUserByEmail :one
-select * from users where email=$1;
+select * from users where email=$1 and deleted_at is null;
 
 -- name: QueryUsers :many
-select * from users;
+select * from users where deleted_at is null;
+
+-- name: QueryDeletedUsers :many
+select * from users where deleted_at is not null;
 
 -- name: InsertUser :one
 insert into
@@ -16,15 +19,59 @@ returning *;
 
 -- name: UpdateUser :one
 update users
-    set updated_at=$2, name=$3, email=$4, password=$5
-where id = $1
+    set updated_at=$2, name=$3, email=$4, version=version + 1
+where id = $1 and version = $5 and deleted_at is null
 returning *;
 
--- name: DeleteUser :exec
-delete from users where id=$1;
+-- name: SetUserAdmin :exec
+update users
+    set is_admin=$2, updated_at=$3, version=version + 1
+where id=$1 and deleted_at is null;
+
+-- name: DeleteUser :execrows
+update users
+    set deleted_at=$2, updated_at=$2, email_released=$3, version=version + 1
+where id=$1 and deleted_at is null;
+
+-- name: RestoreUser :execrows
+update users
+    set deleted_at=null, updated_at=$2, email_released=false, version=version + 1
+where id=$1 and deleted_at >= $3;
+
+-- name: PurgeDeletedUsers :execrows
+delete from users where deleted_at < $1;
 
 -- name: ChangeUserPassword :exec
-update users set updated_at=$2, password=$3 where id=$1;
+update users set updated_at=$2, password=$3, version=version + 1 where id=$1 and deleted_at is null;
 
 -- name: VerifyUserEmail :exec
-update users set updated_at=$2, email_verified_at=$3 where email=$1;
+update users set updated_at=$2, email_verified_at=$3, version=version + 1 where email=$1 and deleted_at is null;
+
+-- name: UpdateUserLocale :exec
+update users set updated_at=$2, locale=$3, version=version + 1 where id=$1 and deleted_at is null;
+
+-- name: SearchUsers :many
+-- the search vector is the expression of users_search_idx, which only
+-- serves the query as long as the two are the same
+select
+    sqlc.embed(users),
+    greatest(
+        ts_rank(
+            setweight(to_tsvector('simple', name), 'A') ||
+            setweight(to_tsvector('simple', replace(replace(email, '@', ' '), '.', ' ')), 'B'),
+            websearch_to_tsquery('simple', sqlc.arg(query)::text)
+        ),
+        word_similarity(sqlc.arg(query)::text, name),
+        word_similarity(sqlc.arg(query)::text, email)
+    )::real as rank
+from users
+where deleted_at is null and (
+    (
+        setweight(to_tsvector('simple', name), 'A') ||
+        setweight(to_tsvector('simple', replace(replace(email, '@', ' '), '.', ' ')), 'B')
+    ) @@ websearch_to_tsquery('simple', sqlc.arg(query)::text)
+    or sqlc.arg(query)::text <% name
+    or sqlc.arg(query)::text <% email
+)
+order by rank desc, created_at desc, id
+limit sqlc.arg(max_results)::int;
@@ -0,0 +1,101 @@
+package psql
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mbvlabs/grafto/psql/database"
+)
+
+type readYourWritesKey struct{}
+
+/*
+WithReadYourWrites returns a context that pins reads to the primary once a
+write has been made with it, so a request that changes a row and reads it back
+does not get a stale row from a lagging replica. Without it, reads that can go
+to the replica always do.
+*/
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readYourWritesKey{}, &atomic.Bool{})
+}
+
+func markWritten(ctx context.Context) {
+	if written, ok := ctx.Value(readYourWritesKey{}).(*atomic.Bool); ok {
+		written.Store(true)
+	}
+}
+
+// HasWritten reports whether a write has been made with ctx since
+// WithReadYourWrites.
+func HasWritten(ctx context.Context) bool {
+	written, ok := ctx.Value(readYourWritesKey{}).(*atomic.Bool)
+	return ok && written.Load()
+}
+
+// writeTracker marks the context as written for every statement sent to the
+// primary that is not a select.
+type writeTracker struct {
+	database.DBTX
+}
+
+func (w writeTracker) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	trackWrite(ctx, sql)
+	return w.DBTX.Exec(ctx, sql, args...)
+}
+
+func (w writeTracker) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	trackWrite(ctx, sql)
+	return w.DBTX.Query(ctx, sql, args...)
+}
+
+func (w writeTracker) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	trackWrite(ctx, sql)
+	return w.DBTX.QueryRow(ctx, sql, args...)
+}
+
+func trackWrite(ctx context.Context, sql string) {
+	if isWrite(sql) {
+		markWritten(ctx)
+	}
+}
+
+// isWrite reports whether sql is anything but a select, skipping the name
+// comment sqlc starts its queries with.
+func isWrite(sql string) bool {
+	sql = strings.TrimSpace(sql)
+	if strings.HasPrefix(sql, sqlcNamePrefix) {
+		_, sql, _ = strings.Cut(sql, "\n")
+	}
+
+	keyword, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	return !strings.EqualFold(strings.TrimSpace(keyword), "select")
+}
+
+// reader returns the queries for a read the replica can serve, see readerDB.
+func (p Postgres) reader(ctx context.Context) *database.Queries {
+	if !p.readsFromReplica(ctx) {
+		return p.Queries
+	}
+
+	return database.New(p.replica)
+}
+
+// readerDB returns the connection for a read the replica can serve: the
+// replica, unless there is none, p is part of a transaction, or ctx has written.
+func (p Postgres) readerDB(ctx context.Context) database.DBTX {
+	switch {
+	case p.readsFromReplica(ctx):
+		return p.replica
+	case p.tx != nil:
+		return p.tx
+	default:
+		return writeTracker{p.pool}
+	}
+}
+
+func (p Postgres) readsFromReplica(ctx context.Context) bool {
+	return p.replica != nil && p.tx == nil && !HasWritten(ctx)
+}
@@ -0,0 +1,166 @@
+package psql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/stretchr/testify/assert"
+)
+
+type noRow struct{}
+
+func (noRow) Scan(dest ...any) error {
+	return pgx.ErrNoRows
+}
+
+// recordingDB records the queries sent to it, every row it returns is missing.
+type recordingDB struct {
+	psql.Pool
+	queries []string
+}
+
+func (db *recordingDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	db.queries = append(db.queries, psql.QueryName(sql))
+	return pgconn.CommandTag{}, nil
+}
+
+func (db *recordingDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	db.queries = append(db.queries, psql.QueryName(sql))
+	return noRow{}
+}
+
+func (db *recordingDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &recordingTx{&fakeTx{}, db}, nil
+}
+
+type recordingTx struct {
+	*fakeTx
+	db *recordingDB
+}
+
+func (tx *recordingTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return tx.db.Exec(ctx, sql, args...)
+}
+
+func (tx *recordingTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return tx.db.QueryRow(ctx, sql, args...)
+}
+
+func TestIsWrite(t *testing.T) {
+	tests := map[string]struct {
+		sql      string
+		expected bool
+	}{
+		"should treat a sqlc select as a read": {
+			sql:      "-- name: QueryUserByID :one\nselect * from users where id=$1",
+			expected: false,
+		},
+		"should treat a select in upper case as a read": {
+			sql:      "  SELECT 1",
+			expected: false,
+		},
+		"should treat a sqlc update as a write": {
+			sql:      "-- name: UpdateUserLocale :exec\nupdate users set locale=$2 where id=$1",
+			expected: true,
+		},
+		"should treat an insert as a write": {
+			sql:      "insert into users (id) values ($1)",
+			expected: true,
+		},
+		"should treat a cte as a write since it can modify rows": {
+			sql:      "with deleted as (delete from tokens returning id) select count(*) from deleted",
+			expected: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, psql.IsWrite(test.sql))
+		})
+	}
+}
+
+func TestReplicaRouting(t *testing.T) {
+	tests := map[string]struct {
+		readYourWrites   bool
+		write            bool
+		inTx             bool
+		expectedPrimary  []string
+		expectedReplicas []string
+	}{
+		"should read from the replica": {
+			expectedReplicas: []string{"QueryUserByID"},
+		},
+		"should read from the replica after a write without read your writes": {
+			write:            true,
+			expectedPrimary:  []string{"DeleteUser"},
+			expectedReplicas: []string{"QueryUserByID"},
+		},
+		"should read from the replica before a write with read your writes": {
+			readYourWrites:   true,
+			expectedReplicas: []string{"QueryUserByID"},
+		},
+		"should read from the primary after a write with read your writes": {
+			readYourWrites:  true,
+			write:           true,
+			expectedPrimary: []string{"DeleteUser", "QueryUserByID"},
+		},
+		"should read from the primary inside a transaction": {
+			inTx:            true,
+			expectedPrimary: []string{"QueryUserByID"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			primary, replica := &recordingDB{}, &recordingDB{}
+			db := psql.NewPostgres(primary, psql.WithReplica(replica))
+
+			ctx := context.Background()
+			if test.readYourWrites {
+				ctx = psql.WithReadYourWrites(ctx)
+			}
+
+			if test.write {
+				// the fake affects no rows, but the write is what counts
+				err := db.DeleteUser(ctx, uuid.New(), time.Now(), false)
+				assert.ErrorIs(t, err, psql.ErrNoRowWithIdentifier)
+			}
+
+			read := func(db psql.Postgres) error {
+				_, err := db.QueryUserByID(ctx, uuid.New())
+				assert.ErrorIs(t, err, pgx.ErrNoRows)
+				return nil
+			}
+			if test.inTx {
+				assert.NoError(t, db.WithTx(ctx, read))
+			} else {
+				assert.NoError(t, read(db))
+			}
+
+			assert.Equal(t, test.expectedPrimary, primary.queries)
+			assert.Equal(t, test.expectedReplicas, replica.queries)
+		})
+	}
+}
+
+func TestTransactionPinsReadsToPrimary(t *testing.T) {
+	primary, replica := &recordingDB{}, &recordingDB{}
+	db := psql.NewPostgres(primary, psql.WithReplica(replica))
+
+	ctx := psql.WithReadYourWrites(context.Background())
+	assert.False(t, psql.HasWritten(ctx))
+
+	assert.NoError(t, db.WithTx(ctx, func(tx psql.Postgres) error {
+		return nil
+	}))
+	assert.True(t, psql.HasWritten(ctx))
+
+	_, _ = db.QueryUserByID(ctx, uuid.New())
+	assert.Empty(t, replica.queries)
+}
@@ -0,0 +1,170 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"sort"
+
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/psql/database"
+)
+
+func newJob(ctx context.Context, job database.RiverJob) models.Job {
+	errs := make([]models.JobError, 0, len(job.Errors))
+	for _, raw := range job.Errors {
+		var attemptErr models.JobError
+		if err := json.Unmarshal(raw, &attemptErr); err != nil {
+			slog.ErrorContext(ctx, "could not parse job error", "error", err, "job_id", job.ID)
+			continue
+		}
+		errs = append(errs, attemptErr)
+	}
+
+	return models.Job{
+		ID:          job.ID,
+		State:       string(job.State),
+		Queue:       job.Queue,
+		Kind:        job.Kind,
+		Priority:    int(job.Priority),
+		Attempt:     int(job.Attempt),
+		MaxAttempts: int(job.MaxAttempts),
+		Args:        job.Args,
+		Metadata:    job.Metadata,
+		Errors:      errs,
+		AttemptedBy: job.AttemptedBy,
+		Tags:        job.Tags,
+		CreatedAt:   job.CreatedAt.Time,
+		ScheduledAt: job.ScheduledAt.Time,
+		AttemptedAt: job.AttemptedAt.Time,
+		FinalizedAt: job.FinalizedAt.Time,
+	}
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+func nullJobState(state string) database.NullRiverJobState {
+	return database.NullRiverJobState{
+		RiverJobState: database.RiverJobState(state),
+		Valid:         state != "",
+	}
+}
+
+func (p Postgres) QueryJobs(
+	ctx context.Context,
+	filters models.JobFilters,
+	limit int,
+	offset int,
+) ([]models.Job, error) {
+	rows, err := p.Queries.QueryJobs(ctx, database.QueryJobsParams{
+		State:  nullJobState(filters.State),
+		Queue:  nullString(filters.Queue),
+		Kind:   nullString(filters.Kind),
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]models.Job, len(rows))
+	for i, row := range rows {
+		jobs[i] = newJob(ctx, row)
+	}
+
+	return jobs, nil
+}
+
+func (p Postgres) CountJobs(
+	ctx context.Context,
+	filters models.JobFilters,
+) (int64, error) {
+	return p.Queries.CountJobs(ctx, database.CountJobsParams{
+		State: nullJobState(filters.State),
+		Queue: nullString(filters.Queue),
+		Kind:  nullString(filters.Kind),
+	})
+}
+
+func (p Postgres) QueryJobByID(ctx context.Context, id int64) (models.Job, error) {
+	job, err := p.Queries.QueryJobByID(ctx, id)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	return newJob(ctx, job), nil
+}
+
+// RetryJob makes a job that is not running available right away, granting it
+// an extra attempt if it has used them all.
+func (p Postgres) RetryJob(ctx context.Context, id int64) (models.Job, error) {
+	job, err := p.Queries.RetryJob(ctx, id)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	return newJob(ctx, job), nil
+}
+
+// DeleteJob deletes a job that is not running.
+func (p Postgres) DeleteJob(ctx context.Context, id int64) error {
+	deleted, err := p.Queries.DeleteJob(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return ErrNoRowWithIdentifier
+	}
+
+	return nil
+}
+
+/*
+QueryQueueStats counts the jobs per state for every queue that has jobs or
+has been paused.
+*/
+func (p Postgres) QueryQueueStats(ctx context.Context) ([]models.QueueStats, error) {
+	counts, err := p.Queries.QueryJobQueueStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	queues, err := p.Queries.QueryRiverQueues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]*models.QueueStats)
+	statsFor := func(name string) *models.QueueStats {
+		if _, ok := stats[name]; !ok {
+			stats[name] = &models.QueueStats{
+				Name:   name,
+				Counts: make(map[string]int64),
+			}
+		}
+
+		return stats[name]
+	}
+
+	for _, count := range counts {
+		statsFor(count.Queue).Counts[string(count.State)] = count.Total
+	}
+
+	for _, queue := range queues {
+		statsFor(queue.Name).PausedAt = queue.PausedAt.Time
+	}
+
+	result := make([]models.QueueStats, 0, len(stats))
+	for _, queueStats := range stats {
+		result = append(result, *queueStats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
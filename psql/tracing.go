@@ -0,0 +1,140 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sqlcNamePrefix starts every query generated by sqlc, followed by the name
+// of the query
+const sqlcNamePrefix = "-- name: "
+
+type queryTraceKey struct{}
+
+type queryTrace struct {
+	span  trace.Span
+	name  string
+	sql   string
+	args  []any
+	start time.Time
+}
+
+/*
+QueryTracer is a pgx.QueryTracer that works every query inside a span named
+after the sqlc query, so database calls show up in the trace of the request or
+job that made them. It counts queries and their duration per query, and logs
+the ones slower than the threshold with the types of their args instead of
+the values, which can hold passwords, tokens and personal data.
+*/
+type QueryTracer struct {
+	tracer             telemetry.Tracer
+	slowQueryThreshold time.Duration
+	logger             *slog.Logger
+	now                func() time.Time
+}
+
+// NewQueryTracer traces queries with tracer, a slowQueryThreshold of zero
+// turns the slow-query log off.
+func NewQueryTracer(tracer telemetry.Tracer, slowQueryThreshold time.Duration) *QueryTracer {
+	return &QueryTracer{tracer, slowQueryThreshold, slog.Default(), time.Now}
+}
+
+func (q *QueryTracer) TraceQueryStart(
+	ctx context.Context,
+	_ *pgx.Conn,
+	data pgx.TraceQueryStartData,
+) context.Context {
+	name := queryName(data.SQL)
+
+	ctx, span := q.tracer.CreateSpan(
+		ctx,
+		name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", name),
+			attribute.String("db.query.text", data.SQL),
+		),
+	)
+
+	return context.WithValue(ctx, queryTraceKey{}, &queryTrace{
+		span:  span,
+		name:  name,
+		sql:   data.SQL,
+		args:  data.Args,
+		start: q.now(),
+	})
+}
+
+func (q *QueryTracer) TraceQueryEnd(
+	ctx context.Context,
+	_ *pgx.Conn,
+	data pgx.TraceQueryEndData,
+) {
+	traced, ok := ctx.Value(queryTraceKey{}).(*queryTrace)
+	if !ok {
+		return
+	}
+	defer traced.span.End()
+
+	duration := q.now().Sub(traced.start)
+	rows := data.CommandTag.RowsAffected()
+
+	traced.span.SetAttributes(attribute.Int64("db.response.returned_rows", rows))
+	queryDuration.WithLabelValues(traced.name).Observe(duration.Seconds())
+
+	// no rows is how sqlc reports a missing row, not a failed query
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		traced.span.RecordError(data.Err)
+		traced.span.SetStatus(codes.Error, data.Err.Error())
+		queryErrors.WithLabelValues(traced.name).Inc()
+	}
+
+	if q.slowQueryThreshold > 0 && duration >= q.slowQueryThreshold {
+		q.logger.WarnContext(
+			ctx,
+			"slow query",
+			"query", traced.name,
+			"duration", duration,
+			"rows", rows,
+			"sql", traced.sql,
+			"args", redactArgs(traced.args),
+		)
+	}
+}
+
+/*
+queryName is the name sqlc gives the query, or the first keyword of queries
+that do not come from sqlc, such as the ones river and the migration runner
+make.
+*/
+func queryName(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if name, ok := strings.CutPrefix(sql, sqlcNamePrefix); ok {
+		name, _, _ = strings.Cut(name, " ")
+		return name
+	}
+
+	keyword, _, _ := strings.Cut(sql, " ")
+	return strings.ToLower(keyword)
+}
+
+// redactArgs describes the args by their type only.
+func redactArgs(args []any) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = fmt.Sprintf("$%d %T", i+1, arg)
+	}
+
+	return redacted
+}
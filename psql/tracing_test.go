@@ -0,0 +1,135 @@
+package psql_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryName(t *testing.T) {
+	tests := map[string]struct {
+		sql      string
+		expected string
+	}{
+		"should use the name sqlc gives the query": {
+			sql:      "-- name: QueryUserByEmail :one\nselect * from users where email=$1",
+			expected: "QueryUserByEmail",
+		},
+		"should use the first keyword of other queries": {
+			sql:      "\n\tSELECT pg_advisory_lock($1)",
+			expected: "select",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, psql.QueryName(test.sql))
+		})
+	}
+}
+
+// steppedClock moves forward by step every time it is read.
+func steppedClock(step time.Duration) func() time.Time {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+func TestQueryTracer(t *testing.T) {
+	tracer := telemetry.NewOtel(config.Config{}).NewTracer("test")
+
+	tests := map[string]struct {
+		sql               string
+		duration          time.Duration
+		err               error
+		expectedSlowLog   bool
+		expectedErrorsInc float64
+	}{
+		"should not log fast queries": {
+			sql:      "-- name: QueryUserByID :one\nselect * from users where id=$1",
+			duration: 10 * time.Millisecond,
+		},
+		"should log slow queries": {
+			sql:             "-- name: QueryUserByEmail :one\nselect * from users where email=$1",
+			duration:        time.Second,
+			expectedSlowLog: true,
+		},
+		"should count failed queries": {
+			sql:               "-- name: InsertUser :one\ninsert into users (email) values ($1)",
+			duration:          10 * time.Millisecond,
+			err:               errors.New("duplicate key value"),
+			expectedErrorsInc: 1,
+		},
+		"should not count missing rows as failed": {
+			sql:      "-- name: QueryUserByToken :one\nselect * from users where token=$1",
+			duration: 10 * time.Millisecond,
+			err:      pgx.ErrNoRows,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var logs bytes.Buffer
+			queryTracer := psql.NewQueryTracerWithClock(
+				tracer,
+				200*time.Millisecond,
+				slog.New(slog.NewTextHandler(&logs, nil)),
+				steppedClock(test.duration),
+			)
+			query := psql.QueryName(test.sql)
+			errorsBefore := testutil.ToFloat64(psql.QueryErrors.WithLabelValues(query))
+
+			ctx := queryTracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+				SQL:  test.sql,
+				Args: []any{"jon@stark.com"},
+			})
+			queryTracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{
+				CommandTag: pgconn.NewCommandTag("SELECT 1"),
+				Err:        test.err,
+			})
+
+			assert.Equal(t, test.expectedSlowLog, strings.Contains(logs.String(), "slow query"))
+			assert.NotContains(t, logs.String(), "jon@stark.com")
+			if test.expectedSlowLog {
+				assert.Contains(t, logs.String(), "query="+query)
+				assert.Contains(t, logs.String(), "$1 string")
+			}
+			assert.Equal(
+				t,
+				errorsBefore+test.expectedErrorsInc,
+				testutil.ToFloat64(psql.QueryErrors.WithLabelValues(query)),
+			)
+		})
+	}
+}
+
+func TestPoolCollector(t *testing.T) {
+	// the pool connects lazily, so no database is needed to read its stats
+	pool, err := pgxpool.New(context.Background(), "postgres://grafto@127.0.0.1:1/grafto?pool_max_conns=7")
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	collector := psql.NewPoolCollector(pool, "primary")
+
+	assert.Equal(t, 8, testutil.CollectAndCount(collector))
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP db_pool_max_connections Connections the pool opens at most.
+# TYPE db_pool_max_connections gauge
+db_pool_max_connections{pool="primary"} 7
+`), "db_pool_max_connections"))
+}
@@ -2,11 +2,14 @@ package psql
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/pkg/pagination"
 	"github.com/mbvlabs/grafto/psql/database"
 )
 
@@ -14,7 +17,7 @@ func (p Postgres) QueryUserByID(
 	ctx context.Context,
 	id uuid.UUID,
 ) (models.User, error) {
-	user, err := p.Queries.QueryUserByID(ctx, id)
+	user, err := p.reader(ctx).QueryUserByID(ctx, id)
 	if err != nil {
 		return models.User{}, err
 	}
@@ -26,6 +29,10 @@ func (p Postgres) QueryUserByID(
 		Name:            user.Name,
 		Email:           user.Email,
 		EmailVerifiedAt: user.EmailVerifiedAt.Time,
+		Locale:          user.Locale,
+		IsAdmin:         user.IsAdmin,
+		Version:         user.Version,
+		DeletedAt:       user.DeletedAt.Time,
 	}, nil
 }
 
@@ -33,7 +40,7 @@ func (p Postgres) QueryUserByEmail(
 	ctx context.Context,
 	email string,
 ) (models.User, error) {
-	user, err := p.Queries.QueryUserByEmail(ctx, email)
+	user, err := p.reader(ctx).QueryUserByEmail(ctx, email)
 	if err != nil {
 		return models.User{}, err
 	}
@@ -45,9 +52,96 @@ func (p Postgres) QueryUserByEmail(
 		Name:            user.Name,
 		Email:           user.Email,
 		EmailVerifiedAt: user.EmailVerifiedAt.Time,
+		Locale:          user.Locale,
+		IsAdmin:         user.IsAdmin,
+		Version:         user.Version,
+		DeletedAt:       user.DeletedAt.Time,
 	}, nil
 }
 
+// UserListSpec is what the user list can be sorted and filtered by.
+var UserListSpec = pagination.Spec{
+	Sorts: map[string]pagination.Field{
+		"created_at": {Column: "created_at", Type: pagination.Time},
+		"name":       {Column: "name", Type: pagination.String},
+		"email":      {Column: "email", Type: pagination.String},
+	},
+	DefaultSort: pagination.Sort{Name: "created_at", Desc: true},
+	Key:         pagination.Field{Column: "id", Type: pagination.UUID},
+	Filters: map[string]pagination.FilterField{
+		"name":           {Field: pagination.Field{Column: "name", Type: pagination.String}, Op: pagination.Contains},
+		"email":          {Field: pagination.Field{Column: "email", Type: pagination.String}, Op: pagination.Contains},
+		"is_admin":       {Field: pagination.Field{Column: "is_admin", Type: pagination.Bool}, Op: pagination.Equals},
+		"created_after":  {Field: pagination.Field{Column: "created_at", Type: pagination.Time}, Op: pagination.AtLeast},
+		"created_before": {Field: pagination.Field{Column: "created_at", Type: pagination.Time}, Op: pagination.Before},
+	},
+}
+
+// DeletedUserListSpec is what the list of deleted users can be sorted and
+// filtered by.
+var DeletedUserListSpec = pagination.Spec{
+	Mode: pagination.Offset,
+	Sorts: map[string]pagination.Field{
+		"deleted_at": {Column: "deleted_at", Type: pagination.Time},
+		"name":       {Column: "name", Type: pagination.String},
+		"email":      {Column: "email", Type: pagination.String},
+	},
+	DefaultSort: pagination.Sort{Name: "deleted_at", Desc: true},
+	Key:         pagination.Field{Column: "id", Type: pagination.UUID},
+	Filters: map[string]pagination.FilterField{
+		"name":  {Field: pagination.Field{Column: "name", Type: pagination.String}, Op: pagination.Contains},
+		"email": {Field: pagination.Field{Column: "email", Type: pagination.String}, Op: pagination.Contains},
+	},
+}
+
+// ListUsers returns a page of users, params should be parsed with
+// UserListSpec.
+func (p Postgres) ListUsers(
+	ctx context.Context,
+	params pagination.Params,
+) (pagination.Page[models.User], error) {
+	return p.listUsers(ctx, params, func(q *database.Queries) ([]database.User, error) {
+		return q.QueryUsers(ctx)
+	})
+}
+
+// ListDeletedUsers returns a page of the users that are deleted but not purged
+// yet, params should be parsed with DeletedUserListSpec.
+func (p Postgres) ListDeletedUsers(
+	ctx context.Context,
+	params pagination.Params,
+) (pagination.Page[models.User], error) {
+	return p.listUsers(ctx, params, func(q *database.Queries) ([]database.User, error) {
+		return q.QueryDeletedUsers(ctx)
+	})
+}
+
+func (p Postgres) listUsers(
+	ctx context.Context,
+	params pagination.Params,
+	query func(q *database.Queries) ([]database.User, error),
+) (pagination.Page[models.User], error) {
+	page, err := List(ctx, p.readerDB(ctx), params, query)
+	if err != nil {
+		return pagination.Page[models.User]{}, err
+	}
+
+	return pagination.MapItems(page, func(user database.User) models.User {
+		return models.User{
+			ID:              user.ID,
+			CreatedAt:       user.CreatedAt.Time,
+			UpdatedAt:       user.UpdatedAt.Time,
+			Name:            user.Name,
+			Email:           user.Email,
+			EmailVerifiedAt: user.EmailVerifiedAt.Time,
+			Locale:          user.Locale,
+			IsAdmin:         user.IsAdmin,
+			Version:         user.Version,
+			DeletedAt:       user.DeletedAt.Time,
+		}
+	}), nil
+}
+
 func (p Postgres) InsertUser(
 	ctx context.Context,
 	data models.User,
@@ -62,7 +156,7 @@ func (p Postgres) InsertUser(
 		Valid: true,
 	}
 
-	_, err := p.Queries.InsertUser(ctx, database.InsertUserParams{
+	user, err := p.Queries.InsertUser(ctx, database.InsertUserParams{
 		ID:        data.ID,
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
@@ -70,13 +164,23 @@ func (p Postgres) InsertUser(
 		Email:     data.Email,
 		Password:  hashedPassword,
 	})
+	if isUniqueViolation(err) {
+		// the email is taken by a user that was deleted while keeping it
+		return models.User{}, models.ErrUserAlreadyExists
+	}
 	if err != nil {
 		return models.User{}, err
 	}
 
+	data.Version = user.Version
 	return data, nil
 }
 
+/*
+UpdateUser saves the name and email of data if data.Version is the current
+version of the user, and bumps the version. When it is not, the user is
+returned in a models.UserConflictError.
+*/
 func (p Postgres) UpdateUser(
 	ctx context.Context,
 	data models.User,
@@ -86,17 +190,41 @@ func (p Postgres) UpdateUser(
 		Valid: true,
 	}
 
-	_, err := p.Queries.UpdateUser(ctx, database.UpdateUserParams{
+	user, err := p.Queries.UpdateUser(ctx, database.UpdateUserParams{
 		ID:        data.ID,
 		UpdatedAt: updatedAt,
 		Name:      data.Name,
 		Email:     data.Email,
+		Version:   data.Version,
 	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		// the user is either gone or at another version
+		current, err := p.QueryUserByID(ctx, data.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, ErrNoRowWithIdentifier
+		}
+		if err != nil {
+			return models.User{}, err
+		}
+
+		return models.User{}, models.UserConflictError{Current: current}
+	}
 	if err != nil {
 		return models.User{}, err
 	}
 
-	return data, nil
+	return models.User{
+		ID:              user.ID,
+		CreatedAt:       user.CreatedAt.Time,
+		UpdatedAt:       user.UpdatedAt.Time,
+		Name:            user.Name,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt.Time,
+		Locale:          user.Locale,
+		IsAdmin:         user.IsAdmin,
+		Version:         user.Version,
+		DeletedAt:       user.DeletedAt.Time,
+	}, nil
 }
 
 func (p Postgres) UpdateUserPassword(
@@ -137,3 +265,162 @@ func (p Postgres) VerifyUserEmail(
 		EmailVerifiedAt: parsedUpdatedAt,
 	})
 }
+
+func (p Postgres) UpdateUserLocale(
+	ctx context.Context,
+	userID uuid.UUID,
+	locale string,
+	updatedAt time.Time,
+) error {
+	return p.Queries.UpdateUserLocale(ctx, database.UpdateUserLocaleParams{
+		ID: userID,
+		UpdatedAt: pgtype.Timestamptz{
+			Time:  updatedAt,
+			Valid: true,
+		},
+		Locale: locale,
+	})
+}
+
+func (p Postgres) SetUserAdmin(
+	ctx context.Context,
+	userID uuid.UUID,
+	isAdmin bool,
+	updatedAt time.Time,
+) error {
+	return p.Queries.SetUserAdmin(ctx, database.SetUserAdminParams{
+		ID:      userID,
+		IsAdmin: isAdmin,
+		UpdatedAt: pgtype.Timestamptz{
+			Time:  updatedAt,
+			Valid: true,
+		},
+	})
+}
+
+/*
+DeleteUser soft deletes the user, which hides it from every query but the ones
+for deleted users until PurgeDeletedUsers removes it. With releaseEmail the
+email can be registered again right away, otherwise it stays taken until then.
+*/
+func (p Postgres) DeleteUser(
+	ctx context.Context,
+	id uuid.UUID,
+	deletedAt time.Time,
+	releaseEmail bool,
+) error {
+	deleted, err := p.Queries.DeleteUser(ctx, database.DeleteUserParams{
+		ID: id,
+		DeletedAt: pgtype.Timestamptz{
+			Time:  deletedAt,
+			Valid: true,
+		},
+		EmailReleased: releaseEmail,
+	})
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return ErrNoRowWithIdentifier
+	}
+
+	return nil
+}
+
+/*
+RestoreUser undoes DeleteUser for a user deleted at or after deletedAfter. It
+fails with models.ErrUserNotRestorable when there is no such user, and with
+models.ErrUserAlreadyExists when its email was registered again in between.
+*/
+func (p Postgres) RestoreUser(
+	ctx context.Context,
+	id uuid.UUID,
+	restoredAt time.Time,
+	deletedAfter time.Time,
+) error {
+	restored, err := p.Queries.RestoreUser(ctx, database.RestoreUserParams{
+		ID: id,
+		UpdatedAt: pgtype.Timestamptz{
+			Time:  restoredAt,
+			Valid: true,
+		},
+		DeletedAt: pgtype.Timestamptz{
+			Time:  deletedAfter,
+			Valid: true,
+		},
+	})
+	if isUniqueViolation(err) {
+		return models.ErrUserAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	if restored == 0 {
+		return models.ErrUserNotRestorable
+	}
+
+	return nil
+}
+
+// PurgeDeletedUsers removes the users deleted before deletedBefore for good and
+// returns how many there were.
+func (p Postgres) PurgeDeletedUsers(
+	ctx context.Context,
+	deletedBefore time.Time,
+) (int64, error) {
+	return p.Queries.PurgeDeletedUsers(ctx, pgtype.Timestamptz{
+		Time:  deletedBefore,
+		Valid: true,
+	})
+}
+
+// MaxUserSearchResults is the most users SearchUsers returns.
+const MaxUserSearchResults = 50
+
+/*
+SearchUsers finds the users whose name or email match query, best match first.
+Words of query match in full text, see websearch_to_tsquery, and names and
+emails that are merely similar to it match as well, which finds them despite
+typos. At most limit users are returned, or MaxUserSearchResults.
+*/
+func (p Postgres) SearchUsers(
+	ctx context.Context,
+	query string,
+	limit int,
+) ([]models.UserSearchResult, error) {
+	if limit < 1 || limit > MaxUserSearchResults {
+		limit = MaxUserSearchResults
+	}
+
+	rows, err := p.reader(ctx).SearchUsers(ctx, database.SearchUsersParams{
+		Query:      query,
+		MaxResults: int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.UserSearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = models.UserSearchResult{
+			User: models.User{
+				ID:              row.User.ID,
+				CreatedAt:       row.User.CreatedAt.Time,
+				UpdatedAt:       row.User.UpdatedAt.Time,
+				Name:            row.User.Name,
+				Email:           row.User.Email,
+				EmailVerifiedAt: row.User.EmailVerifiedAt.Time,
+				Locale:          row.User.Locale,
+				IsAdmin:         row.User.IsAdmin,
+				Version:         row.User.Version,
+			},
+			Rank:           row.Rank,
+			NameHighlight:  models.HighlightMatches(row.User.Name, query),
+			EmailHighlight: models.HighlightMatches(row.User.Email, query),
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,268 @@
+package psql_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mbvlabs/grafto/factory"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/pkg/pagination"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/mbvlabs/grafto/psql/psqltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func insertUsers(t *testing.T, db psql.Postgres, users ...models.User) {
+	t.Helper()
+
+	for _, user := range users {
+		if _, err := db.InsertUser(context.Background(), user, "hashed"); err != nil {
+			t.Fatalf("could not insert %s: %v", user.Email, err)
+		}
+	}
+}
+
+func TestInsertAndQueryUser(t *testing.T) {
+	db := psqltest.DB(t)
+	ctx := context.Background()
+	user := factory.New(1).User()
+	insertUsers(t, db, user)
+
+	byEmail, err := db.QueryUserByEmail(ctx, user.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, byEmail.ID)
+	assert.Equal(t, user.Name, byEmail.Name)
+	assert.Equal(t, int64(1), byEmail.Version)
+	assert.True(t, user.CreatedAt.Equal(byEmail.CreatedAt))
+	assert.False(t, byEmail.IsVerified())
+
+	assert.NoError(t, db.VerifyUserEmail(ctx, time.Now(), user.Email))
+
+	byID, err := db.QueryUserByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.True(t, byID.IsVerified())
+	assert.Equal(t, int64(2), byID.Version)
+}
+
+func TestUpdateUserConflict(t *testing.T) {
+	db := psqltest.DB(t)
+	ctx := context.Background()
+	f := factory.New(1)
+	user := f.User()
+	insertUsers(t, db, user)
+
+	first := f.UpdateUserData(user)
+	updated, err := db.UpdateUser(ctx, models.User{
+		ID:        first.ID,
+		UpdatedAt: first.UpdatedAt,
+		Name:      first.Name,
+		Email:     first.Email,
+		Version:   first.Version,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+
+	// a second change made to the version the first one replaced
+	stale := f.UpdateUserData(user)
+	_, err = db.UpdateUser(ctx, models.User{
+		ID:        stale.ID,
+		UpdatedAt: stale.UpdatedAt,
+		Name:      stale.Name,
+		Email:     stale.Email,
+		Version:   stale.Version,
+	})
+	assert.ErrorIs(t, err, models.ErrEditConflict)
+
+	var conflict models.UserConflictError
+	assert.ErrorAs(t, err, &conflict)
+	assert.Equal(t, first.Name, conflict.Current.Name)
+	assert.Equal(t, int64(2), conflict.Current.Version)
+}
+
+func TestSoftDeleteUser(t *testing.T) {
+	tests := map[string]struct {
+		releaseEmail           bool
+		expectedReregisterErr  error
+		expectedRestoreErr     error
+		expectedUsersWithEmail int
+	}{
+		"should keep the email taken until the user is purged": {
+			releaseEmail:           false,
+			expectedReregisterErr:  models.ErrUserAlreadyExists,
+			expectedRestoreErr:     nil,
+			expectedUsersWithEmail: 1,
+		},
+		"should fail to restore a user whose released email was taken": {
+			releaseEmail:           true,
+			expectedReregisterErr:  nil,
+			expectedRestoreErr:     models.ErrUserAlreadyExists,
+			expectedUsersWithEmail: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := psqltest.DB(t)
+			ctx := context.Background()
+			f := factory.New(1)
+			user := f.User()
+			insertUsers(t, db, user)
+
+			deletedAt := time.Now()
+			assert.NoError(t, db.DeleteUser(ctx, user.ID, deletedAt, test.releaseEmail))
+			assert.ErrorIs(t, db.DeleteUser(ctx, user.ID, deletedAt, test.releaseEmail), psql.ErrNoRowWithIdentifier)
+
+			_, err := db.QueryUserByEmail(ctx, user.Email)
+			assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+			// statements that fail run in a savepoint, so the test can go on
+			err = db.WithTx(ctx, func(tx psql.Postgres) error {
+				_, err := tx.InsertUser(ctx, f.User(factory.WithEmail(user.Email)), "hashed")
+				return err
+			})
+			assert.ErrorIs(t, err, test.expectedReregisterErr)
+
+			assert.ErrorIs(
+				t,
+				db.RestoreUser(ctx, user.ID, time.Now(), deletedAt.Add(time.Minute)),
+				models.ErrUserNotRestorable,
+				"a user deleted before the retention cannot be restored",
+			)
+
+			err = db.WithTx(ctx, func(tx psql.Postgres) error {
+				return tx.RestoreUser(ctx, user.ID, time.Now(), deletedAt.Add(-time.Minute))
+			})
+			assert.ErrorIs(t, err, test.expectedRestoreErr)
+
+			page, err := db.ListUsers(ctx, parseList(t, "email="+url.QueryEscape(user.Email)))
+			assert.NoError(t, err)
+			assert.Len(t, page.Items, test.expectedUsersWithEmail)
+		})
+	}
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	db := psqltest.DB(t)
+	ctx := context.Background()
+	f := factory.New(1)
+	old, recent, kept := f.User(), f.User(), f.User()
+	insertUsers(t, db, old, recent, kept)
+
+	now := time.Now()
+	assert.NoError(t, db.DeleteUser(ctx, old.ID, now.Add(-48*time.Hour), false))
+	assert.NoError(t, db.DeleteUser(ctx, recent.ID, now.Add(-time.Hour), false))
+
+	purged, err := db.PurgeDeletedUsers(ctx, now.Add(-24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	deleted, err := db.ListDeletedUsers(ctx, parseSpec(t, "", psql.DeletedUserListSpec))
+	assert.NoError(t, err)
+	assert.Len(t, deleted.Items, 1)
+	assert.Equal(t, recent.ID, deleted.Items[0].ID)
+	assert.True(t, deleted.Items[0].IsDeleted())
+}
+
+func TestSearchUsers(t *testing.T) {
+	db := psqltest.DB(t)
+	ctx := context.Background()
+	f := factory.New(1)
+	jon := f.User(factory.WithName("Jon Snow"), factory.WithEmail("jon@winterfell.com"))
+	arya := f.User(factory.WithName("Arya Stark"), factory.WithEmail("arya@winterfell.com"))
+	dany := f.User(factory.WithName("Daenerys Targaryen"), factory.WithEmail("dany@dragonstone.com"))
+	insertUsers(t, db, jon, arya, dany)
+
+	tests := map[string]struct {
+		query         string
+		expectedFirst models.User
+		expectedCount int
+	}{
+		"should find by a word of the name": {
+			query:         "snow",
+			expectedFirst: jon,
+			expectedCount: 1,
+		},
+		"should find by a part of the email": {
+			query:         "winterfell",
+			expectedCount: 2,
+		},
+		"should find a misspelled name": {
+			query:         "Targaryan",
+			expectedFirst: dany,
+			expectedCount: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			results, err := db.SearchUsers(ctx, test.query, 10)
+			assert.NoError(t, err)
+			assert.Len(t, results, test.expectedCount)
+			if test.expectedFirst.ID != [16]byte{} && len(results) > 0 {
+				assert.Equal(t, test.expectedFirst.ID, results[0].User.ID)
+				assert.Greater(t, results[0].Rank, float32(0))
+			}
+		})
+	}
+
+	assert.NoError(t, db.DeleteUser(ctx, jon.ID, time.Now(), false))
+	results, err := db.SearchUsers(ctx, "snow", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results, "deleted users are not found")
+}
+
+func TestListUsersWithCursors(t *testing.T) {
+	db := psqltest.DB(t)
+	ctx := context.Background()
+	f := factory.New(1)
+
+	inserted := map[string]bool{}
+	for range 5 {
+		user := f.User()
+		insertUsers(t, db, user)
+		inserted[user.ID.String()] = true
+	}
+
+	listed := map[string]bool{}
+	query := "limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("kept paging past the last page")
+		}
+
+		page, err := db.ListUsers(ctx, parseList(t, query))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), page.Total)
+		for _, user := range page.Items {
+			assert.False(t, listed[user.ID.String()], "user listed twice")
+			listed[user.ID.String()] = true
+		}
+
+		if !page.HasNext() {
+			break
+		}
+		query = "limit=2&cursor=" + page.NextCursor
+	}
+
+	assert.Equal(t, inserted, listed)
+}
+
+func parseList(t *testing.T, query string) pagination.Params {
+	t.Helper()
+
+	return parseSpec(t, query, psql.UserListSpec)
+}
+
+func parseSpec(t *testing.T, query string, spec pagination.Spec) pagination.Params {
+	t.Helper()
+
+	values, err := url.ParseQuery(query)
+	assert.NoError(t, err)
+	params, err := pagination.Parse(values, spec)
+	assert.NoError(t, err)
+
+	return params
+}
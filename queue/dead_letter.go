@@ -0,0 +1,121 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+)
+
+type deadLetterStorage interface {
+	InsertDeadLetterJob(ctx context.Context, arg database.InsertDeadLetterJobParams) error
+}
+
+/*
+DeadLetterHandler is a river.ErrorHandler that classifies job errors as
+retryable or permanent. Retryable errors follow the retry policy as usual. A
+job that fails with a permanent error is cancelled right away, and it is
+dead-lettered along with jobs that have failed their final attempt, so they
+can be reviewed in the admin after river has cleaned them up.
+*/
+type DeadLetterHandler struct {
+	db deadLetterStorage
+}
+
+var _ river.ErrorHandler = (*DeadLetterHandler)(nil)
+
+func NewDeadLetterHandler(db *database.Queries) *DeadLetterHandler {
+	return &DeadLetterHandler{db}
+}
+
+/*
+IsPermanent reports whether err is marked as one that retrying can not fix.
+Errors mark themselves by implementing Permanent, like the errors from the
+email providers and jobs.Permanent do, everything else is retryable.
+*/
+func IsPermanent(err error) bool {
+	var permanent interface{ Permanent() bool }
+
+	return errors.As(err, &permanent) && permanent.Permanent()
+}
+
+func (h *DeadLetterHandler) HandleError(
+	ctx context.Context,
+	job *rivertype.JobRow,
+	err error,
+) *river.ErrorHandlerResult {
+	if IsPermanent(err) {
+		h.deadLetter(ctx, job, models.DeadLetterReasonPermanent, err.Error())
+		return &river.ErrorHandlerResult{SetCancelled: true}
+	}
+
+	if isFinalAttempt(job) {
+		h.deadLetter(ctx, job, models.DeadLetterReasonExhausted, err.Error())
+	}
+
+	return nil
+}
+
+// HandlePanic retries panics as they are most likely bugs that a deploy fixes
+// before the job runs out of attempts.
+func (h *DeadLetterHandler) HandlePanic(
+	ctx context.Context,
+	job *rivertype.JobRow,
+	panicVal any,
+) *river.ErrorHandlerResult {
+	if isFinalAttempt(job) {
+		h.deadLetter(
+			ctx,
+			job,
+			models.DeadLetterReasonExhausted,
+			fmt.Sprintf("panic: %v", panicVal),
+		)
+	}
+
+	return nil
+}
+
+func isFinalAttempt(job *rivertype.JobRow) bool {
+	return job.Attempt >= job.MaxAttempts
+}
+
+func (h *DeadLetterHandler) deadLetter(
+	ctx context.Context,
+	job *rivertype.JobRow,
+	reason string,
+	jobErr string,
+) {
+	jobsDeadLettered.WithLabelValues(job.Kind, job.Queue, reason).Inc()
+
+	slog.WarnContext(
+		ctx,
+		"dead-lettering job",
+		"job_id",
+		job.ID,
+		"kind",
+		job.Kind,
+		"reason",
+		reason,
+		"error",
+		jobErr,
+	)
+
+	// sensitive args are still encrypted at this point so they are stored as
+	// is
+	if err := h.db.InsertDeadLetterJob(ctx, database.InsertDeadLetterJobParams{
+		JobID:   job.ID,
+		Kind:    job.Kind,
+		Queue:   job.Queue,
+		Args:    job.EncodedArgs,
+		Reason:  reason,
+		Error:   jobErr,
+		Attempt: int16(job.Attempt),
+	}); err != nil {
+		slog.ErrorContext(ctx, "could not dead-letter job", "error", err, "job_id", job.ID)
+	}
+}
@@ -0,0 +1,114 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+	"github.com/stretchr/testify/assert"
+)
+
+type providerError struct {
+	permanent bool
+}
+
+func (e providerError) Error() string   { return "provider error" }
+func (e providerError) Permanent() bool { return e.permanent }
+
+func TestIsPermanent(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"should retry plain errors": {
+			err:      errors.New("connection reset"),
+			expected: false,
+		},
+		"should not retry errors marked permanent": {
+			err:      jobs.Permanent(errors.New("unknown template")),
+			expected: true,
+		},
+		"should not retry wrapped permanent errors": {
+			err:      fmt.Errorf("could not send: %w", providerError{permanent: true}),
+			expected: true,
+		},
+		"should retry errors that classify themselves as retryable": {
+			err:      providerError{permanent: false},
+			expected: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, queue.IsPermanent(test.err))
+		})
+	}
+}
+
+func TestDeadLetterHandler(t *testing.T) {
+	tests := map[string]struct {
+		attempt           int
+		err               error
+		expectedCancelled bool
+		expectedReason    string
+	}{
+		"should retry a retryable error with attempts left": {
+			attempt: 1,
+			err:     errors.New("throttled"),
+		},
+		"should dead-letter a retryable error on the final attempt": {
+			attempt:        3,
+			err:            errors.New("throttled"),
+			expectedReason: models.DeadLetterReasonExhausted,
+		},
+		"should cancel and dead-letter a permanent error right away": {
+			attempt:           1,
+			err:               providerError{permanent: true},
+			expectedCancelled: true,
+			expectedReason:    models.DeadLetterReasonPermanent,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var stored []database.InsertDeadLetterJobParams
+			handler := queue.NewDeadLetterHandlerWithStorage(
+				func(ctx context.Context, arg database.InsertDeadLetterJobParams) error {
+					stored = append(stored, arg)
+					return nil
+				},
+			)
+
+			job := &rivertype.JobRow{
+				ID:          7,
+				Kind:        "email_job",
+				Queue:       river.QueueDefault,
+				Attempt:     test.attempt,
+				MaxAttempts: 3,
+				EncodedArgs: []byte(`{"to":["user@grafto.com"]}`),
+			}
+
+			result := handler.HandleError(context.Background(), job, test.err)
+
+			assert.Equal(t, test.expectedCancelled, result != nil && result.SetCancelled)
+
+			if test.expectedReason == "" {
+				assert.Empty(t, stored)
+				return
+			}
+
+			assert.Len(t, stored, 1)
+			assert.Equal(t, int64(7), stored[0].JobID)
+			assert.Equal(t, test.expectedReason, stored[0].Reason)
+			assert.Equal(t, test.err.Error(), stored[0].Error)
+			assert.Equal(t, job.EncodedArgs, stored[0].Args)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package queue
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/pkg/envelope"
+)
+
+// NewKeyring creates the keyring used to encrypt and decrypt job args from
+// the base64 encoded keys in cfg.
+func NewKeyring(cfg config.Encryption) (*envelope.Keyring, error) {
+	keys := make(map[string][]byte, len(cfg.JobEncryptionKeys))
+	for id, encoded := range cfg.JobEncryptionKeys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode job encryption key %q: %w", id, err)
+		}
+
+		keys[id] = key
+	}
+
+	return envelope.NewKeyring(cfg.JobEncryptionKeyID, keys)
+}
@@ -0,0 +1,38 @@
+package queue
+
+import (
+	"context"
+
+	"github.com/mbvlabs/grafto/psql/database"
+)
+
+type DeadLetterStorageFunc func(ctx context.Context, arg database.InsertDeadLetterJobParams) error
+
+func (f DeadLetterStorageFunc) InsertDeadLetterJob(
+	ctx context.Context,
+	arg database.InsertDeadLetterJobParams,
+) error {
+	return f(ctx, arg)
+}
+
+func NewDeadLetterHandlerWithStorage(storage DeadLetterStorageFunc) *DeadLetterHandler {
+	return &DeadLetterHandler{storage}
+}
+
+var (
+	RecordJobEvent    = recordJobEvent
+	RecordQueueSample = recordQueueSample
+
+	IdempotencyOf = idempotencyOf
+
+	WithRetryPolicy = withRetryPolicy
+
+	JobsCompleted         = jobsCompleted
+	JobsFailed            = jobsFailed
+	JobsDiscarded         = jobsDiscarded
+	JobDuration           = jobDuration
+	QueueDepth            = queueDepth
+	OldestAvailableJobAge = oldestAvailableJobAge
+)
+
+var WithSchedule = withSchedule
@@ -0,0 +1,84 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+)
+
+const (
+	// keys of jobs share the idempotency_keys table with the keys of http
+	// requests, see middleware.Idempotent
+	jobIdempotencyKeyPrefix = "job:"
+
+	defaultIdempotencyWindow = 24 * time.Hour
+)
+
+func idempotencyOf(args river.JobArgs) *jobs.Idempotency {
+	idempotent, ok := args.(jobs.IdempotentArgs)
+	if !ok {
+		return nil
+	}
+
+	idempotency := idempotent.IdempotencyOpts()
+	if idempotency == nil || idempotency.Key == "" {
+		return nil
+	}
+
+	if idempotency.Window <= 0 {
+		return &jobs.Idempotency{
+			Key:    idempotency.Key,
+			Window: defaultIdempotencyWindow,
+		}
+	}
+
+	return idempotency
+}
+
+// claimIdempotencyKey fails with jobs.ErrDuplicate when the key is in use.
+func claimIdempotencyKey(
+	ctx context.Context,
+	tx pgx.Tx,
+	idempotency *jobs.Idempotency,
+) error {
+	claimed, err := database.New(tx).ClaimIdempotencyKey(
+		ctx,
+		database.ClaimIdempotencyKeyParams{
+			Key: jobIdempotencyKeyPrefix + idempotency.Key,
+			ExpiresAt: pgtype.Timestamptz{
+				Time:  time.Now().Add(idempotency.Window),
+				Valid: true,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if claimed == 0 {
+		return jobs.ErrDuplicate
+	}
+
+	return nil
+}
+
+func setIdempotencyKeyJob(
+	ctx context.Context,
+	tx pgx.Tx,
+	idempotency *jobs.Idempotency,
+	jobID int64,
+) error {
+	return database.New(tx).SetIdempotencyKeyJob(
+		ctx,
+		database.SetIdempotencyKeyJobParams{
+			Key:   jobIdempotencyKeyPrefix + idempotency.Key,
+			JobID: sql.NullInt64{Int64: jobID, Valid: true},
+		},
+	)
+}
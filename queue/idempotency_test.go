@@ -0,0 +1,55 @@
+package queue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+	"github.com/stretchr/testify/assert"
+)
+
+type idempotentArgs struct {
+	idempotency *jobs.Idempotency
+}
+
+func (idempotentArgs) Kind() string { return "idempotent" }
+
+func (a idempotentArgs) IdempotencyOpts() *jobs.Idempotency { return a.idempotency }
+
+func TestIdempotencyOf(t *testing.T) {
+	tests := map[string]struct {
+		args     river.JobArgs
+		expected *jobs.Idempotency
+	}{
+		"should have no key for args without idempotency": {
+			args:     jobs.PurgeIdempotencyKeysJobArgs{},
+			expected: nil,
+		},
+		"should have no key for an empty key": {
+			args:     idempotentArgs{&jobs.Idempotency{Window: time.Hour}},
+			expected: nil,
+		},
+		"should keep the window of the args": {
+			args: idempotentArgs{&jobs.Idempotency{Key: "password_reset:jon", Window: time.Hour}},
+			expected: &jobs.Idempotency{
+				Key:    "password_reset:jon",
+				Window: time.Hour,
+			},
+		},
+		"should default the window to 24 hours": {
+			args: idempotentArgs{&jobs.Idempotency{Key: "password_reset:jon"}},
+			expected: &jobs.Idempotency{
+				Key:    "password_reset:jon",
+				Window: 24 * time.Hour,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, queue.IdempotencyOf(test.args))
+		})
+	}
+}
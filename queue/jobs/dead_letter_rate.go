@@ -0,0 +1,16 @@
+package jobs
+
+const checkDeadLetterRateJobKind string = "check_dead_letter_rate_job"
+
+/*
+CheckDeadLetterRateJobArgs alerts operators when too many of the recently
+finalized jobs were dead-lettered. It is only attempted once, the next check
+comes around soon enough and a retry could send the same alert twice.
+*/
+type CheckDeadLetterRateJobArgs struct{}
+
+func (CheckDeadLetterRateJobArgs) Kind() string { return checkDeadLetterRateJobKind }
+
+func (CheckDeadLetterRateJobArgs) RetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
@@ -0,0 +1,9 @@
+package jobs
+
+const purgeDeletedUsersJobKind string = "purge_deleted_users_job"
+
+// PurgeDeletedUsersJobArgs removes the deleted users that can no longer be
+// restored.
+type PurgeDeletedUsersJobArgs struct{}
+
+func (PurgeDeletedUsersJobArgs) Kind() string { return purgeDeletedUsersJobKind }
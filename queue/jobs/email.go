@@ -1,19 +1,136 @@
 package jobs
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"time"
 
-const emailJobKind string = "email_job"
+	"github.com/google/uuid"
+	"github.com/riverqueue/river"
+)
+
+const (
+	emailJobKind          string = "email_job"
+	templatedEmailJobKind string = "templated_email_job"
+)
+
+// emailRetryPolicy gives a provider outage a couple of hours to clear up
+// before the email is given up on.
+var emailRetryPolicy = RetryPolicy{
+	MaxAttempts: 10,
+	Backoff:     BackoffExponential,
+	BaseDelay:   30 * time.Second,
+	MaxDelay:    2 * time.Hour,
+	Jitter:      0.2,
+}
+
+/*
+Recipients is a list of addresses that also accepts a single string, so jobs
+enqueued before multiple recipients were supported can still be worked.
+*/
+type Recipients []string
+
+func (r *Recipients) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*r = Recipients{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*r = multiple
+
+	return nil
+}
+
+/*
+EmailAttachment holds the attachment content inline for small files. Larger
+files are stored in the email_attachments table and only referenced by
+StoredID.
+*/
+type EmailAttachment struct {
+	Filename    string     `json:"filename"`
+	ContentType string     `json:"content_type"`
+	Content     []byte     `json:"content,omitempty"`
+	StoredID    *uuid.UUID `json:"stored_id,omitempty"`
+}
 
 type EmailJobArgs struct {
-	To          string `json:"to"`
-	From        string `json:"from"`
-	Subject     string `json:"subject"`
-	TextVersion string `json:"text_version"`
-	HtmlVersion string `json:"html_version"`
+	To          Recipients        `json:"to"`
+	Cc          []string          `json:"cc,omitempty"`
+	Bcc         []string          `json:"bcc,omitempty"`
+	ReplyTo     []string          `json:"reply_to,omitempty"`
+	From        string            `json:"from"`
+	Subject     string            `json:"subject"`
+	TextVersion string            `json:"text_version"`
+	HtmlVersion string            `json:"html_version"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Attachments []EmailAttachment `json:"attachments,omitempty"`
+	Idempotency *Idempotency      `json:"idempotency,omitempty"`
+	Lane        EmailLane         `json:"lane,omitempty"`
+}
+
+/*
+UnmarshalJSON leaves the args sealed by EncryptArgs empty instead of failing on
+them. River decodes the args before the worker runs, and the worker decodes
+them again once they are decrypted.
+*/
+func (e *EmailJobArgs) UnmarshalJSON(data []byte) error {
+	type emailJobArgs EmailJobArgs
+
+	return json.Unmarshal(withoutEncryptedArgs(data), (*emailJobArgs)(e))
 }
 
 func (EmailJobArgs) Kind() string { return emailJobKind }
 
+func (e EmailJobArgs) InsertOpts() river.InsertOpts { return emailInsertOpts(e.EmailLane()) }
+
+func (e EmailJobArgs) EmailLane() EmailLane { return emailLane(e.Lane) }
+
+func (e EmailJobArgs) RecipientCount() int { return len(e.To) + len(e.Cc) + len(e.Bcc) }
+
+func (EmailJobArgs) RetryPolicy() RetryPolicy { return emailRetryPolicy }
+
+func (e EmailJobArgs) IdempotencyOpts() *Idempotency { return e.Idempotency }
+
+/*
+TemplatedEmailJobArgs carries the name of a registered email and its props
+instead of the rendered content, so the email is rendered by the worker at send
+time with the templates deployed then. Props is the json encoded props of the
+email, see emails.New.
+*/
+type TemplatedEmailJobArgs struct {
+	To          Recipients        `json:"to"`
+	Cc          []string          `json:"cc,omitempty"`
+	Bcc         []string          `json:"bcc,omitempty"`
+	ReplyTo     []string          `json:"reply_to,omitempty"`
+	From        string            `json:"from"`
+	Template    string            `json:"template"`
+	Props       json.RawMessage   `json:"props"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Idempotency *Idempotency      `json:"idempotency,omitempty"`
+	Lane        EmailLane         `json:"lane,omitempty"`
+}
+
+func (TemplatedEmailJobArgs) Kind() string { return templatedEmailJobKind }
+
+func (t TemplatedEmailJobArgs) InsertOpts() river.InsertOpts {
+	return emailInsertOpts(t.EmailLane())
+}
+
+func (t TemplatedEmailJobArgs) EmailLane() EmailLane { return emailLane(t.Lane) }
+
+func (t TemplatedEmailJobArgs) RecipientCount() int {
+	return len(t.To) + len(t.Cc) + len(t.Bcc)
+}
+
+func (TemplatedEmailJobArgs) RetryPolicy() RetryPolicy { return emailRetryPolicy }
+
+func (t TemplatedEmailJobArgs) IdempotencyOpts() *Idempotency { return t.Idempotency }
+
 type EmailSender interface {
 	Send(
 		ctx context.Context,
@@ -0,0 +1,13 @@
+package jobs
+
+const purgeEmailAttachmentsJobKind string = "purge_email_attachments_job"
+
+/*
+PurgeEmailAttachmentsJobArgs removes the stored email attachments that no job
+will send, which are the ones of jobs river has cleaned up after they were
+discarded or cancelled, and the ones of duplicate emails that were never
+queued.
+*/
+type PurgeEmailAttachmentsJobArgs struct{}
+
+func (PurgeEmailAttachmentsJobArgs) Kind() string { return purgeEmailAttachmentsJobKind }
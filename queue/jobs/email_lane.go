@@ -0,0 +1,44 @@
+package jobs
+
+import "github.com/riverqueue/river"
+
+/*
+EmailLane is the queue an email is sent from. Transactional and bulk mail are
+worked from separate queues so a newsletter going out never holds up a
+password reset, and bulk mail leaves part of the send rate to transactional
+mail, see config.Email.
+*/
+type EmailLane string
+
+const (
+	EmailLaneTransactional EmailLane = "email_transactional"
+	EmailLaneBulk          EmailLane = "email_bulk"
+)
+
+// ThrottledEmail is implemented by the args of jobs that send email, so the
+// worker can take their share of the send rate before sending.
+type ThrottledEmail interface {
+	EmailLane() EmailLane
+	// RecipientCount is the number of messages sending the email counts as,
+	// providers like ses count every recipient towards the send rate
+	RecipientCount() int
+}
+
+// emailLane defaults to transactional, which includes the jobs enqueued
+// before lanes existed.
+func emailLane(lane EmailLane) EmailLane {
+	if lane == EmailLaneBulk {
+		return EmailLaneBulk
+	}
+
+	return EmailLaneTransactional
+}
+
+func emailInsertOpts(lane EmailLane) river.InsertOpts {
+	priority := 1
+	if lane == EmailLaneBulk {
+		priority = 4
+	}
+
+	return river.InsertOpts{Queue: string(lane), Priority: priority}
+}
@@ -0,0 +1,42 @@
+package jobs_test
+
+import (
+	"testing"
+
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailLaneInsertOpts(t *testing.T) {
+	tests := map[string]struct {
+		args          interface{ InsertOpts() river.InsertOpts }
+		expectedQueue string
+		expectedPrio  int
+	}{
+		"should send transactional mail by default": {
+			args:          jobs.EmailJobArgs{},
+			expectedQueue: string(jobs.EmailLaneTransactional),
+			expectedPrio:  1,
+		},
+		"should send bulk mail from its own queue": {
+			args:          jobs.EmailJobArgs{Lane: jobs.EmailLaneBulk},
+			expectedQueue: string(jobs.EmailLaneBulk),
+			expectedPrio:  4,
+		},
+		"should send templated email in the transactional lane": {
+			args:          jobs.TemplatedEmailJobArgs{},
+			expectedQueue: string(jobs.EmailLaneTransactional),
+			expectedPrio:  1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := test.args.InsertOpts()
+
+			assert.Equal(t, test.expectedQueue, opts.Queue)
+			assert.Equal(t, test.expectedPrio, opts.Priority)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/mbvlabs/grafto/pkg/envelope"
+)
+
+var ErrNoKeyring = errors.New("job args are encrypted but no keyring is configured")
+
+// encryptedKey marks an arg whose value has been replaced by its envelope
+const encryptedKey = "$encrypted"
+
+type encryptedArg struct {
+	Envelope envelope.Envelope `json:"$encrypted"`
+}
+
+/*
+EncryptArgs seals every sensitive arg of the json encoded args, see
+sensitiveArgs, so the values are never stored in river_job in plain text. Args
+of kinds without sensitive args are returned as is.
+*/
+func EncryptArgs(keyring *envelope.Keyring, kind string, args []byte) ([]byte, error) {
+	sensitive := sensitiveArgs[kind]
+	if len(sensitive) == 0 {
+		return args, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(args, &fields); err != nil {
+		return nil, err
+	}
+
+	for _, key := range sensitive {
+		value, ok := fields[key]
+		if !ok || string(value) == "null" {
+			continue
+		}
+
+		sealed, err := keyring.Seal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		encrypted, err := json.Marshal(encryptedArg{sealed})
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = encrypted
+	}
+
+	return json.Marshal(fields)
+}
+
+/*
+DecryptArgs restores the args sealed by EncryptArgs. Args without encrypted
+values, e.g. jobs inserted before encryption was introduced, are returned as
+is, and keyring may be nil for those.
+*/
+func DecryptArgs(keyring *envelope.Keyring, args []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(args, &fields); err != nil {
+		// args that are not an object can not hold encrypted values
+		return args, nil
+	}
+
+	decrypted := false
+	for key, value := range fields {
+		var arg encryptedArg
+		if !isEncrypted(value) || json.Unmarshal(value, &arg) != nil {
+			continue
+		}
+
+		if keyring == nil {
+			return nil, ErrNoKeyring
+		}
+
+		opened, err := keyring.Open(arg.Envelope)
+		if err != nil {
+			return nil, err
+		}
+
+		fields[key] = opened
+		decrypted = true
+	}
+
+	if !decrypted {
+		return args, nil
+	}
+
+	return json.Marshal(fields)
+}
+
+// withoutEncryptedArgs drops the values sealed by EncryptArgs from the json
+// encoded args. Args that are not an object are returned as is.
+func withoutEncryptedArgs(args []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(args, &fields); err != nil {
+		return args
+	}
+
+	dropped := false
+	for key, value := range fields {
+		if isEncrypted(value) {
+			delete(fields, key)
+			dropped = true
+		}
+	}
+
+	if !dropped {
+		return args
+	}
+
+	stripped, err := json.Marshal(fields)
+	if err != nil {
+		return args
+	}
+
+	return stripped
+}
+
+func isEncrypted(value json.RawMessage) bool {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(value, &object); err != nil {
+		return false
+	}
+
+	_, ok := object[encryptedKey]
+	return ok && len(object) == 1
+}
@@ -0,0 +1,113 @@
+package jobs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/mbvlabs/grafto/pkg/envelope"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/stretchr/testify/assert"
+)
+
+func newKeyring(t *testing.T) *envelope.Keyring {
+	keyring, err := envelope.NewKeyring("test", map[string][]byte{
+		"test": bytes.Repeat([]byte{1}, 32),
+	})
+	assert.NoError(t, err)
+
+	return keyring
+}
+
+func TestEncryptArgs(t *testing.T) {
+	keyring := newKeyring(t)
+
+	args := jobs.EmailJobArgs{
+		To:          jobs.Recipients{"jon@stark.com"},
+		From:        "ned@stark.com",
+		Subject:     "Reset your password",
+		TextVersion: "https://grafto.com/reset-password?token=secret",
+		HtmlVersion: "<a href='https://grafto.com/reset-password?token=secret'>",
+	}
+	encoded, err := json.Marshal(args)
+	assert.NoError(t, err)
+
+	encrypted, err := jobs.EncryptArgs(keyring, args.Kind(), encoded)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "token=secret")
+	assert.Contains(t, string(encrypted), "Reset your password")
+
+	decrypted, err := jobs.DecryptArgs(keyring, encrypted)
+	assert.NoError(t, err)
+
+	var decoded jobs.EmailJobArgs
+	assert.NoError(t, json.Unmarshal(decrypted, &decoded))
+	assert.Equal(t, args, decoded)
+
+	var redacted map[string]any
+	assert.NoError(t, json.Unmarshal(jobs.RedactArgs(args.Kind(), encrypted), &redacted))
+	assert.Equal(t, jobs.Redacted, redacted["text_version"])
+	assert.Equal(t, jobs.Redacted, redacted["html_version"])
+}
+
+func TestDecryptArgs(t *testing.T) {
+	tests := map[string]struct {
+		keyring     *envelope.Keyring
+		args        []byte
+		expected    []byte
+		expectedErr error
+	}{
+		"should return args inserted before encryption as is": {
+			keyring:  newKeyring(t),
+			args:     []byte(`{"to":"jon@stark.com","text_version":"plain"}`),
+			expected: []byte(`{"to":"jon@stark.com","text_version":"plain"}`),
+		},
+		"should not need a keyring for args without encrypted values": {
+			args:     []byte(`{"to":"jon@stark.com"}`),
+			expected: []byte(`{"to":"jon@stark.com"}`),
+		},
+		"should fail on encrypted args without a keyring": {
+			args:        []byte(`{"text_version":{"$encrypted":{"kid":"test","dek":"","ct":""}}}`),
+			expectedErr: jobs.ErrNoKeyring,
+		},
+		"should fail on encrypted args sealed with an unknown key": {
+			keyring:     newKeyring(t),
+			args:        []byte(`{"text_version":{"$encrypted":{"kid":"retired","dek":"","ct":""}}}`),
+			expectedErr: envelope.ErrUnknownKey,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			decrypted, err := jobs.DecryptArgs(test.keyring, test.args)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, decrypted)
+		})
+	}
+}
+
+func TestUnmarshalEncryptedEmailJobArgs(t *testing.T) {
+	args := jobs.EmailJobArgs{
+		To:          jobs.Recipients{"jon@stark.com"},
+		From:        "ned@stark.com",
+		Subject:     "Verify your email",
+		TextVersion: "https://grafto.com/verify-email?token=secret",
+		HtmlVersion: "<a href='https://grafto.com/verify-email?token=secret'>",
+	}
+	encoded, err := json.Marshal(args)
+	assert.NoError(t, err)
+	encrypted, err := jobs.EncryptArgs(newKeyring(t), args.Kind(), encoded)
+	assert.NoError(t, err)
+
+	var decoded jobs.EmailJobArgs
+	assert.NoError(t, json.Unmarshal(encrypted, &decoded))
+	assert.Equal(t, args.To, decoded.To)
+	assert.Equal(t, args.Subject, decoded.Subject)
+	assert.Empty(t, decoded.TextVersion)
+	assert.Empty(t, decoded.HtmlVersion)
+}
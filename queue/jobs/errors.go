@@ -0,0 +1,27 @@
+package jobs
+
+/*
+permanentError marks an error that retrying the job can not fix, e.g. a
+rejected recipient or args that can not be decoded. Errors from outside the
+queue, like the email providers' send errors, are classified the same way as
+long as they have a Permanent method.
+*/
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+func (e permanentError) Permanent() bool { return true }
+
+// Permanent marks err as one that will fail the same way on every attempt, so
+// the job is dead-lettered right away instead of retried.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return permanentError{err}
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrDuplicate = errors.New("a job with the same idempotency key was already inserted")
+
+/*
+Idempotency makes the insert of a job a no-op when a job with the same Key was
+inserted within Window, e.g. to send a single password reset email for
+"password_reset:<user_id>" no matter how often the form is submitted. The
+duplicate insert fails with ErrDuplicate. Window defaults to 24 hours.
+
+The key is claimed in the idempotency_keys table rather than left to the unique
+inserts of river, as those compare the args of jobs and know nothing of keys.
+Jobs that are duplicates when their args are the same can still set
+river.UniqueOpts in their insert opts.
+*/
+type Idempotency struct {
+	Key    string        `json:"key"`
+	Window time.Duration `json:"window"`
+}
+
+// IdempotentArgs is implemented by args that can carry an Idempotency.
+type IdempotentArgs interface {
+	IdempotencyOpts() *Idempotency
+}
+
+// HasSensitiveArgs reports whether jobs of kind have args that are encrypted
+// at rest, see EncryptArgs.
+func HasSensitiveArgs(kind string) bool {
+	return len(sensitiveArgs[kind]) > 0
+}
@@ -0,0 +1,20 @@
+package jobs
+
+import "time"
+
+const purgeIdempotencyKeysJobKind string = "purge_idempotency_keys_job"
+
+// PurgeIdempotencyKeysJobArgs removes the idempotency keys that have expired.
+type PurgeIdempotencyKeysJobArgs struct{}
+
+func (PurgeIdempotencyKeysJobArgs) Kind() string { return purgeIdempotencyKeysJobKind }
+
+// RetryPolicy gives up early as the next run an hour later purges whatever a
+// failed run left behind.
+func (PurgeIdempotencyKeysJobArgs) RetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     BackoffConstant,
+		BaseDelay:   5 * time.Minute,
+	}
+}
@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const Redacted = "[redacted]"
+
+// sensitiveArgs lists the args, per job kind, that can hold secrets such as
+// tokens embedded in links and must never be shown outside the worker. They
+// are encrypted at rest, see EncryptArgs.
+var sensitiveArgs = map[string][]string{
+	emailJobKind: {"text_version", "html_version", "attachments"},
+	// the props of an email hold the links that end up in it
+	templatedEmailJobKind: {"props"},
+}
+
+// args of any kind are redacted when their key contains one of these
+var sensitiveKeyParts = []string{"token", "password", "secret", "key"}
+
+/*
+RedactArgs returns the json encoded args of a job with every sensitive value
+replaced by Redacted. Nested objects are redacted as well. Args that are not a
+json object are redacted as a whole as there is no telling what they hold.
+*/
+func RedactArgs(kind string, args []byte) []byte {
+	var fields map[string]any
+	if err := json.Unmarshal(args, &fields); err != nil {
+		redacted, _ := json.Marshal(Redacted)
+		return redacted
+	}
+
+	for _, key := range sensitiveArgs[kind] {
+		if _, ok := fields[key]; ok {
+			fields[key] = Redacted
+		}
+	}
+
+	redacted, err := json.MarshalIndent(redactValue(fields), "", "  ")
+	if err != nil {
+		redacted, _ = json.Marshal(Redacted)
+	}
+
+	return redacted
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, nested := range v {
+			if isSensitiveKey(key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redactValue(nested)
+		}
+		return v
+	case []any:
+		for i, nested := range v {
+			v[i] = redactValue(nested)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+
+	return false
+}
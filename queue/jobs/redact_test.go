@@ -0,0 +1,76 @@
+package jobs_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactArgs(t *testing.T) {
+	tests := map[string]struct {
+		kind     string
+		args     any
+		expected map[string]any
+	}{
+		"should redact the bodies and attachments of email jobs": {
+			kind: jobs.EmailJobArgs{}.Kind(),
+			args: jobs.EmailJobArgs{
+				To:          jobs.Recipients{"jon@stark.com"},
+				From:        "ned@stark.com",
+				Subject:     "Reset your password",
+				TextVersion: "https://grafto.com/reset-password?token=secret",
+				HtmlVersion: "<a href='https://grafto.com/reset-password?token=secret'>",
+				Attachments: []jobs.EmailAttachment{
+					{Filename: "invoice.pdf", Content: []byte("%PDF")},
+				},
+			},
+			expected: map[string]any{
+				"to":           []any{"jon@stark.com"},
+				"from":         "ned@stark.com",
+				"subject":      "Reset your password",
+				"text_version": jobs.Redacted,
+				"html_version": jobs.Redacted,
+				"attachments":  jobs.Redacted,
+			},
+		},
+		"should redact sensitive keys of any kind at any depth": {
+			kind: "unknown_job",
+			args: map[string]any{
+				"user_id":      "8a1b",
+				"reset_token":  "secret",
+				"credentials":  map[string]any{"Password": "hunter2", "username": "jon"},
+				"webhooks":     []any{map[string]any{"signing_key": "secret"}},
+				"api_response": "ok",
+			},
+			expected: map[string]any{
+				"user_id":      "8a1b",
+				"reset_token":  jobs.Redacted,
+				"credentials":  map[string]any{"Password": jobs.Redacted, "username": "jon"},
+				"webhooks":     []any{map[string]any{"signing_key": jobs.Redacted}},
+				"api_response": "ok",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			args, err := json.Marshal(test.args)
+			if err != nil {
+				t.Fatalf("could not marshal args: %v", err)
+			}
+
+			var actual map[string]any
+			if err := json.Unmarshal(jobs.RedactArgs(test.kind, args), &actual); err != nil {
+				t.Fatalf("could not unmarshal redacted args: %v", err)
+			}
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestRedactArgsRejectsNonObjects(t *testing.T) {
+	assert.JSONEq(t, `"[redacted]"`, string(jobs.RedactArgs("unknown_job", []byte(`"token"`))))
+}
@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+type Backoff int
+
+const (
+	// BackoffExponential doubles the delay after every failed attempt.
+	BackoffExponential Backoff = iota
+	// BackoffLinear adds BaseDelay to the delay after every failed attempt.
+	BackoffLinear
+	// BackoffConstant waits BaseDelay between every attempt.
+	BackoffConstant
+)
+
+/*
+RetryPolicy declares how a kind of job is retried, overriding the default
+schedule of river. Args declare their policy by implementing
+RetryPolicyProvider, next to the args themselves.
+
+Jitter spreads retries out so jobs that failed together, e.g. because a
+provider was down, are not retried together. A jitter of 0.2 picks a delay
+between 80% and 120% of the one the backoff calls for.
+*/
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     Backoff
+	// BaseDelay is the delay before the first retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay, leave it zero for no cap
+	MaxDelay time.Duration
+	Jitter   float64
+}
+
+type RetryPolicyProvider interface {
+	RetryPolicy() RetryPolicy
+}
+
+// Delay is the delay the backoff calls for after the given number of failed
+// attempts, before jitter is applied.
+func (p RetryPolicy) Delay(failures int) time.Duration {
+	failures = max(failures, 1)
+
+	var delay float64
+	switch p.Backoff {
+	case BackoffLinear:
+		delay = float64(p.BaseDelay) * float64(failures)
+	case BackoffConstant:
+		delay = float64(p.BaseDelay)
+	default:
+		delay = float64(p.BaseDelay) * math.Pow(2, float64(failures-1))
+	}
+
+	// the float keeps a long streak of failures from overflowing before the
+	// cap applies
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	if delay > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration(delay)
+}
+
+/*
+NextRetry is when to retry a job that has failed the given number of times,
+with random in [0, 1) picking where within the jitter the retry lands. Failures
+are counted instead of attempts so snoozing a job does not grow its backoff.
+*/
+func (p RetryPolicy) NextRetry(now time.Time, failures int, random float64) time.Time {
+	delay := float64(p.Delay(failures))
+	delay *= 1 - p.Jitter + 2*p.Jitter*random
+
+	return now.Add(time.Duration(min(delay, math.MaxInt64)))
+}
+
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+func (e retryAfterError) Error() string { return e.err.Error() }
+
+func (e retryAfterError) Unwrap() error { return e.err }
+
+func (e retryAfterError) RetryAfter() time.Duration { return e.after }
+
+// RetryAfter hints that the job should not be retried for at least after, e.g.
+// because the worker was told to back off.
+func RetryAfter(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+
+	return retryAfterError{err, after}
+}
+
+/*
+RetryAfterOf is the retry after hint carried by err, zero if it has none.
+Errors carry a hint by implementing RetryAfter, like the ones RetryAfter returns
+and the throttling errors of the email providers do.
+*/
+func RetryAfterOf(err error) time.Duration {
+	var hint interface{ RetryAfter() time.Duration }
+	if !errors.As(err, &hint) {
+		return 0
+	}
+
+	return max(hint.RetryAfter(), 0)
+}
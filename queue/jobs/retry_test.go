@@ -0,0 +1,114 @@
+package jobs_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := map[string]struct {
+		policy   jobs.RetryPolicy
+		failures int
+		expected time.Duration
+	}{
+		"should wait the base delay after the first failure": {
+			policy:   jobs.RetryPolicy{Backoff: jobs.BackoffExponential, BaseDelay: 30 * time.Second},
+			failures: 1,
+			expected: 30 * time.Second,
+		},
+		"should double the delay for exponential backoff": {
+			policy:   jobs.RetryPolicy{Backoff: jobs.BackoffExponential, BaseDelay: 30 * time.Second},
+			failures: 4,
+			expected: 4 * time.Minute,
+		},
+		"should add the base delay for linear backoff": {
+			policy:   jobs.RetryPolicy{Backoff: jobs.BackoffLinear, BaseDelay: 30 * time.Second},
+			failures: 4,
+			expected: 2 * time.Minute,
+		},
+		"should keep the base delay for constant backoff": {
+			policy:   jobs.RetryPolicy{Backoff: jobs.BackoffConstant, BaseDelay: 30 * time.Second},
+			failures: 4,
+			expected: 30 * time.Second,
+		},
+		"should cap the delay": {
+			policy: jobs.RetryPolicy{
+				Backoff:   jobs.BackoffExponential,
+				BaseDelay: 30 * time.Second,
+				MaxDelay:  time.Hour,
+			},
+			failures: 100,
+			expected: time.Hour,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.policy.Delay(test.failures))
+		})
+	}
+}
+
+func TestRetryPolicyNextRetry(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	policy := jobs.RetryPolicy{
+		Backoff:   jobs.BackoffConstant,
+		BaseDelay: 10 * time.Minute,
+		Jitter:    0.2,
+	}
+
+	tests := map[string]struct {
+		random   float64
+		expected time.Time
+	}{
+		"should retry early at the low end of the jitter": {
+			random:   0,
+			expected: now.Add(8 * time.Minute),
+		},
+		"should retry on the delay in the middle of the jitter": {
+			random:   0.5,
+			expected: now.Add(10 * time.Minute),
+		},
+		"should retry late at the high end of the jitter": {
+			random:   0.75,
+			expected: now.Add(11 * time.Minute),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, policy.NextRetry(now, 1, test.random))
+		})
+	}
+}
+
+func TestRetryAfterOf(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected time.Duration
+	}{
+		"should have no hint for plain errors": {
+			err:      errors.New("connection reset"),
+			expected: 0,
+		},
+		"should find a wrapped hint": {
+			err:      fmt.Errorf("could not send: %w", jobs.RetryAfter(errors.New("throttled"), time.Minute)),
+			expected: time.Minute,
+		},
+		"should have no hint without an error": {
+			err:      nil,
+			expected: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, jobs.RetryAfterOf(test.err))
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package queue
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+)
+
+const (
+	metricsNamespace      = "river"
+	metricsSampleInterval = 15 * time.Second
+)
+
+var (
+	jobsInserted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_inserted_total",
+		Help:      "Jobs inserted by this process.",
+	}, []string{"kind", "queue"})
+
+	jobsCompleted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_completed_total",
+		Help:      "Jobs worked successfully.",
+	}, []string{"kind", "queue"})
+
+	jobsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_failed_total",
+		Help:      "Failed job attempts, including the final attempt of discarded jobs.",
+	}, []string{"kind", "queue"})
+
+	jobsDiscarded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_discarded_total",
+		Help:      "Jobs that failed their final attempt and will not be retried.",
+	}, []string{"kind", "queue"})
+
+	jobsCancelled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_cancelled_total",
+		Help:      "Jobs cancelled by their worker or remotely.",
+	}, []string{"kind", "queue"})
+
+	jobsDeadLettered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_dead_lettered_total",
+		Help:      "Jobs that failed for good, by why they were given up on.",
+	}, []string{"kind", "queue", "reason"})
+
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "job_duration_seconds",
+		Help:      "Time spent working a job, by outcome.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 16),
+	}, []string{"kind", "queue", "outcome"})
+
+	jobQueueWait = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "job_queue_wait_seconds",
+		Help:      "Time a job spent available before a worker picked it up.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 16),
+	}, []string{"kind", "queue"})
+
+	queueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "queue_jobs",
+		Help:      "Jobs in the database per queue and state, sampled periodically.",
+	}, []string{"queue", "state"})
+
+	oldestAvailableJobAge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "queue_oldest_available_job_age_seconds",
+		Help:      "How long the oldest available job has been waiting, sampled periodically.",
+	}, []string{"queue"})
+)
+
+var jobEventKinds = []river.EventKind{
+	river.EventKindJobCompleted,
+	river.EventKindJobFailed,
+	river.EventKindJobCancelled,
+	river.EventKindJobSnoozed,
+}
+
+type insertOptsProvider interface {
+	InsertOpts() river.InsertOpts
+}
+
+// insertQueue resolves the queue a job is inserted into the same way river
+// does: opts first, then the defaults of the args, then the default queue.
+func insertQueue(args river.JobArgs, opts *river.InsertOpts) string {
+	if opts != nil && opts.Queue != "" {
+		return opts.Queue
+	}
+	if provider, ok := args.(insertOptsProvider); ok {
+		if queue := provider.InsertOpts().Queue; queue != "" {
+			return queue
+		}
+	}
+
+	return river.QueueDefault
+}
+
+func recordInsertMany(params []river.InsertManyParams) {
+	for _, param := range params {
+		jobsInserted.WithLabelValues(
+			param.Args.Kind(),
+			insertQueue(param.Args, param.InsertOpts),
+		).Inc()
+	}
+}
+
+// recordJobEvents records the outcome of every worked job until events is
+// closed.
+func recordJobEvents(events <-chan *river.Event) {
+	for event := range events {
+		recordJobEvent(event)
+	}
+}
+
+func recordJobEvent(event *river.Event) {
+	if event.Job == nil {
+		return
+	}
+
+	kind, queue := event.Job.Kind, event.Job.Queue
+
+	var outcome string
+	switch event.Kind {
+	case river.EventKindJobCompleted:
+		outcome = "completed"
+		jobsCompleted.WithLabelValues(kind, queue).Inc()
+	case river.EventKindJobFailed:
+		outcome = "failed"
+		jobsFailed.WithLabelValues(kind, queue).Inc()
+		if event.Job.State == rivertype.JobStateDiscarded {
+			outcome = "discarded"
+			jobsDiscarded.WithLabelValues(kind, queue).Inc()
+		}
+	case river.EventKindJobCancelled:
+		outcome = "cancelled"
+		jobsCancelled.WithLabelValues(kind, queue).Inc()
+	case river.EventKindJobSnoozed:
+		outcome = "snoozed"
+	default:
+		return
+	}
+
+	if event.JobStats != nil {
+		jobDuration.WithLabelValues(kind, queue, outcome).
+			Observe(event.JobStats.RunDuration.Seconds())
+		jobQueueWait.WithLabelValues(kind, queue).
+			Observe(event.JobStats.QueueWaitDuration.Seconds())
+	}
+}
+
+// sampleQueues keeps the queue depth gauges up to date until ctx is done.
+func sampleQueues(ctx context.Context, db *database.Queries) {
+	ticker := time.NewTicker(metricsSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		sampleQueuesOnce(ctx, db)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func sampleQueuesOnce(ctx context.Context, db *database.Queries) {
+	stats, err := db.QueryJobQueueStats(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "could not sample queue depth", "error", err)
+		}
+		return
+	}
+
+	ages, err := db.QueryOldestAvailableJobAges(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "could not sample oldest available jobs", "error", err)
+		}
+		return
+	}
+
+	recordQueueSample(stats, ages)
+}
+
+/*
+recordQueueSample replaces the queue gauges with a new sample. Gauges are reset
+first so that queues and states without jobs drop to zero instead of keeping
+their last value.
+*/
+func recordQueueSample(
+	stats []database.QueryJobQueueStatsRow,
+	ages []database.QueryOldestAvailableJobAgesRow,
+) {
+	queueDepth.Reset()
+	oldestAvailableJobAge.Reset()
+
+	for _, stat := range stats {
+		queueDepth.WithLabelValues(stat.Queue, string(stat.State)).
+			Set(float64(stat.Total))
+		oldestAvailableJobAge.WithLabelValues(stat.Queue).Set(0)
+	}
+
+	for _, age := range ages {
+		oldestAvailableJobAge.WithLabelValues(age.Queue).Set(age.AgeSeconds)
+	}
+}
@@ -0,0 +1,93 @@
+package queue_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordJobEvent(t *testing.T) {
+	tests := map[string]struct {
+		event             *river.Event
+		expectedCompleted float64
+		expectedFailed    float64
+		expectedDiscarded float64
+		expectedOutcome   string
+	}{
+		"should count completed jobs": {
+			event: &river.Event{
+				Kind: river.EventKindJobCompleted,
+				Job:  &rivertype.JobRow{Kind: "completed_job", Queue: "default", State: rivertype.JobStateCompleted},
+			},
+			expectedCompleted: 1,
+			expectedOutcome:   "completed",
+		},
+		"should count failed attempts that will be retried": {
+			event: &river.Event{
+				Kind: river.EventKindJobFailed,
+				Job:  &rivertype.JobRow{Kind: "retried_job", Queue: "default", State: rivertype.JobStateRetryable},
+			},
+			expectedFailed:  1,
+			expectedOutcome: "failed",
+		},
+		"should count the final attempt as both failed and discarded": {
+			event: &river.Event{
+				Kind: river.EventKindJobFailed,
+				Job:  &rivertype.JobRow{Kind: "discarded_job", Queue: "default", State: rivertype.JobStateDiscarded},
+			},
+			expectedFailed:    1,
+			expectedDiscarded: 1,
+			expectedOutcome:   "discarded",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.event.JobStats = &river.JobStatistics{RunDuration: 200 * time.Millisecond}
+			kind, queueName := test.event.Job.Kind, test.event.Job.Queue
+
+			queue.RecordJobEvent(test.event)
+
+			assert.Equal(t, test.expectedCompleted, testutil.ToFloat64(queue.JobsCompleted.WithLabelValues(kind, queueName)))
+			assert.Equal(t, test.expectedFailed, testutil.ToFloat64(queue.JobsFailed.WithLabelValues(kind, queueName)))
+			assert.Equal(t, test.expectedDiscarded, testutil.ToFloat64(queue.JobsDiscarded.WithLabelValues(kind, queueName)))
+
+			var duration dto.Metric
+			histogram := queue.JobDuration.WithLabelValues(kind, queueName, test.expectedOutcome)
+			assert.NoError(t, histogram.(prometheus.Histogram).Write(&duration))
+			assert.Equal(t, uint64(1), duration.GetHistogram().GetSampleCount())
+			assert.Equal(t, 0.2, duration.GetHistogram().GetSampleSum())
+		})
+	}
+}
+
+func TestRecordQueueSample(t *testing.T) {
+	queue.RecordQueueSample(
+		[]database.QueryJobQueueStatsRow{
+			{Queue: "default", State: database.RiverJobStateAvailable, Total: 3},
+			{Queue: "email", State: database.RiverJobStateCompleted, Total: 7},
+		},
+		[]database.QueryOldestAvailableJobAgesRow{
+			{Queue: "default", AgeSeconds: 42},
+		},
+	)
+
+	assert.Equal(t, float64(3), testutil.ToFloat64(queue.QueueDepth.WithLabelValues("default", "available")))
+	assert.Equal(t, float64(7), testutil.ToFloat64(queue.QueueDepth.WithLabelValues("email", "completed")))
+	assert.Equal(t, float64(42), testutil.ToFloat64(queue.OldestAvailableJobAge.WithLabelValues("default")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(queue.OldestAvailableJobAge.WithLabelValues("email")))
+
+	// a queue that has been emptied should not keep its last sample
+	queue.RecordQueueSample(nil, nil)
+
+	assert.Equal(t, 0, testutil.CollectAndCount(queue.QueueDepth))
+	assert.Equal(t, 0, testutil.CollectAndCount(queue.OldestAvailableJobAge))
+}
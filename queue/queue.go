@@ -1,13 +1,21 @@
 package queue
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mbvlabs/grafto/pkg/envelope"
+	"github.com/mbvlabs/grafto/queue/jobs"
 	"github.com/riverqueue/river"
 	"github.com/riverqueue/river/riverdriver/riverpgxv5"
+	"github.com/riverqueue/river/rivertype"
 )
 
 /*
@@ -18,6 +26,7 @@ type clientCfg struct {
 	fetchCooldown     time.Duration
 	fetchPollInterval time.Duration
 	jobTimeout        time.Duration
+	keyring           *envelope.Keyring
 	logger            *slog.Logger
 	periodicJobs      []*river.PeriodicJob
 	queues            *map[string]river.QueueConfig
@@ -50,6 +59,14 @@ func WithJobTimeout(timeout time.Duration) ClientCfgOpts {
 	}
 }
 
+// WithKeyring encrypts the sensitive args of inserted jobs, see
+// jobs.EncryptArgs.
+func WithKeyring(keyring *envelope.Keyring) ClientCfgOpts {
+	return func(cfg *clientCfg) {
+		cfg.keyring = keyring
+	}
+}
+
 func WithLogger(logger *slog.Logger) ClientCfgOpts {
 	return func(cfg *clientCfg) {
 		cfg.logger = logger
@@ -74,10 +91,7 @@ func WithWorkers(workers *river.Workers) ClientCfgOpts {
 	}
 }
 
-/*
-NewClient creates a new river.Client. It uses the provided pool to connect to the database. It uses some defaults for error handling, fetch cooldown, fetch poll interval, job timeout, and logger. For a 'read only' client, omit the queue.
-*/
-func NewClient(pool *pgxpool.Pool, opts ...ClientCfgOpts) *river.Client[pgx.Tx] {
+func newClientCfg(opts ...ClientCfgOpts) *clientCfg {
 	cfg := &clientCfg{
 		fetchCooldown:     100 * time.Millisecond,
 		fetchPollInterval: 1 * time.Second,
@@ -89,10 +103,232 @@ func NewClient(pool *pgxpool.Pool, opts ...ClientCfgOpts) *river.Client[pgx.Tx]
 		opt(cfg)
 	}
 
+	return cfg
+}
+
+/*
+Client is a river.Client that carries the trace context of the caller over to
+the jobs it inserts, see InjectTraceContext, encrypts their sensitive args,
+deduplicates inserts of args with an idempotency key, see jobs.Idempotency,
+takes the max attempts from the retry policy of the args, see
+jobs.RetryPolicy, and counts the inserted jobs.
+*/
+type Client struct {
+	*river.Client[pgx.Tx]
+	pool    *pgxpool.Pool
+	keyring *envelope.Keyring
+}
+
+/*
+encryptedArgs stands in for the args of a job when inserting it, so river
+stores the args with their sensitive values sealed while everything else river
+reads from the args, the kind and the insert opts, stays the same.
+*/
+type encryptedArgs struct {
+	river.JobArgs
+	encoded []byte
+}
+
+func (e encryptedArgs) InsertOpts() river.InsertOpts {
+	if provider, ok := e.JobArgs.(insertOptsProvider); ok {
+		return provider.InsertOpts()
+	}
+
+	return river.InsertOpts{}
+}
+
+func (e encryptedArgs) MarshalJSON() ([]byte, error) {
+	return e.encoded, nil
+}
+
+func (c *Client) encrypt(args river.JobArgs) (river.JobArgs, error) {
+	if c.keyring == nil {
+		return args, nil
+	}
+
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := jobs.EncryptArgs(c.keyring, args.Kind(), encoded)
+	if err != nil {
+		return nil, fmt.Errorf("could not encrypt args of %s job: %w", args.Kind(), err)
+	}
+
+	return encryptedArgs{args, encrypted}, nil
+}
+
+// Insert inserts a job. It fails with jobs.ErrDuplicate when the args carry an
+// idempotency key that has been used within its window.
+func (c *Client) Insert(
+	ctx context.Context,
+	args river.JobArgs,
+	opts *river.InsertOpts,
+) (*rivertype.JobRow, error) {
+	if idempotencyOf(args) == nil {
+		return c.insert(ctx, nil, args, opts)
+	}
+
+	// the key has to be claimed in the same transaction as the job is
+	// inserted in, so a failed insert does not leave the key claimed
+	var row *rivertype.JobRow
+	err := pgx.BeginFunc(ctx, c.pool, func(tx pgx.Tx) error {
+		var err error
+		row, err = c.insert(ctx, tx, args, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return row, nil
+}
+
+func (c *Client) InsertTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	args river.JobArgs,
+	opts *river.InsertOpts,
+) (*rivertype.JobRow, error) {
+	return c.insert(ctx, tx, args, opts)
+}
+
+// insert inserts the job in tx, or on its own when tx is nil.
+func (c *Client) insert(
+	ctx context.Context,
+	tx pgx.Tx,
+	args river.JobArgs,
+	opts *river.InsertOpts,
+) (*rivertype.JobRow, error) {
+	idempotency := idempotencyOf(args)
+	if idempotency != nil {
+		if err := claimIdempotencyKey(ctx, tx, idempotency); err != nil {
+			return nil, err
+		}
+	}
+
+	encrypted, err := c.encrypt(args)
+	if err != nil {
+		return nil, err
+	}
+	opts = withRetryPolicy(args, opts)
+	opts = InjectTraceContext(ctx, opts)
+
+	var row *rivertype.JobRow
+	if tx == nil {
+		row, err = c.Client.Insert(ctx, encrypted, opts)
+	} else {
+		row, err = c.Client.InsertTx(ctx, tx, encrypted, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if idempotency != nil {
+		if err := setIdempotencyKeyJob(ctx, tx, idempotency, row.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	jobsInserted.WithLabelValues(row.Kind, row.Queue).Inc()
+
+	return row, nil
+}
+
+// InsertMany inserts the jobs in a batch, leaving out the ones whose
+// idempotency key has been used within its window.
+func (c *Client) InsertMany(
+	ctx context.Context,
+	params []river.InsertManyParams,
+) (int64, error) {
+	if !slices.ContainsFunc(params, func(param river.InsertManyParams) bool {
+		return idempotencyOf(param.Args) != nil
+	}) {
+		return c.insertMany(ctx, nil, params)
+	}
+
+	var inserted int64
+	err := pgx.BeginFunc(ctx, c.pool, func(tx pgx.Tx) error {
+		var err error
+		inserted, err = c.insertMany(ctx, tx, params)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return inserted, nil
+}
+
+func (c *Client) InsertManyTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	params []river.InsertManyParams,
+) (int64, error) {
+	return c.insertMany(ctx, tx, params)
+}
+
+// insertMany inserts the jobs in tx, or on their own when tx is nil.
+func (c *Client) insertMany(
+	ctx context.Context,
+	tx pgx.Tx,
+	params []river.InsertManyParams,
+) (int64, error) {
+	toInsert := make([]river.InsertManyParams, 0, len(params))
+	for _, param := range params {
+		if idempotency := idempotencyOf(param.Args); idempotency != nil {
+			err := claimIdempotencyKey(ctx, tx, idempotency)
+			if errors.Is(err, jobs.ErrDuplicate) {
+				continue
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		args, err := c.encrypt(param.Args)
+		if err != nil {
+			return 0, err
+		}
+
+		toInsert = append(toInsert, river.InsertManyParams{
+			Args:       args,
+			InsertOpts: InjectTraceContext(ctx, withRetryPolicy(param.Args, param.InsertOpts)),
+		})
+	}
+
+	if len(toInsert) == 0 {
+		return 0, nil
+	}
+
+	var inserted int64
+	var err error
+	if tx == nil {
+		inserted, err = c.Client.InsertMany(ctx, toInsert)
+	} else {
+		inserted, err = c.Client.InsertManyTx(ctx, tx, toInsert)
+	}
+	if err != nil {
+		return 0, err
+	}
+	recordInsertMany(toInsert)
+
+	return inserted, nil
+}
+
+/*
+NewClient creates a new river.Client. It uses the provided pool to connect to the database. It uses some defaults for error handling, fetch cooldown, fetch poll interval, job timeout, and logger. For a 'read only' client, omit the queue.
+*/
+func NewClient(pool *pgxpool.Pool, opts ...ClientCfgOpts) *Client {
+	return newClient(pool, newClientCfg(opts...))
+}
+
+func newClient(pool *pgxpool.Pool, cfg *clientCfg) *Client {
 	riverCfg := &river.Config{
 		ErrorHandler:      cfg.errorHandler,
 		FetchCooldown:     cfg.fetchCooldown,
-		FetchPollInterval: cfg.fetchCooldown,
+		FetchPollInterval: cfg.fetchPollInterval,
 		JobTimeout:        cfg.jobTimeout,
 		Logger:            cfg.logger,
 		PeriodicJobs:      cfg.periodicJobs,
@@ -108,5 +344,5 @@ func NewClient(pool *pgxpool.Pool, opts ...ClientCfgOpts) *river.Client[pgx.Tx]
 		panic(err)
 	}
 
-	return riverClient
+	return &Client{riverClient, pool, cfg.keyring}
 }
@@ -0,0 +1,31 @@
+package queue
+
+import (
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+)
+
+/*
+withRetryPolicy sets the max attempts declared by the retry policy of the args,
+see jobs.RetryPolicy, unless the caller has set them in opts. When the job
+fails, the worker schedules the retry from the same policy.
+*/
+func withRetryPolicy(args river.JobArgs, opts *river.InsertOpts) *river.InsertOpts {
+	provider, ok := args.(jobs.RetryPolicyProvider)
+	if !ok || (opts != nil && opts.MaxAttempts != 0) {
+		return opts
+	}
+
+	maxAttempts := provider.RetryPolicy().MaxAttempts
+	if maxAttempts == 0 {
+		return opts
+	}
+
+	withPolicy := river.InsertOpts{}
+	if opts != nil {
+		withPolicy = *opts
+	}
+	withPolicy.MaxAttempts = maxAttempts
+
+	return &withPolicy
+}
@@ -0,0 +1,52 @@
+package queue_test
+
+import (
+	"testing"
+
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRetryPolicy(t *testing.T) {
+	tests := map[string]struct {
+		args                river.JobArgs
+		opts                *river.InsertOpts
+		expectedMaxAttempts int
+	}{
+		"should take the max attempts from the policy": {
+			args:                jobs.EmailJobArgs{},
+			expectedMaxAttempts: 10,
+		},
+		"should keep the other opts": {
+			args:                jobs.EmailJobArgs{},
+			opts:                &river.InsertOpts{Queue: "emails"},
+			expectedMaxAttempts: 10,
+		},
+		"should prefer max attempts set by the caller": {
+			args:                jobs.EmailJobArgs{},
+			opts:                &river.InsertOpts{MaxAttempts: 2},
+			expectedMaxAttempts: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts := queue.WithRetryPolicy(test.args, test.opts)
+
+			assert.Equal(t, test.expectedMaxAttempts, opts.MaxAttempts)
+			if test.opts != nil {
+				assert.Equal(t, test.opts.Queue, opts.Queue)
+			}
+		})
+	}
+}
+
+func TestWithRetryPolicyWithoutPolicy(t *testing.T) {
+	assert.Nil(t, queue.WithRetryPolicy(noPolicyArgs{}, nil))
+}
+
+type noPolicyArgs struct{}
+
+func (noPolicyArgs) Kind() string { return "no_policy" }
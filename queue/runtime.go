@@ -0,0 +1,395 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/riverqueue/river"
+)
+
+var (
+	ErrUnexpectedStop  = errors.New("river client stopped without being asked to")
+	ErrHardStopTimeout = errors.New("jobs did not return after being cancelled")
+)
+
+// river elects a single leader per database under this name, and only the
+// leader enqueues periodic jobs
+const (
+	leaderName         = "default"
+	leaderPollInterval = 5 * time.Second
+)
+
+type State string
+
+const (
+	StateStarting State = "starting"
+	StateRunning  State = "running"
+	StateStopping State = "stopping"
+	StateStopped  State = "stopped"
+)
+
+type Leader struct {
+	LeaderID  string    `json:"leader_id"`
+	ElectedAt time.Time `json:"elected_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type Status struct {
+	State        State          `json:"state"`
+	StartedAt    *time.Time     `json:"started_at,omitempty"`
+	Queues       map[string]int `json:"queues"`
+	PeriodicJobs int            `json:"periodic_jobs"`
+	Leader       *Leader        `json:"leader"`
+}
+
+/*
+Runtime runs a river client that works jobs for the lifetime of a context.
+Cancelling the context starts a soft stop where no new jobs are fetched but
+running jobs are given WorkerSoftStopTimeout to finish. After that, or when
+ForceStop is called, running jobs have their context cancelled and are given
+WorkerHardStopTimeout to return.
+
+Handler exposes liveness, readiness and status endpoints for the process
+supervisor, along with the queue and worker metrics.
+*/
+type Runtime struct {
+	client          *Client
+	pool            *pgxpool.Pool
+	db              *database.Queries
+	queues          map[string]river.QueueConfig
+	periodicJobs    int
+	softStopTimeout time.Duration
+	hardStopTimeout time.Duration
+	forceStop       chan struct{}
+	forceStopOnce   sync.Once
+
+	mu        sync.RWMutex
+	state     State
+	startedAt time.Time
+	leader    *Leader
+}
+
+// Queues turns the queue to concurrency mapping from config into river
+// queue configs.
+func Queues(concurrency map[string]int) map[string]river.QueueConfig {
+	queues := make(map[string]river.QueueConfig, len(concurrency))
+	for name, maxWorkers := range concurrency {
+		queues[name] = river.QueueConfig{MaxWorkers: maxWorkers}
+	}
+
+	return queues
+}
+
+/*
+NewRuntime creates a Runtime that works the queues from cfg. The opts are
+passed on to the underlying client, so workers and periodic jobs are added
+with WithWorkers and WithPeriodicJobs.
+*/
+func NewRuntime(
+	pool *pgxpool.Pool,
+	cfg config.Worker,
+	opts ...ClientCfgOpts,
+) *Runtime {
+	clientOpts := append(
+		[]ClientCfgOpts{WithQueues(Queues(cfg.WorkerQueues))},
+		opts...,
+	)
+	clientCfg := newClientCfg(clientOpts...)
+
+	return &Runtime{
+		client:          newClient(pool, clientCfg),
+		pool:            pool,
+		db:              database.New(pool),
+		queues:          *clientCfg.queues,
+		periodicJobs:    len(clientCfg.periodicJobs),
+		softStopTimeout: cfg.WorkerSoftStopTimeout,
+		hardStopTimeout: cfg.WorkerHardStopTimeout,
+		forceStop:       make(chan struct{}),
+		state:           StateStarting,
+	}
+}
+
+// Client returns the underlying river client, e.g. to insert jobs when the
+// workers are embedded in the app.
+func (r *Runtime) Client() *Client {
+	return r.client
+}
+
+/*
+Run starts working jobs and blocks until ctx is done and the client has
+stopped. It returns ErrUnexpectedStop if the client stops on its own.
+*/
+func (r *Runtime) Run(ctx context.Context) error {
+	r.setState(StateStarting)
+
+	events, unsubscribe := r.client.Subscribe(jobEventKinds...)
+	defer unsubscribe()
+	go recordJobEvents(events)
+
+	// the client is started with a context that is never cancelled as
+	// cancelling it would cancel every running job right away, skipping the
+	// soft stop
+	if err := r.client.Start(context.WithoutCancel(ctx)); err != nil {
+		r.setState(StateStopped)
+		return err
+	}
+
+	r.mu.Lock()
+	r.state = StateRunning
+	r.startedAt = time.Now()
+	r.mu.Unlock()
+
+	slog.InfoContext(
+		ctx,
+		"worker started",
+		"queues",
+		r.queueConcurrency(),
+		"periodic_jobs",
+		r.periodicJobs,
+	)
+
+	go r.watchLeader(ctx)
+	go sampleQueues(ctx, r.db)
+
+	select {
+	case <-ctx.Done():
+		return r.stop()
+	case <-r.client.Stopped():
+		r.setState(StateStopped)
+		return ErrUnexpectedStop
+	}
+}
+
+// ForceStop skips the remainder of an ongoing soft stop and cancels running
+// jobs, e.g. when a second interrupt is received.
+func (r *Runtime) ForceStop() {
+	r.forceStopOnce.Do(func() {
+		close(r.forceStop)
+	})
+}
+
+func (r *Runtime) stop() error {
+	r.setState(StateStopping)
+	defer r.setState(StateStopped)
+
+	slog.Info(
+		"initiating soft stop, waiting for running jobs to finish",
+		"timeout",
+		r.softStopTimeout,
+	)
+
+	softStopCtx, softStopCancel := context.WithTimeout(
+		context.Background(),
+		r.softStopTimeout,
+	)
+	defer softStopCancel()
+
+	go func() {
+		select {
+		case <-r.forceStop:
+			softStopCancel()
+		case <-softStopCtx.Done():
+		}
+	}()
+
+	err := r.client.Stop(softStopCtx)
+	if err == nil {
+		slog.Info("soft stop succeeded")
+		return nil
+	}
+	if !errors.Is(err, context.DeadlineExceeded) &&
+		!errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	slog.Warn(
+		"initiating hard stop, cancelling running jobs",
+		"timeout",
+		r.hardStopTimeout,
+	)
+
+	hardStopCtx, hardStopCancel := context.WithTimeout(
+		context.Background(),
+		r.hardStopTimeout,
+	)
+	defer hardStopCancel()
+
+	// as long as jobs respect context cancellation this returns in time; if
+	// one does not there is nothing left to do but give up on it
+	if err := r.client.StopAndCancel(hardStopCtx); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return ErrHardStopTimeout
+		}
+
+		return err
+	}
+
+	slog.Info("hard stop succeeded")
+
+	return nil
+}
+
+/*
+watchLeader keeps track of which client is the elected leader. Only the leader
+enqueues periodic jobs, so a worker with periodic jobs logs when leadership
+changes and the current leader is part of the status.
+*/
+func (r *Runtime) watchLeader(ctx context.Context) {
+	ticker := time.NewTicker(leaderPollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.refreshLeader(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runtime) refreshLeader(ctx context.Context) {
+	var leader *Leader
+
+	row, err := r.db.QueryRiverLeader(ctx, leaderName)
+	switch {
+	case err == nil:
+		leader = &Leader{
+			LeaderID:  row.LeaderID,
+			ElectedAt: row.ElectedAt.Time,
+			ExpiresAt: row.ExpiresAt.Time,
+		}
+	case errors.Is(err, pgx.ErrNoRows):
+	case ctx.Err() != nil:
+		return
+	default:
+		slog.ErrorContext(ctx, "could not query river leader", "error", err)
+		return
+	}
+
+	r.mu.Lock()
+	previous := r.leader
+	r.leader = leader
+	r.mu.Unlock()
+
+	if r.periodicJobs == 0 {
+		return
+	}
+
+	switch {
+	case leader == nil && previous != nil:
+		slog.WarnContext(
+			ctx,
+			"no river leader elected, periodic jobs are not enqueued until one is",
+		)
+	case leader != nil && (previous == nil || previous.LeaderID != leader.LeaderID):
+		slog.InfoContext(
+			ctx,
+			"river leader elected, periodic jobs are enqueued by the leader",
+			"leader_id",
+			leader.LeaderID,
+		)
+	}
+}
+
+func (r *Runtime) setState(state State) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.state = state
+}
+
+func (r *Runtime) queueConcurrency() map[string]int {
+	concurrency := make(map[string]int, len(r.queues))
+	for name, queue := range r.queues {
+		concurrency[name] = queue.MaxWorkers
+	}
+
+	return concurrency
+}
+
+func (r *Runtime) Status() Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	status := Status{
+		State:        r.state,
+		Queues:       r.queueConcurrency(),
+		PeriodicJobs: r.periodicJobs,
+		Leader:       r.leader,
+	}
+	if !r.startedAt.IsZero() {
+		startedAt := r.startedAt
+		status.StartedAt = &startedAt
+	}
+
+	return status
+}
+
+// HealthServer returns a server of Handler on addr, for the probes of the
+// process that runs the worker.
+func (r *Runtime) HealthServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:         addr,
+		Handler:      r.Handler(),
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+	}
+}
+
+/*
+Handler serves:
+  - /healthz, ok for as long as the process has not stopped working
+  - /readyz, ok while jobs are being worked and the database is reachable
+  - /status, the Status as json
+  - /metrics, the prometheus metrics of the process
+*/
+func (r *Runtime) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, req *http.Request) {
+		if r.Status().State == StateStopped {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, req *http.Request) {
+		if r.Status().State != StateRunning {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if err := r.pool.Ping(req.Context()); err != nil {
+			slog.ErrorContext(req.Context(), "readiness check could not reach database", "error", err)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(r.Status()); err != nil {
+			slog.ErrorContext(req.Context(), "could not encode worker status", "error", err)
+		}
+	})
+
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	return mux
+}
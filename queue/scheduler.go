@@ -0,0 +1,239 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mbvlabs/grafto/config"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	ErrUnknownSchedule   = errors.New("no schedule with that name")
+	ErrDuplicateSchedule = errors.New("schedule name is used more than once")
+)
+
+const (
+	// scheduleKey is the key in river_job.metadata that holds the name of the
+	// schedule that enqueued the job
+	scheduleKey = "schedule"
+	// manualKey marks jobs enqueued by Scheduler.Trigger rather than on
+	// schedule
+	manualKey = "manual"
+)
+
+// Schedule declares a job the leader enqueues on a schedule.
+type Schedule struct {
+	// Name identifies the schedule in config, the admin and the scheduler
+	// command, so it should not change between deploys
+	Name string
+	// Spec is a cron expression like "0 3 * * *", a descriptor like "@daily"
+	// or an interval like "@every 1h"
+	Spec string
+	Args river.JobArgs
+	Opts *river.InsertOpts
+	// RunOnStart enqueues the job when a new leader is elected, for jobs
+	// whose interval is long enough for a restart to skip a run
+	RunOnStart bool
+}
+
+// ScheduledJob is a Schedule with its spec parsed and config applied.
+type ScheduledJob struct {
+	Schedule
+	// Overridden reports whether Spec comes from config.Scheduler rather
+	// than from code
+	Overridden bool
+	// Disabled reports whether the schedule is disabled in
+	// config.Scheduler. Disabling it in the admin is checked by the worker.
+	Disabled bool
+
+	schedule cron.Schedule
+}
+
+// Next returns the first time after the given one the job is enqueued.
+func (s ScheduledJob) Next(after time.Time) time.Time {
+	return s.schedule.Next(after)
+}
+
+/*
+NextRun estimates when the job is enqueued next from when it last ran. River
+does not expose when its enqueuer plans the next run, and intervals count from
+when the leader started, so for those this is the interval after the last run
+or, without one, after now.
+*/
+func (s ScheduledJob) NextRun(lastRun time.Time, now time.Time) time.Time {
+	if !lastRun.IsZero() {
+		if next := s.Next(lastRun); next.After(now) {
+			return next
+		}
+	}
+
+	return s.Next(now)
+}
+
+/*
+Scheduler holds the declared schedules with the overrides and disabled
+schedules from config applied. Jobs are enqueued by river's periodic job
+enqueuer, which inserts them without going through Client, so the args of
+scheduled jobs must not carry sensitive values.
+*/
+type Scheduler struct {
+	jobs []ScheduledJob
+}
+
+func NewScheduler(schedules []Schedule, cfg config.Scheduler) (*Scheduler, error) {
+	scheduler := &Scheduler{jobs: make([]ScheduledJob, 0, len(schedules))}
+
+	for _, schedule := range schedules {
+		if slices.ContainsFunc(scheduler.jobs, func(job ScheduledJob) bool {
+			return job.Name == schedule.Name
+		}) {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateSchedule, schedule.Name)
+		}
+
+		job := ScheduledJob{
+			Schedule: schedule,
+			Disabled: slices.Contains(cfg.DisabledSchedules, schedule.Name),
+		}
+		if spec, ok := cfg.ScheduleOverrides[schedule.Name]; ok {
+			job.Spec = spec
+			job.Overridden = true
+		}
+
+		parsed, err := cron.ParseStandard(job.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse schedule %s %q: %w", job.Name, job.Spec, err)
+		}
+		job.schedule = parsed
+
+		scheduler.jobs = append(scheduler.jobs, job)
+	}
+
+	// catch typos in config, which would otherwise leave a job running that
+	// was meant to be changed or disabled
+	for name := range cfg.ScheduleOverrides {
+		if _, err := scheduler.Job(name); err != nil {
+			return nil, fmt.Errorf("could not override schedule %s: %w", name, err)
+		}
+	}
+	for _, name := range cfg.DisabledSchedules {
+		if _, err := scheduler.Job(name); err != nil {
+			return nil, fmt.Errorf("could not disable schedule %s: %w", name, err)
+		}
+	}
+
+	return scheduler, nil
+}
+
+// Jobs returns the schedules in the order they were declared.
+func (s *Scheduler) Jobs() []ScheduledJob {
+	return slices.Clone(s.jobs)
+}
+
+func (s *Scheduler) Job(name string) (ScheduledJob, error) {
+	for _, job := range s.jobs {
+		if job.Name == name {
+			return job, nil
+		}
+	}
+
+	return ScheduledJob{}, ErrUnknownSchedule
+}
+
+// PeriodicJobs returns the schedules that are not disabled in config, for use
+// with WithPeriodicJobs.
+func (s *Scheduler) PeriodicJobs() ([]*river.PeriodicJob, error) {
+	periodicJobs := make([]*river.PeriodicJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		if job.Disabled {
+			continue
+		}
+
+		opts, err := withSchedule(job.Args, job.Opts, job.Name, false)
+		if err != nil {
+			return nil, err
+		}
+
+		args := job.Args
+		periodicJobs = append(periodicJobs, river.NewPeriodicJob(
+			job.schedule,
+			func() (river.JobArgs, *river.InsertOpts) {
+				// river may hold on to the opts, so every run gets its own
+				optsCopy := *opts
+				return args, &optsCopy
+			},
+			&river.PeriodicJobOpts{RunOnStart: job.RunOnStart},
+		))
+	}
+
+	return periodicJobs, nil
+}
+
+// Trigger enqueues the job of the named schedule right away. It runs even
+// when the schedule is disabled.
+func (s *Scheduler) Trigger(
+	ctx context.Context,
+	client *Client,
+	name string,
+) (*rivertype.JobRow, error) {
+	job, err := s.Job(name)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := withSchedule(job.Args, job.Opts, job.Name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Insert(ctx, job.Args, opts)
+}
+
+// withSchedule returns a copy of opts with the retry policy of the args and
+// the name of the schedule added to the job metadata.
+func withSchedule(
+	args river.JobArgs,
+	opts *river.InsertOpts,
+	name string,
+	manual bool,
+) (*river.InsertOpts, error) {
+	withName := river.InsertOpts{}
+	if withPolicy := withRetryPolicy(args, opts); withPolicy != nil {
+		withName = *withPolicy
+	}
+
+	metadata, err := mergeMetadata(
+		withName.Metadata,
+		map[string]any{scheduleKey: name, manualKey: manual},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not add schedule %s to job metadata: %w", name, err)
+	}
+	withName.Metadata = metadata
+
+	return &withName, nil
+}
+
+// ScheduleOf returns the name of the schedule that enqueued the job, if any,
+// and whether it was triggered manually.
+func ScheduleOf(metadata []byte) (string, bool) {
+	if len(metadata) == 0 {
+		return "", false
+	}
+
+	var stored struct {
+		Schedule string `json:"schedule"`
+		Manual   bool   `json:"manual"`
+	}
+	if err := json.Unmarshal(metadata, &stored); err != nil {
+		return "", false
+	}
+
+	return stored.Schedule, stored.Manual
+}
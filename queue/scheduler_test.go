@@ -0,0 +1,180 @@
+package queue_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSchedules() []queue.Schedule {
+	return []queue.Schedule{
+		{
+			Name: "purge",
+			Spec: "@every 1h",
+			Args: jobs.PurgeIdempotencyKeysJobArgs{},
+		},
+		{
+			Name: "nightly",
+			Spec: "0 3 * * *",
+			Args: jobs.CheckDeadLetterRateJobArgs{},
+		},
+	}
+}
+
+func TestNewScheduler(t *testing.T) {
+	tests := map[string]struct {
+		schedules        []queue.Schedule
+		cfg              config.Scheduler
+		expectedErr      error
+		expectedSpecs    map[string]string
+		expectedDisabled []string
+		expectedPeriodic int
+	}{
+		"should use the specs declared in code": {
+			schedules:        testSchedules(),
+			expectedSpecs:    map[string]string{"purge": "@every 1h", "nightly": "0 3 * * *"},
+			expectedPeriodic: 2,
+		},
+		"should override specs from config": {
+			schedules: testSchedules(),
+			cfg: config.Scheduler{
+				ScheduleOverrides: map[string]string{"nightly": "30 4 * * 1"},
+			},
+			expectedSpecs:    map[string]string{"purge": "@every 1h", "nightly": "30 4 * * 1"},
+			expectedPeriodic: 2,
+		},
+		"should leave out schedules disabled in config": {
+			schedules:        testSchedules(),
+			cfg:              config.Scheduler{DisabledSchedules: []string{"purge"}},
+			expectedSpecs:    map[string]string{"purge": "@every 1h", "nightly": "0 3 * * *"},
+			expectedDisabled: []string{"purge"},
+			expectedPeriodic: 1,
+		},
+		"should reject overrides of unknown schedules": {
+			schedules: testSchedules(),
+			cfg: config.Scheduler{
+				ScheduleOverrides: map[string]string{"nigthly": "30 4 * * *"},
+			},
+			expectedErr: queue.ErrUnknownSchedule,
+		},
+		"should reject disabling unknown schedules": {
+			schedules:   testSchedules(),
+			cfg:         config.Scheduler{DisabledSchedules: []string{"purg"}},
+			expectedErr: queue.ErrUnknownSchedule,
+		},
+		"should reject duplicate names": {
+			schedules:   append(testSchedules(), testSchedules()[0]),
+			expectedErr: queue.ErrDuplicateSchedule,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			scheduler, err := queue.NewScheduler(test.schedules, test.cfg)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			for _, job := range scheduler.Jobs() {
+				assert.Equal(t, test.expectedSpecs[job.Name], job.Spec)
+				_, overridden := test.cfg.ScheduleOverrides[job.Name]
+				assert.Equal(t, overridden, job.Overridden)
+				assert.Equal(t, slices.Contains(test.expectedDisabled, job.Name), job.Disabled)
+			}
+
+			periodicJobs, err := scheduler.PeriodicJobs()
+			assert.NoError(t, err)
+			assert.Len(t, periodicJobs, test.expectedPeriodic)
+		})
+	}
+}
+
+func TestNewSchedulerRejectsInvalidSpec(t *testing.T) {
+	_, err := queue.NewScheduler(testSchedules(), config.Scheduler{
+		ScheduleOverrides: map[string]string{"nightly": "every night"},
+	})
+
+	assert.ErrorContains(t, err, "nightly")
+}
+
+func TestScheduledJobNextRun(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+
+	scheduler, err := queue.NewScheduler(testSchedules(), config.Scheduler{})
+	assert.NoError(t, err)
+
+	purge, err := scheduler.Job("purge")
+	assert.NoError(t, err)
+	nightly, err := scheduler.Job("nightly")
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		job      queue.ScheduledJob
+		lastRun  time.Time
+		expected time.Time
+	}{
+		"should count an interval from the last run": {
+			job:      purge,
+			lastRun:  now.Add(-20 * time.Minute),
+			expected: now.Add(40 * time.Minute),
+		},
+		"should count an interval from now when it has not run": {
+			job:      purge,
+			expected: now.Add(time.Hour),
+		},
+		"should count an interval from now when a run was missed": {
+			job:      purge,
+			lastRun:  now.Add(-3 * time.Hour),
+			expected: now.Add(time.Hour),
+		},
+		"should use the next slot of a cron expression": {
+			job:      nightly,
+			lastRun:  time.Date(2026, 10, 19, 3, 0, 0, 0, time.UTC),
+			expected: time.Date(2026, 10, 20, 3, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.job.NextRun(test.lastRun, now))
+		})
+	}
+}
+
+func TestWithSchedule(t *testing.T) {
+	tests := map[string]struct {
+		opts   *river.InsertOpts
+		manual bool
+	}{
+		"should name the schedule of scheduled jobs": {},
+		"should mark jobs that are triggered manually": {
+			manual: true,
+		},
+		"should keep metadata already set": {
+			opts: &river.InsertOpts{Metadata: []byte(`{"trace_context":{}}`)},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			opts, err := queue.WithSchedule(jobs.PurgeIdempotencyKeysJobArgs{}, test.opts, "purge", test.manual)
+			assert.NoError(t, err)
+
+			schedule, manual := queue.ScheduleOf(opts.Metadata)
+			assert.Equal(t, "purge", schedule)
+			assert.Equal(t, test.manual, manual)
+			assert.Equal(t, 3, opts.MaxAttempts)
+			if test.opts != nil {
+				assert.Contains(t, string(opts.Metadata), "trace_context")
+			}
+		})
+	}
+}
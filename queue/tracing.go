@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"github.com/riverqueue/river"
+)
+
+// traceContextKey is the key in river_job.metadata that holds the trace
+// context of the request that inserted the job
+const traceContextKey = "trace_context"
+
+/*
+InjectTraceContext returns a copy of opts with the trace context of ctx added
+to the job metadata, keeping any metadata already set. opts is returned as is
+when ctx carries no span.
+*/
+func InjectTraceContext(
+	ctx context.Context,
+	opts *river.InsertOpts,
+) *river.InsertOpts {
+	carrier := telemetry.Inject(ctx)
+	if len(carrier) == 0 {
+		return opts
+	}
+
+	var withTrace river.InsertOpts
+	if opts != nil {
+		withTrace = *opts
+	}
+
+	metadata, err := mergeMetadata(
+		withTrace.Metadata,
+		map[string]any{traceContextKey: carrier},
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not add trace context to job metadata", "error", err)
+		return opts
+	}
+	withTrace.Metadata = metadata
+
+	return &withTrace
+}
+
+// mergeMetadata adds values to the json encoded job metadata, keeping what is
+// already there.
+func mergeMetadata(metadata []byte, values map[string]any) ([]byte, error) {
+	merged := map[string]any{}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &merged); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, value := range values {
+		merged[key] = value
+	}
+
+	return json.Marshal(merged)
+}
+
+// ExtractTraceContext returns ctx with the trace context stored in the job
+// metadata by InjectTraceContext, if any.
+func ExtractTraceContext(ctx context.Context, metadata []byte) context.Context {
+	if len(metadata) == 0 {
+		return ctx
+	}
+
+	var stored struct {
+		TraceContext map[string]string `json:"trace_context"`
+	}
+	if err := json.Unmarshal(metadata, &stored); err != nil {
+		return ctx
+	}
+
+	return telemetry.Extract(ctx, stored.TraceContext)
+}
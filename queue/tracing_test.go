@@ -0,0 +1,58 @@
+package queue_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/riverqueue/river"
+	"github.com/stretchr/testify/assert"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceContextRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		opts             *river.InsertOpts
+		expectedMetadata map[string]any
+	}{
+		"should add trace context when opts are nil": {
+			opts:             nil,
+			expectedMetadata: map[string]any{},
+		},
+		"should keep existing metadata": {
+			opts:             &river.InsertOpts{Metadata: []byte(`{"foo":"bar"}`)},
+			expectedMetadata: map[string]any{"foo": "bar"},
+		},
+	}
+
+	provider := tracesdk.NewTracerProvider()
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+			defer span.End()
+
+			opts := queue.InjectTraceContext(ctx, test.opts)
+
+			var metadata map[string]any
+			assert.NoError(t, json.Unmarshal(opts.Metadata, &metadata))
+			for key, value := range test.expectedMetadata {
+				assert.Equal(t, value, metadata[key])
+			}
+
+			extracted := trace.SpanContextFromContext(
+				queue.ExtractTraceContext(context.Background(), opts.Metadata),
+			)
+			assert.True(t, extracted.IsRemote())
+			assert.Equal(t, span.SpanContext().TraceID(), extracted.TraceID())
+			assert.Equal(t, span.SpanContext().SpanID(), extracted.SpanID())
+		})
+	}
+}
+
+func TestInjectTraceContextWithoutSpan(t *testing.T) {
+	opts := &river.InsertOpts{Queue: "default"}
+
+	assert.Same(t, opts, queue.InjectTraceContext(context.Background(), opts))
+}
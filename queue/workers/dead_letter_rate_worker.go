@@ -0,0 +1,136 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/mbvlabs/grafto/services"
+	"github.com/riverqueue/river"
+)
+
+var ErrAlertWebhook = errors.New("alert webhook did not accept the alert")
+
+type CheckDeadLetterRateJobWorker struct {
+	db      *database.Queries
+	emailer services.EmailClient
+	client  *http.Client
+	cfg     config.Alerting
+	river.WorkerDefaults[jobs.CheckDeadLetterRateJobArgs]
+}
+
+type deadLetterAlert struct {
+	Text         string  `json:"text"`
+	DeadLettered int64   `json:"dead_lettered"`
+	Finalized    int64   `json:"finalized"`
+	Rate         float64 `json:"rate"`
+	Threshold    float64 `json:"threshold"`
+	Window       string  `json:"window"`
+}
+
+func (w *CheckDeadLetterRateJobWorker) Work(
+	ctx context.Context,
+	job *river.Job[jobs.CheckDeadLetterRateJobArgs],
+) error {
+	rate, err := w.db.QueryDeadLetterRate(ctx, pgtype.Timestamptz{
+		Time:  time.Now().Add(-w.cfg.DeadLetterAlertWindow),
+		Valid: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	if rate.Finalized == 0 || rate.Finalized < w.cfg.DeadLetterAlertMinJobs {
+		return nil
+	}
+
+	share := float64(rate.DeadLettered) / float64(rate.Finalized)
+	if share <= w.cfg.DeadLetterAlertThreshold {
+		return nil
+	}
+
+	alert := deadLetterAlert{
+		Text: fmt.Sprintf(
+			"%d of %d jobs (%.1f%%) finalized in the last %s were dead-lettered, above the %.1f%% threshold. Review them at /admin/dead-letters.",
+			rate.DeadLettered,
+			rate.Finalized,
+			share*100,
+			w.cfg.DeadLetterAlertWindow,
+			w.cfg.DeadLetterAlertThreshold*100,
+		),
+		DeadLettered: rate.DeadLettered,
+		Finalized:    rate.Finalized,
+		Rate:         share,
+		Threshold:    w.cfg.DeadLetterAlertThreshold,
+		Window:       w.cfg.DeadLetterAlertWindow.String(),
+	}
+
+	slog.WarnContext(
+		ctx,
+		"dead-letter rate above threshold",
+		"rate",
+		share,
+		"dead_lettered",
+		rate.DeadLettered,
+		"finalized",
+		rate.Finalized,
+	)
+
+	// one channel failing should not keep the alert from the other
+	var errs []error
+	if w.cfg.AlertWebhookURL != "" {
+		errs = append(errs, w.postWebhook(ctx, alert))
+	}
+	if w.cfg.AlertEmail != "" {
+		errs = append(errs, w.emailer.SendEmail(ctx, services.EmailPayload{
+			To:       []string{w.cfg.AlertEmail},
+			Subject:  "Dead-letter rate above threshold",
+			HtmlBody: "<p>" + alert.Text + "</p>",
+			TextBody: alert.Text,
+		}))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (w *CheckDeadLetterRateJobWorker) postWebhook(
+	ctx context.Context,
+	alert deadLetterAlert,
+) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		w.cfg.AlertWebhookURL,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: status %d", ErrAlertWebhook, res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+)
+
+type PurgeDeletedUsersJobWorker struct {
+	db *database.Queries
+	// retention is how long deleted users can be restored, see config.Users
+	retention time.Duration
+	river.WorkerDefaults[jobs.PurgeDeletedUsersJobArgs]
+}
+
+func (w *PurgeDeletedUsersJobWorker) Work(
+	ctx context.Context,
+	job *river.Job[jobs.PurgeDeletedUsersJobArgs],
+) error {
+	purged, err := w.db.PurgeDeletedUsers(ctx, pgtype.Timestamptz{
+		Time:  time.Now().Add(-w.retention),
+		Valid: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "purged deleted users", "count", purged)
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+)
+
+// orphanedAttachmentGrace keeps attachments without a job around for longer
+// than an email job retries, as the ones stored before attachments recorded
+// their job may still be sent.
+const orphanedAttachmentGrace = 7 * 24 * time.Hour
+
+type PurgeEmailAttachmentsJobWorker struct {
+	db *database.Queries
+	river.WorkerDefaults[jobs.PurgeEmailAttachmentsJobArgs]
+}
+
+func (w *PurgeEmailAttachmentsJobWorker) Work(
+	ctx context.Context,
+	job *river.Job[jobs.PurgeEmailAttachmentsJobArgs],
+) error {
+	purged, err := w.db.DeleteOrphanedEmailAttachments(ctx, pgtype.Timestamptz{
+		Time:  time.Now().Add(-orphanedAttachmentGrace),
+		Valid: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "purged orphaned email attachments", "count", purged)
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+)
+
+const (
+	emailRateLimitName = "email"
+	// a job waits this long for its share of the send rate before it is
+	// snoozed, handing its worker to the next job
+	maxEmailRateLimitWait = 10 * time.Second
+	emailRateLimitSnooze  = 5 * time.Second
+	minEmailRatePoll      = 50 * time.Millisecond
+	maxEmailRatePoll      = time.Second
+)
+
+type rateLimitStorage interface {
+	TakeRateLimitTokens(ctx context.Context, arg database.TakeRateLimitTokensParams) (float64, error)
+}
+
+/*
+emailRateLimiter is a token bucket kept in the rate_limits table, so every
+worker instance sends from the same budget. Bulk mail leaves a reserve of the
+bucket for transactional mail, so transactional mail can go out while a
+newsletter is using up the rest of the send rate.
+*/
+type emailRateLimiter struct {
+	storage rateLimitStorage
+	rate    float64
+	burst   float64
+	reserve float64
+	maxWait time.Duration
+}
+
+func newEmailRateLimiter(storage rateLimitStorage, cfg config.Email) *emailRateLimiter {
+	return &emailRateLimiter{
+		storage: storage,
+		rate:    cfg.EmailRateLimit,
+		burst:   max(cfg.EmailRateBurst, 1),
+		reserve: max(cfg.EmailRateBurst, 1) * cfg.EmailTransactionalReserve,
+		maxWait: maxEmailRateLimitWait,
+	}
+}
+
+// take reports whether the messages were taken from the bucket.
+func (l *emailRateLimiter) take(
+	ctx context.Context,
+	lane jobs.EmailLane,
+	messages int,
+) (bool, error) {
+	// an email to more recipients than the bucket holds would never fit, so
+	// it waits for a full bucket instead
+	cost := min(float64(max(messages, 1)), l.burst)
+
+	var reserve float64
+	if lane == jobs.EmailLaneBulk {
+		reserve = min(l.reserve, l.burst-cost)
+	}
+
+	_, err := l.storage.TakeRateLimitTokens(ctx, database.TakeRateLimitTokensParams{
+		Name:    emailRateLimitName,
+		Burst:   l.burst,
+		Cost:    cost,
+		Rate:    l.rate,
+		Reserve: reserve,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// wait takes the messages from the bucket, polling until they fit or maxWait
+// has passed.
+func (l *emailRateLimiter) wait(
+	ctx context.Context,
+	lane jobs.EmailLane,
+	messages int,
+) (bool, error) {
+	// polling about as often as a message fits in the bucket keeps the
+	// waiting workers from hammering the table
+	poll := maxEmailRatePoll
+	if l.rate > 0 {
+		poll = min(max(time.Duration(float64(time.Second)/l.rate), minEmailRatePoll), maxEmailRatePoll)
+	}
+	deadline := time.Now().Add(l.maxWait)
+
+	for {
+		taken, err := l.take(ctx, lane, messages)
+		if err != nil || taken {
+			return taken, err
+		}
+
+		if time.Now().Add(poll).After(deadline) {
+			return false, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+}
+
+type throttledEmailArgs interface {
+	river.JobArgs
+	jobs.ThrottledEmail
+}
+
+// rateLimited sends email only once it fits in the send rate, see
+// emailRateLimiter.
+type rateLimited[T throttledEmailArgs] struct {
+	river.Worker[T]
+	limiter *emailRateLimiter
+}
+
+func withEmailRateLimit[T throttledEmailArgs](
+	worker river.Worker[T],
+	limiter *emailRateLimiter,
+) river.Worker[T] {
+	return &rateLimited[T]{worker, limiter}
+}
+
+func (r *rateLimited[T]) Work(ctx context.Context, job *river.Job[T]) error {
+	lane := job.Args.EmailLane()
+
+	taken, err := r.limiter.wait(ctx, lane, job.Args.RecipientCount())
+	if err != nil {
+		slog.ErrorContext(ctx, "could not take from email rate limit", "error", err, "lane", lane)
+		return err
+	}
+
+	// snoozing does not use up an attempt
+	if !taken {
+		return river.JobSnooze(emailRateLimitSnooze)
+	}
+
+	return r.Worker.Work(ctx, job)
+}
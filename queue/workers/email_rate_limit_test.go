@@ -0,0 +1,116 @@
+package workers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/mbvlabs/grafto/queue/workers"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingWorker[T river.JobArgs] struct {
+	calls int
+	river.WorkerDefaults[T]
+}
+
+func (w *countingWorker[T]) Work(ctx context.Context, job *river.Job[T]) error {
+	w.calls++
+	return nil
+}
+
+func TestEmailRateLimit(t *testing.T) {
+	cfg := config.Email{
+		EmailRateLimit:            10,
+		EmailRateBurst:            10,
+		EmailTransactionalReserve: 0.2,
+	}
+
+	tests := map[string]struct {
+		args            jobs.EmailJobArgs
+		expectedCost    float64
+		expectedReserve float64
+	}{
+		"should let transactional mail use the whole bucket": {
+			args:            jobs.EmailJobArgs{To: jobs.Recipients{"user@grafto.com"}},
+			expectedCost:    1,
+			expectedReserve: 0,
+		},
+		"should leave a reserve for transactional mail when sending bulk": {
+			args: jobs.EmailJobArgs{
+				To:   jobs.Recipients{"user@grafto.com"},
+				Lane: jobs.EmailLaneBulk,
+			},
+			expectedCost:    1,
+			expectedReserve: 2,
+		},
+		"should count every recipient": {
+			args: jobs.EmailJobArgs{
+				To:  jobs.Recipients{"user@grafto.com"},
+				Cc:  []string{"cc@grafto.com"},
+				Bcc: []string{"bcc@grafto.com"},
+			},
+			expectedCost:    3,
+			expectedReserve: 0,
+		},
+		"should cap the cost at the size of the bucket": {
+			args: jobs.EmailJobArgs{
+				To:   make(jobs.Recipients, 25),
+				Lane: jobs.EmailLaneBulk,
+			},
+			expectedCost:    10,
+			expectedReserve: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var taken []database.TakeRateLimitTokensParams
+			inner := &countingWorker[jobs.EmailJobArgs]{}
+			worker := workers.WithEmailRateLimit[jobs.EmailJobArgs](
+				inner,
+				func(ctx context.Context, arg database.TakeRateLimitTokensParams) (float64, error) {
+					taken = append(taken, arg)
+					return 0, nil
+				},
+				cfg,
+			)
+
+			err := worker.Work(context.Background(), &river.Job[jobs.EmailJobArgs]{
+				JobRow: &rivertype.JobRow{ID: 1},
+				Args:   test.args,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, 1, inner.calls)
+			assert.Len(t, taken, 1)
+			assert.Equal(t, test.expectedCost, taken[0].Cost)
+			assert.Equal(t, test.expectedReserve, taken[0].Reserve)
+			assert.Equal(t, cfg.EmailRateLimit, taken[0].Rate)
+		})
+	}
+}
+
+func TestEmailRateLimitExceeded(t *testing.T) {
+	inner := &countingWorker[jobs.EmailJobArgs]{}
+	worker := workers.WithEmailRateLimit[jobs.EmailJobArgs](
+		inner,
+		func(ctx context.Context, arg database.TakeRateLimitTokensParams) (float64, error) {
+			return 0, pgx.ErrNoRows
+		},
+		config.Email{EmailRateLimit: 10, EmailRateBurst: 10},
+	)
+
+	err := worker.Work(context.Background(), &river.Job[jobs.EmailJobArgs]{
+		JobRow: &rivertype.JobRow{ID: 1},
+		Args:   jobs.EmailJobArgs{To: jobs.Recipients{"user@grafto.com"}},
+	})
+
+	assert.ErrorIs(t, err, river.JobSnooze(0))
+	assert.Equal(t, 0, inner.calls)
+}
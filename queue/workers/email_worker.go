@@ -2,7 +2,9 @@ package workers
 
 import (
 	"context"
+	"log/slog"
 
+	"github.com/mbvlabs/grafto/psql/database"
 	"github.com/mbvlabs/grafto/queue/jobs"
 	"github.com/mbvlabs/grafto/services"
 	"github.com/riverqueue/river"
@@ -10,18 +12,65 @@ import (
 
 type EmailJobWorker struct {
 	emailer services.EmailClient
+	db      *database.Queries
 	river.WorkerDefaults[jobs.EmailJobArgs]
 }
 
 func (w *EmailJobWorker) Work(ctx context.Context, job *river.Job[jobs.EmailJobArgs]) error {
-	return w.emailer.SendEmail(
+	attachments := make([]services.Attachment, 0, len(job.Args.Attachments))
+	for _, attachment := range job.Args.Attachments {
+		content := attachment.Content
+		if attachment.StoredID != nil {
+			stored, err := w.db.QueryEmailAttachmentByID(ctx, *attachment.StoredID)
+			if err != nil {
+				return err
+			}
+			content = stored.Content
+		}
+
+		attachments = append(attachments, services.Attachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Content:     content,
+		})
+	}
+
+	if err := w.emailer.SendEmail(
 		ctx,
 		services.EmailPayload{
-			To:       job.Args.To,
-			From:     job.Args.From,
-			Subject:  job.Args.Subject,
-			HtmlBody: job.Args.TextVersion,
-			TextBody: job.Args.HtmlVersion,
+			To:          job.Args.To,
+			Cc:          job.Args.Cc,
+			Bcc:         job.Args.Bcc,
+			ReplyTo:     job.Args.ReplyTo,
+			From:        job.Args.From,
+			Subject:     job.Args.Subject,
+			HtmlBody:    job.Args.HtmlVersion,
+			TextBody:    job.Args.TextVersion,
+			Headers:     job.Args.Headers,
+			Attachments: attachments,
 		},
-	)
+	); err != nil {
+		return err
+	}
+
+	// the email is sent at this point so failing to clean up must not cause
+	// a retry that would send it again
+	for _, attachment := range job.Args.Attachments {
+		if attachment.StoredID == nil {
+			continue
+		}
+
+		if err := w.db.DeleteEmailAttachment(ctx, *attachment.StoredID); err != nil {
+			slog.ErrorContext(
+				ctx,
+				"could not delete stored email attachment",
+				"error",
+				err,
+				"attachment_id",
+				*attachment.StoredID,
+			)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,51 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mbvlabs/grafto/pkg/envelope"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+)
+
+/*
+decrypting hands the wrapped worker its job with the sensitive args decrypted.
+River has already decoded the args by the time Work is called, with the
+encrypted ones left empty, see jobs.EmailJobArgs.UnmarshalJSON, so the args are
+decoded again from the decrypted json.
+*/
+type decrypting[T river.JobArgs] struct {
+	river.Worker[T]
+	keyring *envelope.Keyring
+}
+
+func withDecryption[T river.JobArgs](
+	worker river.Worker[T],
+	keyring *envelope.Keyring,
+) river.Worker[T] {
+	return &decrypting[T]{worker, keyring}
+}
+
+func (d *decrypting[T]) Work(ctx context.Context, job *river.Job[T]) error {
+	decrypted, err := jobs.DecryptArgs(d.keyring, job.EncodedArgs)
+	if err != nil {
+		err = fmt.Errorf("could not decrypt job args: %w", err)
+		// a missing keyring is fixed by configuring one, while args sealed
+		// with a retired key or tampered with never open
+		if errors.Is(err, jobs.ErrNoKeyring) {
+			return err
+		}
+
+		return jobs.Permanent(err)
+	}
+
+	var args T
+	if err := json.Unmarshal(decrypted, &args); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	return d.Worker.Work(ctx, &river.Job[T]{JobRow: job.JobRow, Args: args})
+}
@@ -0,0 +1,73 @@
+package workers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mbvlabs/grafto/pkg/envelope"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/mbvlabs/grafto/queue/workers"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingWorker[T river.JobArgs] struct {
+	args []T
+	river.WorkerDefaults[T]
+}
+
+func (w *recordingWorker[T]) Work(ctx context.Context, job *river.Job[T]) error {
+	w.args = append(w.args, job.Args)
+	return nil
+}
+
+// unmarshalJob decodes the args of row the way River does before it calls
+// Work.
+func unmarshalJob[T river.JobArgs](t *testing.T, row *rivertype.JobRow) *river.Job[T] {
+	t.Helper()
+
+	job := &river.Job[T]{JobRow: row}
+	if err := json.Unmarshal(row.EncodedArgs, &job.Args); err != nil {
+		t.Fatalf("river could not decode the args: %v", err)
+	}
+
+	return job
+}
+
+func TestDecryptionOfEmailJobs(t *testing.T) {
+	keyring, err := envelope.NewKeyring("test", map[string][]byte{
+		"test": bytes.Repeat([]byte{1}, 32),
+	})
+	assert.NoError(t, err)
+
+	args := jobs.EmailJobArgs{
+		To:          jobs.Recipients{"jon@stark.com"},
+		From:        "ned@stark.com",
+		Subject:     "Reset your password",
+		TextVersion: "https://grafto.com/reset-password?token=secret",
+		HtmlVersion: "<a href='https://grafto.com/reset-password?token=secret'>",
+		Attachments: []jobs.EmailAttachment{
+			{Filename: "terms.txt", ContentType: "text/plain", Content: []byte("terms")},
+		},
+	}
+	encoded, err := json.Marshal(args)
+	assert.NoError(t, err)
+	encrypted, err := jobs.EncryptArgs(keyring, args.Kind(), encoded)
+	assert.NoError(t, err)
+
+	job := unmarshalJob[jobs.EmailJobArgs](t, &rivertype.JobRow{
+		Kind:        args.Kind(),
+		EncodedArgs: encrypted,
+	})
+	assert.Empty(t, job.Args.TextVersion, "encrypted args are left empty until decrypted")
+	assert.Equal(t, args.Subject, job.Args.Subject)
+
+	recorder := &recordingWorker[jobs.EmailJobArgs]{}
+	worker := workers.WithDecryption[jobs.EmailJobArgs](recorder, keyring)
+	assert.NoError(t, worker.Work(context.Background(), job))
+
+	assert.Equal(t, []jobs.EmailJobArgs{args}, recorder.args)
+}
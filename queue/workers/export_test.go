@@ -0,0 +1,77 @@
+package workers
+
+import (
+	"context"
+
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/pkg/envelope"
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/riverqueue/river"
+)
+
+// WithRetryPolicy wraps worker with a fixed random so the jitter of retries is
+// predictable.
+func WithRetryPolicy[T river.JobArgs](
+	worker river.Worker[T],
+	clock Clock,
+	random float64,
+) river.Worker[T] {
+	retrying := withRetryPolicy(worker, clock).(*retrying[T])
+	retrying.random = func() float64 { return random }
+
+	return retrying
+}
+
+type RateLimitStorageFunc func(ctx context.Context, arg database.TakeRateLimitTokensParams) (float64, error)
+
+func (f RateLimitStorageFunc) TakeRateLimitTokens(
+	ctx context.Context,
+	arg database.TakeRateLimitTokensParams,
+) (float64, error) {
+	return f(ctx, arg)
+}
+
+// WithEmailRateLimit wraps worker with a limiter that does not wait for the
+// bucket to refill.
+func WithEmailRateLimit[T throttledEmailArgs](
+	worker river.Worker[T],
+	storage RateLimitStorageFunc,
+	cfg config.Email,
+) river.Worker[T] {
+	limiter := newEmailRateLimiter(storage, cfg)
+	limiter.maxWait = 0
+
+	return withEmailRateLimit(worker, limiter)
+}
+
+type ScheduleStorage struct {
+	Enabled bool
+	Err     error
+	Runs    []database.RecordJobScheduleRunParams
+}
+
+func (s *ScheduleStorage) QueryJobScheduleEnabled(ctx context.Context, name string) (bool, error) {
+	return s.Enabled, s.Err
+}
+
+func (s *ScheduleStorage) RecordJobScheduleRun(
+	ctx context.Context,
+	arg database.RecordJobScheduleRunParams,
+) error {
+	s.Runs = append(s.Runs, arg)
+	return nil
+}
+
+func WithScheduleState[T river.JobArgs](
+	worker river.Worker[T],
+	storage *ScheduleStorage,
+) river.Worker[T] {
+	return withScheduleState(worker, storage)
+}
+
+func WithDecryption[T river.JobArgs](
+	worker river.Worker[T],
+	keyring *envelope.Keyring,
+) river.Worker[T] {
+	return withDecryption(worker, keyring)
+}
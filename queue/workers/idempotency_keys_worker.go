@@ -0,0 +1,29 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+)
+
+type PurgeIdempotencyKeysJobWorker struct {
+	db *database.Queries
+	river.WorkerDefaults[jobs.PurgeIdempotencyKeysJobArgs]
+}
+
+func (w *PurgeIdempotencyKeysJobWorker) Work(
+	ctx context.Context,
+	job *river.Job[jobs.PurgeIdempotencyKeysJobArgs],
+) error {
+	purged, err := w.db.DeleteExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "purged expired idempotency keys", "count", purged)
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package workers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/riverqueue/river"
+)
+
+// jobs fetched from a paused queue are snoozed for this long before they are
+// looked at again
+const pausedQueueSnooze = 30 * time.Second
+
+type queueStorage interface {
+	QueryRiverQueuePaused(ctx context.Context, name string) (bool, error)
+}
+
+/*
+pausable snoozes jobs from queues that are paused in the river_queue table
+instead of working them. The river version in use does not read river_queue
+itself, so pausing is enforced here for every registered worker. Snoozing
+does not use up an attempt.
+*/
+type pausable[T river.JobArgs] struct {
+	river.Worker[T]
+	storage queueStorage
+}
+
+func withPause[T river.JobArgs](
+	worker river.Worker[T],
+	storage queueStorage,
+) river.Worker[T] {
+	return &pausable[T]{worker, storage}
+}
+
+func (p *pausable[T]) Work(ctx context.Context, job *river.Job[T]) error {
+	paused, err := p.storage.QueryRiverQueuePaused(ctx, job.Queue)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not check if queue is paused", "error", err, "queue", job.Queue)
+		return err
+	}
+
+	if paused {
+		return river.JobSnooze(pausedQueueSnooze)
+	}
+
+	return p.Worker.Work(ctx, job)
+}
@@ -0,0 +1,96 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+)
+
+// Clock tells the time retries are scheduled from, so tests can control it.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+/*
+retrying schedules the retries of failed jobs from the retry policy of their
+args, see jobs.RetryPolicy, falling back to the default schedule of river for
+args without one. A retry after hint returned by the worker, see
+jobs.RetryAfter, is the earliest the job is retried regardless of policy.
+
+River only asks for the next retry after Work has returned, so the hint is
+kept by job id until then.
+*/
+type retrying[T river.JobArgs] struct {
+	river.Worker[T]
+	clock      Clock
+	random     func() float64
+	retryAfter sync.Map
+}
+
+func withRetryPolicy[T river.JobArgs](
+	worker river.Worker[T],
+	clock Clock,
+) river.Worker[T] {
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	return &retrying[T]{Worker: worker, clock: clock, random: rand.Float64}
+}
+
+func (r *retrying[T]) Work(ctx context.Context, job *river.Job[T]) error {
+	err := r.Worker.Work(ctx, job)
+
+	if hint := jobs.RetryAfterOf(err); hint > 0 && willRetry(job.JobRow, err) {
+		r.retryAfter.Store(job.ID, hint)
+	}
+
+	return err
+}
+
+/*
+willRetry reports whether river asks for the next retry of job after it failed
+with err, which it does not for jobs that are out of attempts, cancelled by the
+worker or dead-lettered right away, see queue.DeadLetterHandler. A hint kept
+for those would never be taken.
+*/
+func willRetry(job *rivertype.JobRow, err error) bool {
+	return job.Attempt < job.MaxAttempts &&
+		!errors.Is(err, river.JobCancel(nil)) &&
+		!queue.IsPermanent(err)
+}
+
+func (r *retrying[T]) NextRetry(job *river.Job[T]) time.Time {
+	var hint time.Duration
+	if stored, ok := r.retryAfter.LoadAndDelete(job.ID); ok {
+		hint = stored.(time.Duration)
+	}
+
+	now := r.clock.Now()
+
+	// the error of the attempt that just failed is not in job.Errors yet
+	next := r.Worker.NextRetry(job)
+	if provider, ok := any(job.Args).(jobs.RetryPolicyProvider); ok {
+		next = provider.RetryPolicy().NextRetry(now, len(job.Errors)+1, r.random())
+	}
+
+	// a zero time leaves the retry to the default schedule of river, which
+	// only the hint can push back
+	earliest := now.Add(hint)
+	if hint > 0 && next.Before(earliest) {
+		return earliest
+	}
+
+	return next
+}
@@ -0,0 +1,175 @@
+package workers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/mbvlabs/grafto/queue/workers"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+type retryJobArgs struct{}
+
+func (retryJobArgs) Kind() string { return "retry_job" }
+
+func (retryJobArgs) RetryPolicy() jobs.RetryPolicy {
+	return jobs.RetryPolicy{
+		MaxAttempts: 5,
+		Backoff:     jobs.BackoffExponential,
+		BaseDelay:   time.Minute,
+		Jitter:      0.5,
+	}
+}
+
+type noPolicyJobArgs struct{}
+
+func (noPolicyJobArgs) Kind() string { return "no_policy_job" }
+
+type failingWorker[T river.JobArgs] struct {
+	err error
+	river.WorkerDefaults[T]
+}
+
+func (w *failingWorker[T]) Work(ctx context.Context, job *river.Job[T]) error {
+	return w.err
+}
+
+func TestRetryPolicyNextRetry(t *testing.T) {
+	clock := fixedClock{time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+
+	tests := map[string]struct {
+		err      error
+		errors   int
+		attempt  int
+		expected time.Time
+	}{
+		"should retry on the schedule of the policy": {
+			err:      errors.New("connection reset"),
+			errors:   2,
+			attempt:  3,
+			expected: clock.now.Add(4 * time.Minute),
+		},
+		"should honour a hint that is later than the schedule": {
+			err:      jobs.RetryAfter(errors.New("throttled"), time.Hour),
+			errors:   0,
+			attempt:  1,
+			expected: clock.now.Add(time.Hour),
+		},
+		"should keep the schedule when it is later than the hint": {
+			err:      jobs.RetryAfter(errors.New("throttled"), time.Second),
+			errors:   0,
+			attempt:  1,
+			expected: clock.now.Add(time.Minute),
+		},
+		"should count errors instead of attempts so snoozes do not add up": {
+			err:      errors.New("connection reset"),
+			errors:   0,
+			attempt:  4,
+			expected: clock.now.Add(time.Minute),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			worker := workers.WithRetryPolicy[retryJobArgs](
+				&failingWorker[retryJobArgs]{err: test.err},
+				clock,
+				0.5,
+			)
+
+			job := &river.Job[retryJobArgs]{
+				JobRow: &rivertype.JobRow{
+					ID:          1,
+					Attempt:     test.attempt,
+					MaxAttempts: 5,
+					Errors:      make([]rivertype.AttemptError, test.errors),
+				},
+			}
+
+			err := worker.Work(context.Background(), job)
+			assert.ErrorIs(t, err, test.err)
+			assert.Equal(t, test.expected, worker.NextRetry(job))
+		})
+	}
+}
+
+func TestRetryWithoutPolicy(t *testing.T) {
+	clock := fixedClock{time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+
+	tests := map[string]struct {
+		err      error
+		expected time.Time
+	}{
+		"should leave the retry to river": {
+			err:      errors.New("connection reset"),
+			expected: time.Time{},
+		},
+		"should only honour the hint": {
+			err:      jobs.RetryAfter(errors.New("throttled"), time.Minute),
+			expected: clock.now.Add(time.Minute),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			worker := workers.WithRetryPolicy[noPolicyJobArgs](
+				&failingWorker[noPolicyJobArgs]{err: test.err},
+				clock,
+				0.5,
+			)
+
+			job := &river.Job[noPolicyJobArgs]{
+				JobRow: &rivertype.JobRow{ID: 1, Attempt: 1, MaxAttempts: 25},
+			}
+
+			_ = worker.Work(context.Background(), job)
+			assert.Equal(t, test.expected, worker.NextRetry(job))
+		})
+	}
+}
+
+func TestRetryHintOfJobsThatAreNotRetried(t *testing.T) {
+	clock := fixedClock{time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+	throttled := jobs.RetryAfter(errors.New("throttled"), time.Hour)
+
+	tests := map[string]struct {
+		err error
+	}{
+		"should drop the hint of a cancelled job": {
+			err: river.JobCancel(throttled),
+		},
+		"should drop the hint of a permanent error": {
+			err: jobs.Permanent(throttled),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			worker := &failingWorker[noPolicyJobArgs]{err: test.err}
+			retrying := workers.WithRetryPolicy[noPolicyJobArgs](worker, clock, 0.5)
+
+			job := &river.Job[noPolicyJobArgs]{
+				JobRow: &rivertype.JobRow{ID: 1, Attempt: 1, MaxAttempts: 25},
+			}
+			_ = retrying.Work(context.Background(), job)
+
+			// retried by hand later on, it fails without a hint
+			worker.err = errors.New("connection reset")
+			job.Attempt++
+			_ = retrying.Work(context.Background(), job)
+
+			assert.Equal(t, time.Time{}, retrying.NextRetry(job))
+		})
+	}
+}
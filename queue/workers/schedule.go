@@ -0,0 +1,89 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/riverqueue/river"
+)
+
+// outcomeSkipped is recorded for scheduled jobs that were not worked because
+// their schedule is disabled
+const outcomeSkipped = "skipped"
+
+type scheduleStorage interface {
+	QueryJobScheduleEnabled(ctx context.Context, name string) (bool, error)
+	RecordJobScheduleRun(ctx context.Context, arg database.RecordJobScheduleRunParams) error
+}
+
+/*
+scheduled skips jobs enqueued by a schedule that is disabled in the
+job_schedules table, so a schedule can be turned off without a deploy, and
+records the outcome of every scheduled job for the admin. Jobs triggered
+manually run even when their schedule is disabled.
+*/
+type scheduled[T river.JobArgs] struct {
+	river.Worker[T]
+	storage scheduleStorage
+}
+
+func withScheduleState[T river.JobArgs](
+	worker river.Worker[T],
+	storage scheduleStorage,
+) river.Worker[T] {
+	return &scheduled[T]{worker, storage}
+}
+
+func (s *scheduled[T]) Work(ctx context.Context, job *river.Job[T]) error {
+	name, manual := queue.ScheduleOf(job.Metadata)
+	if name == "" {
+		return s.Worker.Work(ctx, job)
+	}
+
+	if !manual {
+		enabled, err := s.storage.QueryJobScheduleEnabled(ctx, name)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			slog.ErrorContext(ctx, "could not check if schedule is enabled", "error", err, "schedule", name)
+			return err
+		}
+
+		// schedules without a row have never been disabled
+		if err == nil && !enabled {
+			slog.InfoContext(ctx, "skipping job of disabled schedule", "schedule", name, "job_id", job.ID)
+			s.record(ctx, name, job.ID, outcomeSkipped, nil)
+			return nil
+		}
+	}
+
+	err := s.Worker.Work(ctx, job)
+	s.record(ctx, name, job.ID, jobOutcome(err), err)
+
+	return err
+}
+
+func (s *scheduled[T]) record(
+	ctx context.Context,
+	name string,
+	jobID int64,
+	outcome string,
+	jobErr error,
+) {
+	var lastError sql.NullString
+	if jobErr != nil {
+		lastError = sql.NullString{String: jobErr.Error(), Valid: true}
+	}
+
+	if err := s.storage.RecordJobScheduleRun(ctx, database.RecordJobScheduleRunParams{
+		Name:        name,
+		LastJobID:   sql.NullInt64{Int64: jobID, Valid: true},
+		LastOutcome: sql.NullString{String: outcome, Valid: true},
+		LastError:   lastError,
+	}); err != nil {
+		slog.ErrorContext(ctx, "could not record run of schedule", "error", err, "schedule", name)
+	}
+}
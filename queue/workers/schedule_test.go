@@ -0,0 +1,74 @@
+package workers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/mbvlabs/grafto/queue/workers"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleState(t *testing.T) {
+	tests := map[string]struct {
+		metadata        string
+		storage         workers.ScheduleStorage
+		expectedCalls   int
+		expectedOutcome string
+	}{
+		"should work jobs that were not scheduled": {
+			metadata:      `{}`,
+			storage:       workers.ScheduleStorage{Enabled: false},
+			expectedCalls: 1,
+		},
+		"should work and record jobs of enabled schedules": {
+			metadata:        `{"schedule":"purge","manual":false}`,
+			storage:         workers.ScheduleStorage{Enabled: true},
+			expectedCalls:   1,
+			expectedOutcome: "completed",
+		},
+		"should work jobs of schedules that have never been stored": {
+			metadata:        `{"schedule":"purge","manual":false}`,
+			storage:         workers.ScheduleStorage{Err: pgx.ErrNoRows},
+			expectedCalls:   1,
+			expectedOutcome: "completed",
+		},
+		"should skip jobs of disabled schedules": {
+			metadata:        `{"schedule":"purge","manual":false}`,
+			storage:         workers.ScheduleStorage{Enabled: false},
+			expectedCalls:   0,
+			expectedOutcome: "skipped",
+		},
+		"should work jobs of disabled schedules that are triggered manually": {
+			metadata:        `{"schedule":"purge","manual":true}`,
+			storage:         workers.ScheduleStorage{Enabled: false},
+			expectedCalls:   1,
+			expectedOutcome: "completed",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			inner := &countingWorker[jobs.PurgeIdempotencyKeysJobArgs]{}
+			worker := workers.WithScheduleState[jobs.PurgeIdempotencyKeysJobArgs](inner, &test.storage)
+
+			err := worker.Work(context.Background(), &river.Job[jobs.PurgeIdempotencyKeysJobArgs]{
+				JobRow: &rivertype.JobRow{ID: 7, Metadata: []byte(test.metadata)},
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedCalls, inner.calls)
+			if test.expectedOutcome == "" {
+				assert.Empty(t, test.storage.Runs)
+				return
+			}
+			assert.Len(t, test.storage.Runs, 1)
+			assert.Equal(t, "purge", test.storage.Runs[0].Name)
+			assert.Equal(t, int64(7), test.storage.Runs[0].LastJobID.Int64)
+			assert.Equal(t, test.expectedOutcome, test.storage.Runs[0].LastOutcome.String)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package workers
+
+import (
+	"context"
+
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/mbvlabs/grafto/services"
+	"github.com/riverqueue/river"
+)
+
+type TemplatedEmailJobWorker struct {
+	emailer services.EmailClient
+	river.WorkerDefaults[jobs.TemplatedEmailJobArgs]
+}
+
+func (w *TemplatedEmailJobWorker) Work(
+	ctx context.Context,
+	job *river.Job[jobs.TemplatedEmailJobArgs],
+) error {
+	// rendering only depends on the args so it fails the same way every time
+	payload, err := services.RenderTemplatedEmail(job.Args)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	return w.emailer.SendEmail(ctx, payload)
+}
@@ -0,0 +1,107 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/riverqueue/river"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	outcomeCompleted = "completed"
+	outcomeSnoozed   = "snoozed"
+	outcomeCancelled = "cancelled"
+	outcomeFailed    = "failed"
+)
+
+/*
+traced works a job inside a span that continues the trace of the request that
+inserted it, so the request, the job and whatever the job calls end up in one
+trace.
+*/
+type traced[T river.JobArgs] struct {
+	river.Worker[T]
+	tracer telemetry.Tracer
+}
+
+func withTracing[T river.JobArgs](
+	worker river.Worker[T],
+	tracer telemetry.Tracer,
+) river.Worker[T] {
+	return &traced[T]{worker, tracer}
+}
+
+func (t *traced[T]) Work(ctx context.Context, job *river.Job[T]) error {
+	ctx = queue.ExtractTraceContext(ctx, job.Metadata)
+
+	ctx, span := t.tracer.CreateSpan(
+		ctx,
+		job.Kind,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.Int64("job.id", job.ID),
+			attribute.String("job.kind", job.Kind),
+			attribute.String("job.queue", job.Queue),
+			attribute.Int("job.attempt", job.Attempt),
+			attribute.Int("job.max_attempts", job.MaxAttempts),
+		),
+	)
+	defer span.End()
+
+	err := t.Worker.Work(ctx, job)
+
+	outcome := jobOutcome(err)
+	span.SetAttributes(attribute.String("job.outcome", outcome))
+	if outcome == outcomeFailed || outcome == outcomeCancelled {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
+
+func jobOutcome(err error) string {
+	switch {
+	case err == nil:
+		return outcomeCompleted
+	case errors.Is(err, river.JobSnooze(0)):
+		return outcomeSnoozed
+	case errors.Is(err, river.JobCancel(nil)):
+		return outcomeCancelled
+	default:
+		return outcomeFailed
+	}
+}
+
+// register adds worker with the behaviour every worker shares: retry
+// policies, tracing, respecting paused queues and disabled schedules, and
+// decrypting sensitive args.
+func register[T river.JobArgs](
+	workers *river.Workers,
+	worker river.Worker[T],
+	deps WorkerDependencies,
+) error {
+	if err := river.AddWorkerSafely(
+		workers,
+		withRetryPolicy(
+			withTracing(
+				withPause(
+					withScheduleState(withDecryption(worker, deps.Keyring), deps.DB),
+					deps.DB,
+				),
+				deps.Tracer,
+			),
+			deps.Clock,
+		),
+	); err != nil {
+		return fmt.Errorf("could not register worker for %T: %w", *new(T), err)
+	}
+
+	return nil
+}
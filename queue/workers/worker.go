@@ -1,26 +1,103 @@
 package workers
 
 import (
+	"net/http"
+	"time"
+
+	"github.com/mbvlabs/grafto/config"
 	awsses "github.com/mbvlabs/grafto/pkg/aws_ses"
+	"github.com/mbvlabs/grafto/pkg/envelope"
 	"github.com/mbvlabs/grafto/pkg/telemetry"
 	"github.com/mbvlabs/grafto/psql/database"
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/queue/jobs"
 	"github.com/riverqueue/river"
 )
 
 type WorkerDependencies struct {
-	DB      *database.Queries
-	Emailer awsses.AwsSimpleEmailService
-	Tracer  telemetry.Tracer
+	DB       *database.Queries
+	Emailer  awsses.AwsSimpleEmailService
+	Tracer   telemetry.Tracer
+	Keyring  *envelope.Keyring
+	Alerting config.Alerting
+	Email    config.Email
+	Users    config.Users
+	// Clock schedules retries, leave it nil to use the system clock
+	Clock Clock
 }
 
 func SetupWorkers(deps WorkerDependencies) (*river.Workers, error) {
 	workers := river.NewWorkers()
 
-	if err := river.AddWorkerSafely(workers, &EmailJobWorker{
+	emailRateLimiter := newEmailRateLimiter(deps.DB, deps.Email)
+
+	if err := register(workers, withEmailRateLimit[jobs.EmailJobArgs](&EmailJobWorker{
+		emailer: &deps.Emailer,
+		db:      deps.DB,
+	}, emailRateLimiter), deps); err != nil {
+		return nil, err
+	}
+
+	if err := register(workers, withEmailRateLimit[jobs.TemplatedEmailJobArgs](&TemplatedEmailJobWorker{
+		emailer: &deps.Emailer,
+	}, emailRateLimiter), deps); err != nil {
+		return nil, err
+	}
+
+	if err := register[jobs.PurgeIdempotencyKeysJobArgs](workers, &PurgeIdempotencyKeysJobWorker{
+		db: deps.DB,
+	}, deps); err != nil {
+		return nil, err
+	}
+
+	if err := register[jobs.PurgeDeletedUsersJobArgs](workers, &PurgeDeletedUsersJobWorker{
+		db:        deps.DB,
+		retention: deps.Users.DeletedUserRetention,
+	}, deps); err != nil {
+		return nil, err
+	}
+
+	if err := register[jobs.PurgeEmailAttachmentsJobArgs](workers, &PurgeEmailAttachmentsJobWorker{
+		db: deps.DB,
+	}, deps); err != nil {
+		return nil, err
+	}
+
+	if err := register[jobs.CheckDeadLetterRateJobArgs](workers, &CheckDeadLetterRateJobWorker{
+		db:      deps.DB,
 		emailer: &deps.Emailer,
-	}); err != nil {
+		client:  &http.Client{Timeout: 10 * time.Second},
+		cfg:     deps.Alerting,
+	}, deps); err != nil {
 		return nil, err
 	}
 
 	return workers, nil
 }
+
+// Schedules declares the jobs the leader enqueues on a schedule, see
+// queue.NewScheduler for overriding or disabling them in config.
+func Schedules(alerting config.Alerting) []queue.Schedule {
+	return []queue.Schedule{
+		{
+			Name: "purge_idempotency_keys",
+			Spec: "@every 1h",
+			Args: jobs.PurgeIdempotencyKeysJobArgs{},
+		},
+		{
+			Name: "purge_deleted_users",
+			Spec: "@daily",
+			Args: jobs.PurgeDeletedUsersJobArgs{},
+		},
+		{
+			Name: "purge_email_attachments",
+			Spec: "@daily",
+			Args: jobs.PurgeEmailAttachmentsJobArgs{},
+		},
+		{
+			Name: "check_dead_letter_rate",
+			Spec: "@every " + alerting.DeadLetterAlertWindow.String(),
+			Args: jobs.CheckDeadLetterRateJobArgs{},
+		},
+	}
+}
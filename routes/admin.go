@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/http/handlers"
+	"github.com/mbvlabs/grafto/http/middleware"
+)
+
+func adminRoutes(
+	router *echo.Echo,
+	ctrl handlers.AdminJobs,
+	usersCtrl handlers.AdminUsers,
+	mw middleware.Middleware,
+) {
+	adminRouter := router.Group("/admin", mw.AdminOnly)
+
+	adminRouter.GET("/jobs", func(c echo.Context) error {
+		return ctrl.Index(c)
+	})
+	adminRouter.GET("/jobs/:id", func(c echo.Context) error {
+		return ctrl.Show(c)
+	})
+	adminRouter.POST("/jobs/:id/retry", func(c echo.Context) error {
+		return ctrl.Retry(c)
+	})
+	adminRouter.POST("/jobs/:id/cancel", func(c echo.Context) error {
+		return ctrl.Cancel(c)
+	})
+	adminRouter.POST("/jobs/:id/delete", func(c echo.Context) error {
+		return ctrl.Delete(c)
+	})
+	adminRouter.GET("/dead-letters", func(c echo.Context) error {
+		return ctrl.DeadLetters(c)
+	})
+	adminRouter.POST("/dead-letters/:id/retry", func(c echo.Context) error {
+		return ctrl.RetryDeadLetter(c)
+	})
+	adminRouter.POST("/dead-letters/:id/resolve", func(c echo.Context) error {
+		return ctrl.ResolveDeadLetter(c)
+	})
+	adminRouter.GET("/schedules", func(c echo.Context) error {
+		return ctrl.Schedules(c)
+	})
+	adminRouter.POST("/schedules/:name/enable", func(c echo.Context) error {
+		return ctrl.EnableSchedule(c)
+	})
+	adminRouter.POST("/schedules/:name/disable", func(c echo.Context) error {
+		return ctrl.DisableSchedule(c)
+	})
+	adminRouter.POST("/schedules/:name/trigger", func(c echo.Context) error {
+		return ctrl.TriggerSchedule(c)
+	})
+	adminRouter.POST("/queues/:name/pause", func(c echo.Context) error {
+		return ctrl.PauseQueue(c)
+	})
+	adminRouter.POST("/queues/:name/resume", func(c echo.Context) error {
+		return ctrl.ResumeQueue(c)
+	})
+	adminRouter.GET("/users", func(c echo.Context) error {
+		return usersCtrl.Index(c)
+	})
+	adminRouter.GET("/users/search", func(c echo.Context) error {
+		return usersCtrl.Search(c)
+	})
+	adminRouter.GET("/users/deleted", func(c echo.Context) error {
+		return usersCtrl.Deleted(c)
+	})
+	adminRouter.GET("/users/:id/edit", func(c echo.Context) error {
+		return usersCtrl.Edit(c)
+	})
+	adminRouter.POST("/users/:id", func(c echo.Context) error {
+		return usersCtrl.Update(c)
+	})
+	adminRouter.POST("/users/:id/delete", func(c echo.Context) error {
+		return usersCtrl.Delete(c)
+	})
+	adminRouter.POST("/users/:id/restore", func(c echo.Context) error {
+		return usersCtrl.Restore(c)
+	})
+}
@@ -3,13 +3,30 @@ package routes
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/mbvlabs/grafto/http/handlers"
+	"github.com/mbvlabs/grafto/http/middleware"
 )
 
 func apiV1Routes(
 	router *echo.Group,
 	controllers handlers.Api,
+	mw middleware.Middleware,
 ) {
 	router.GET("/health", func(c echo.Context) error {
 		return controllers.AppHealth(c)
 	})
+	router.GET("/users", func(c echo.Context) error {
+		return controllers.Users(c)
+	}, mw.AdminOnly)
+	router.POST("/users", func(c echo.Context) error {
+		return controllers.CreateUser(c)
+	}, mw.AdminOnly)
+	router.GET("/users/search", func(c echo.Context) error {
+		return controllers.SearchUsers(c)
+	}, mw.AdminOnly)
+	router.GET("/users/:id", func(c echo.Context) error {
+		return controllers.User(c)
+	}, mw.AdminOnly)
+	router.PUT("/users/:id", func(c echo.Context) error {
+		return controllers.UpdateUser(c)
+	}, mw.AdminOnly)
 }
@@ -9,4 +9,7 @@ func appRoutes(router *echo.Echo, ctrl handlers.App) {
 	router.GET("/", func(c echo.Context) error {
 		return ctrl.LandingPage(c)
 	})
+	router.POST("/locale", func(c echo.Context) error {
+		return ctrl.UpdateLocale(c)
+	})
 }
@@ -0,0 +1,12 @@
+package routes_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/mbvlabs/grafto/psql/psqltest"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(psqltest.Main(m))
+}
@@ -21,6 +21,8 @@ type Routes struct {
 	authHandlers         handlers.Authentication
 	registrationHandlers handlers.Registration
 	apiHandlers          handlers.Api
+	adminJobsHandlers    handlers.AdminJobs
+	adminUsersHandlers   handlers.AdminUsers
 	baseHandlers         handlers.Base
 	middleware           middleware.Middleware
 	cfg                  config.Config
@@ -32,6 +34,8 @@ func NewRoutes(
 	authHandlers handlers.Authentication,
 	registrationHandlers handlers.Registration,
 	apiHandlers handlers.Api,
+	adminJobsHandlers handlers.AdminJobs,
+	adminUsersHandlers handlers.AdminUsers,
 	baseHandlers handlers.Base,
 	mw middleware.Middleware,
 	cfg config.Config,
@@ -40,6 +44,9 @@ func NewRoutes(
 
 	router.Debug = true
 
+	router.Use(mw.Trace)
+	router.Use(mw.ReadYourWrites)
+
 	if cfg.Environment == config.PROD_ENVIRONMENT {
 		router.Debug = false
 		router.Use(echomw.GzipWithConfig(echomw.GzipConfig{
@@ -66,6 +73,7 @@ func NewRoutes(
 	})
 
 	router.Use(mw.RegisterUserContext)
+	router.Use(mw.Localize)
 
 	slogechoCfg := slogecho.Config{
 		WithRequestID: false,
@@ -86,6 +94,8 @@ func NewRoutes(
 		authHandlers,
 		registrationHandlers,
 		apiHandlers,
+		adminJobsHandlers,
+		adminUsersHandlers,
 		baseHandlers,
 		mw,
 		cfg,
@@ -97,11 +107,12 @@ func (r *Routes) web() {
 	dashboardRoutes(r.router, r.dashboardHandlers, r.middleware)
 	appRoutes(r.router, r.appHandlers)
 	registrationRoutes(r.router, r.registrationHandlers)
+	adminRoutes(r.router, r.adminJobsHandlers, r.adminUsersHandlers, r.middleware)
 }
 
 func (r *Routes) api() {
-	apiV1Router := r.router.Group("/api/v1")
-	apiV1Routes(apiV1Router, r.apiHandlers)
+	apiV1Router := r.router.Group("/api/v1", r.middleware.Idempotent)
+	apiV1Routes(apiV1Router, r.apiHandlers, r.middleware)
 }
 
 func (r *Routes) SetupRoutes() *echo.Echo {
@@ -0,0 +1,177 @@
+package routes_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mbvlabs/grafto/factory"
+	"github.com/mbvlabs/grafto/psql/psqltest"
+	"github.com/mbvlabs/grafto/routes/routestest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	server := routestest.NewServer(t, psqltest.DB(t))
+
+	res, err := http.Get(server.URL + "/api/v1/health")
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestRegistration(t *testing.T) {
+	db := psqltest.DB(t)
+	server := routestest.NewServer(t, db)
+	data := factory.New(1).CreateUserData()
+
+	form := url.Values{
+		"username":         {data.Name},
+		"email":            {data.Email},
+		"password":         {data.Password},
+		"confirm_password": {data.ConfirmPassword},
+	}
+
+	res, err := http.PostForm(server.URL+"/register", form)
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	user, err := db.QueryUserByEmail(context.Background(), data.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, data.Name, user.Name)
+	assert.False(t, user.IsVerified())
+
+	// registering the email again is refused without breaking the
+	// transaction of the test
+	res, err = http.PostForm(server.URL+"/register", form)
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	again, err := db.QueryUserByEmail(context.Background(), data.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, again.ID)
+}
+
+func TestAdminRoutesRequireLogin(t *testing.T) {
+	server := routestest.NewServer(t, psqltest.DB(t))
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err := client.Get(server.URL + "/api/v1/users")
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusPermanentRedirect, res.StatusCode)
+	assert.Equal(t, "/login", res.Header.Get("Location"))
+}
+
+func TestCreateUserReplaysIdempotentRequests(t *testing.T) {
+	db := psqltest.DB(t)
+	server := routestest.NewProtectedServer(t, db)
+	f := factory.New(1)
+
+	admin := f.User(factory.Admin(), factory.Verified())
+	if _, err := db.InsertUser(context.Background(), admin, "hashed"); err != nil {
+		t.Fatalf("could not insert admin: %v", err)
+	}
+	cookie := routestest.SessionCookie(t, db, admin.ID)
+
+	data := f.CreateUserData()
+	body, err := json.Marshal(map[string]string{
+		"name":             data.Name,
+		"email":            data.Email,
+		"password":         data.Password,
+		"confirm_password": data.ConfirmPassword,
+	})
+	assert.NoError(t, err)
+
+	// the api is used without a CSRF token
+	create := func(key string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/users", strings.NewReader(string(body)))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		req.AddCookie(cookie)
+
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		payload, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+
+		return res, string(payload)
+	}
+
+	first, created := create("create-jon")
+	assert.Equal(t, http.StatusCreated, first.StatusCode)
+
+	retry, replayed := create("create-jon")
+	assert.Equal(t, http.StatusCreated, retry.StatusCode)
+	assert.Equal(t, "true", retry.Header.Get("Idempotent-Replayed"))
+	assert.Equal(t, created, replayed)
+
+	again, _ := create("create-jon-again")
+	assert.Equal(t, http.StatusConflict, again.StatusCode)
+
+	user, err := db.QueryUserByEmail(context.Background(), data.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, data.Name, user.Name)
+}
+
+func TestUpdateUserThroughAPI(t *testing.T) {
+	db := psqltest.DB(t)
+	server := routestest.NewProtectedServer(t, db)
+	f := factory.New(1)
+	ctx := context.Background()
+
+	admin := f.User(factory.Admin(), factory.Verified())
+	if _, err := db.InsertUser(ctx, admin, "hashed"); err != nil {
+		t.Fatalf("could not insert admin: %v", err)
+	}
+	user, err := db.InsertUser(ctx, f.User(), "hashed")
+	if err != nil {
+		t.Fatalf("could not insert user: %v", err)
+	}
+	cookie := routestest.SessionCookie(t, db, admin.ID)
+	etag := `"` + strconv.FormatInt(user.Version, 10) + `"`
+
+	// the api is used without a CSRF token
+	update := func(contentType string, body string) *http.Response {
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/api/v1/users/"+user.ID.String(), strings.NewReader(body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("If-Match", etag)
+		req.AddCookie(cookie)
+
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		res.Body.Close()
+
+		return res
+	}
+
+	res := update("application/json", `{"name":"Jon Snow","email":"jon@snow.com"}`)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res = update("application/json", `{"name":"Aegon Targaryen","email":"jon@snow.com"}`)
+	assert.Equal(t, http.StatusConflict, res.StatusCode, "the ETag is stale after the update")
+
+	res = update("application/x-www-form-urlencoded", "name=Aegon+Targaryen&email=jon%40snow.com")
+	assert.Equal(t, http.StatusForbidden, res.StatusCode, "forms still need a CSRF token")
+
+	updated, err := db.QueryUserByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jon Snow", updated.Name)
+}
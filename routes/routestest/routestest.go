@@ -0,0 +1,140 @@
+// Package routestest serves the routes of the app over httptest for handler
+// tests, backed by the test database of psqltest.
+package routestest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo/v4"
+	"github.com/mbvlabs/grafto/config"
+	graftohttp "github.com/mbvlabs/grafto/http"
+	"github.com/mbvlabs/grafto/http/handlers"
+	"github.com/mbvlabs/grafto/http/middleware"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/pkg/telemetry"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/mbvlabs/grafto/psql/psqltest"
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/queue/workers"
+	"github.com/mbvlabs/grafto/routes"
+	"github.com/mbvlabs/grafto/services"
+)
+
+// Config returns the config the server runs with, which has the keys and
+// secrets the app needs and leaves everything else empty.
+func Config() config.Config {
+	var cfg config.Config
+	cfg.ProjectName = "Grafto"
+	cfg.Environment = config.DEV_ENVIRONMENT
+	cfg.AppDomain = "localhost"
+	cfg.AppProtocol = "http"
+	cfg.DefaultSenderSignature = "grafto@example.com"
+	cfg.PasswordPepper = "pepper"
+	cfg.SessionKey = "session-key"
+	cfg.SessionEncryptionKey = "0123456789abcdef0123456789abcdef"
+	cfg.TokenSigningKey = "token-signing-key"
+	cfg.CsrfToken = "0123456789abcdef0123456789abcdef"
+	cfg.DeadLetterAlertWindow = 15 * time.Minute
+	cfg.DeletedUserRetention = 30 * 24 * time.Hour
+	cfg.EmailRelease = config.EmailReleasedOnPurge
+
+	return cfg
+}
+
+/*
+NewServer serves the routes wired up like cmd/app does, with db as the
+database, and closes the server when the test ends. Pass the psql.Postgres of
+psqltest.DB to have every request run in the transaction of the test, in which
+case requests must be made one at a time. The routes are served without the
+CSRF protection of http.NewServer, and emails are queued but never sent.
+*/
+func NewServer(t testing.TB, db psql.Postgres) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(newRouter(t, db))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// NewProtectedServer is NewServer with the CSRF protection of http.NewServer,
+// for tests of what clients without a CSRF token can do.
+func NewProtectedServer(t testing.TB, db psql.Postgres) *httptest.Server {
+	t.Helper()
+
+	cfg := Config()
+	server := httptest.NewServer(graftohttp.Protect(newRouter(t, db), cfg))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+/*
+SessionCookie returns the cookie of a session of the user with userID, which
+must be in db. The cookie is Secure, so add it to requests to the http server
+of the test by hand rather than through a cookie jar.
+*/
+func SessionCookie(t testing.TB, db psql.Postgres, userID uuid.UUID) *http.Cookie {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	if _, err := newAuth(db).NewUserSession(req, rec, userID); err != nil {
+		t.Fatalf("could not create session: %v", err)
+	}
+
+	return rec.Result().Cookies()[0]
+}
+
+func newAuth(db psql.Postgres) services.Auth {
+	cfg := Config()
+
+	return services.NewAuth(
+		db,
+		sessions.NewCookieStore([]byte(cfg.SessionKey), []byte(cfg.SessionEncryptionKey)),
+		cfg,
+	)
+}
+
+func newRouter(t testing.TB, db psql.Postgres) *echo.Echo {
+	t.Helper()
+
+	cfg := Config()
+
+	scheduler, err := queue.NewScheduler(workers.Schedules(cfg.Alerting), cfg.Scheduler)
+	if err != nil {
+		t.Fatalf("could not set up schedules: %v", err)
+	}
+	queueClient := queue.NewClient(psqltest.Pool(t))
+
+	authSvc := newAuth(db)
+	tokenService := services.NewTokenSvc(db, cfg.TokenSigningKey)
+	emailService := services.NewEmailSvc(cfg, nil, queueClient, db)
+	userModelSvc := models.NewUserService(db, authSvc, models.WithDeletedUsers(cfg.Users))
+
+	base := handlers.NewDependencies(
+		cfg,
+		db,
+		handlers.NewCookieStore(""),
+		queueClient,
+		telemetry.Tracer{},
+	)
+
+	return routes.NewRoutes(
+		handlers.NewApp(base),
+		handlers.NewDashboard(base),
+		handlers.NewAuthentication(authSvc, base, userModelSvc, *tokenService, emailService),
+		handlers.NewRegistration(authSvc, base, userModelSvc, *tokenService, emailService),
+		handlers.NewApi(base, userModelSvc),
+		handlers.NewAdminJobs(base, scheduler),
+		handlers.NewAdminUsers(base, userModelSvc),
+		base,
+		middleware.NewMiddleware(authSvc, db, telemetry.Tracer{}),
+		cfg,
+	).SetupRoutes()
+}
@@ -0,0 +1,154 @@
+// Package seed loads development data into an empty database, see cmd/seed.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/factory"
+	"github.com/mbvlabs/grafto/models"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+)
+
+var ErrProduction = errors.New("seeding is refused in production")
+
+// The emails of the users a seed creates besides the generated ones.
+const (
+	AdminEmail      = "admin@example.com"
+	VerifiedEmail   = "verified@example.com"
+	UnverifiedEmail = "unverified@example.com"
+)
+
+const (
+	factorySeed    = 1
+	generatedUsers = 30
+)
+
+type passwordHasher interface {
+	HashAndPepperPassword(password string) (string, error)
+}
+
+type tokenCreator interface {
+	CreateUserEmailVerification(ctx context.Context, userID uuid.UUID) (string, error)
+	CreateResetPasswordToken(ctx context.Context, userID uuid.UUID) (string, error)
+}
+
+type jobInserter interface {
+	Insert(ctx context.Context, args river.JobArgs, opts *river.InsertOpts) (*rivertype.JobRow, error)
+}
+
+type Deps struct {
+	// Environment is the one of config.App, seeding is refused in production
+	Environment string
+	// Sender is the address the seeded emails are from
+	Sender string
+	DB     psql.Postgres
+	Hasher passwordHasher
+	Tokens tokenCreator
+	Jobs   jobInserter
+}
+
+// Result is what a seed created, with the tokens in plain text so they can
+// be used to verify an email or reset a password in development.
+type Result struct {
+	AlreadySeeded          bool
+	Users                  []models.User
+	EmailVerificationToken string
+	PasswordResetToken     string
+	Jobs                   []*rivertype.JobRow
+}
+
+/*
+Run creates an admin, a verified and an unverified user along with generated
+users, tokens for them and a few email jobs. Every user has factory.Password
+as the password. The data is the same on every run, and a database that has
+the admin already is left as it is, so running it twice is harmless.
+*/
+func Run(ctx context.Context, deps Deps) (Result, error) {
+	if deps.Environment == config.PROD_ENVIRONMENT {
+		return Result{}, ErrProduction
+	}
+
+	_, err := deps.DB.QueryUserByEmail(ctx, AdminEmail)
+	if err == nil {
+		return Result{AlreadySeeded: true}, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return Result{}, err
+	}
+
+	hashedPassword, err := deps.Hasher.HashAndPepperPassword(factory.Password)
+	if err != nil {
+		return Result{}, err
+	}
+
+	f := factory.New(factorySeed)
+	users := []models.User{
+		f.User(factory.WithName("Admin"), factory.WithEmail(AdminEmail), factory.Admin(), factory.Verified()),
+		f.User(factory.WithEmail(VerifiedEmail), factory.Verified()),
+		f.User(factory.WithEmail(UnverifiedEmail)),
+	}
+	for i := range generatedUsers {
+		// every third generated user has not verified the email yet
+		if i%3 == 0 {
+			users = append(users, f.User())
+		} else {
+			users = append(users, f.User(factory.Verified()))
+		}
+	}
+
+	var result Result
+	if err := deps.DB.WithTx(ctx, func(tx psql.Postgres) error {
+		for _, user := range users {
+			if _, err := tx.InsertUser(ctx, user, hashedPassword); err != nil {
+				return fmt.Errorf("could not insert %s: %w", user.Email, err)
+			}
+			if user.IsVerified() {
+				if err := tx.VerifyUserEmail(ctx, user.EmailVerifiedAt, user.Email); err != nil {
+					return err
+				}
+			}
+			if user.IsAdmin {
+				if err := tx.SetUserAdmin(ctx, user.ID, true, user.UpdatedAt); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return Result{}, err
+	}
+	result.Users = users
+
+	result.EmailVerificationToken, err = deps.Tokens.CreateUserEmailVerification(ctx, users[2].ID)
+	if err != nil {
+		return Result{}, err
+	}
+	result.PasswordResetToken, err = deps.Tokens.CreateResetPasswordToken(ctx, users[1].ID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// the emails are due in a day, so they show up in the admin without
+	// being sent right away
+	scheduledAt := time.Now().Add(24 * time.Hour)
+	for _, user := range users[:3] {
+		job, err := deps.Jobs.Insert(ctx, f.EmailJob(user, deps.Sender), &river.InsertOpts{
+			ScheduledAt: scheduledAt,
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		result.Jobs = append(result.Jobs, job)
+	}
+
+	return result, nil
+}
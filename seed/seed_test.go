@@ -0,0 +1,20 @@
+package seed_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/seed"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefusedInProduction(t *testing.T) {
+	// without a database, so seeding panics if it gets past the check
+	result, err := seed.Run(context.Background(), seed.Deps{
+		Environment: config.PROD_ENVIRONMENT,
+	})
+
+	assert.ErrorIs(t, err, seed.ErrProduction)
+	assert.Empty(t, result.Users)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"encoding/gob"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -16,8 +17,15 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// the session stores the id of the user, which gob can only encode in the
+// values of the session once the type is registered
+func init() {
+	gob.Register(uuid.UUID{})
+}
+
 type authStorage interface {
 	QueryUserByEmail(ctx context.Context, mail string) (models.User, error)
+	QueryUserByID(ctx context.Context, id uuid.UUID) (models.User, error)
 }
 
 type Auth struct {
@@ -31,6 +39,9 @@ type UserSession struct {
 	ID            uuid.UUID
 	Authenticated bool
 	IsAdmin       bool
+	// Locale is the one saved on the user when the session began, empty for
+	// sessions that began before it was stored
+	Locale string
 }
 
 func NewAuth(
@@ -107,6 +118,12 @@ func (a Auth) NewUserSession(
 		return UserSession{}, err
 	}
 
+	user, err := a.storage.QueryUserByID(req.Context(), userID)
+	if err != nil {
+		slog.ErrorContext(req.Context(), "could not query user for session", "error", err)
+		return UserSession{}, err
+	}
+
 	session.Options.HttpOnly = true
 	session.Options.Domain = a.cfg.AppDomain
 	session.Options.Secure = true
@@ -114,7 +131,8 @@ func (a Auth) NewUserSession(
 
 	session.Values["user_id"] = userID
 	session.Values["authenticated"] = true
-	session.Values["is_admin"] = false
+	session.Values["is_admin"] = user.IsAdmin
+	session.Values["locale"] = user.Locale
 
 	if err := session.Save(req, res); err != nil {
 		return UserSession{}, err
@@ -123,7 +141,8 @@ func (a Auth) NewUserSession(
 	return UserSession{
 		ID:            userID,
 		Authenticated: true,
-		IsAdmin:       false,
+		IsAdmin:       user.IsAdmin,
+		Locale:        user.Locale,
 	}, nil
 }
 
@@ -148,9 +167,12 @@ func (a Auth) GetUserSession(req *http.Request) (UserSession, error) {
 		return UserSession{}, err
 	}
 
+	locale, _ := session.Values["locale"].(string)
+
 	return UserSession{
 		ID:            userID,
 		Authenticated: isAuthenticated,
 		IsAdmin:       isAdmin,
+		Locale:        locale,
 	}, nil
 }
@@ -2,22 +2,62 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/pkg/i18n"
 	"github.com/mbvlabs/grafto/queue/jobs"
 	"github.com/mbvlabs/grafto/views/emails"
 	"github.com/riverqueue/river"
 	"github.com/riverqueue/river/rivertype"
 )
 
+const (
+	// attachments above this size are stored in the database instead of being
+	// serialized into the job args
+	inlineAttachmentLimit = 64 * 1024
+	// an email from a template is queued once per recipient within this
+	// window, so a form that is submitted twice sends a single email
+	emailIdempotencyWindow = 2 * time.Minute
+)
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
 type EmailPayload struct {
-	To       string
-	From     string
-	Subject  string
-	HtmlBody string
-	TextBody string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	ReplyTo     []string
+	From        string
+	Subject     string
+	HtmlBody    string
+	TextBody    string
+	Headers     map[string]string
+	Attachments []Attachment
+	// Bulk marks mail like newsletters that, when queued, is sent after
+	// transactional mail, see jobs.EmailLane
+	Bulk bool
+}
+
+// Recipients returns every address the email should be delivered to,
+// including the ones that are not visible in the headers.
+func (p EmailPayload) Recipients() []string {
+	recipients := make([]string, 0, len(p.To)+len(p.Cc)+len(p.Bcc))
+	recipients = append(recipients, p.To...)
+	recipients = append(recipients, p.Cc...)
+	recipients = append(recipients, p.Bcc...)
+
+	return recipients
 }
 
 type EmailClient interface {
@@ -30,23 +70,83 @@ type QueueClient interface {
 		args river.JobArgs,
 		opts *river.InsertOpts,
 	) (*rivertype.JobRow, error)
+	InsertTx(
+		ctx context.Context,
+		tx pgx.Tx,
+		args river.JobArgs,
+		opts *river.InsertOpts,
+	) (*rivertype.JobRow, error)
+}
+
+type emailStorage interface {
+	InsertEmailAttachment(
+		ctx context.Context,
+		id uuid.UUID,
+		filename string,
+		contentType string,
+		content []byte,
+	) error
+	SetEmailAttachmentsJob(ctx context.Context, jobID int64, ids []uuid.UUID) error
+}
+
+// emailTxStorage is the storage of an ongoing transaction, see Email.WithTx.
+type emailTxStorage interface {
+	emailStorage
+	Tx() pgx.Tx
+}
+
+// emailTransactor is storage that runs fn in a transaction and hands it the
+// storage of the transaction, like psql.Postgres.WithTx does.
+type emailTransactor[S emailTxStorage] interface {
+	emailStorage
+	WithTx(ctx context.Context, fn func(tx S) error) error
 }
 
 type Email struct {
 	cfg         config.Config
 	client      EmailClient
 	queueClient QueueClient
+	storage     emailStorage
+	tx          pgx.Tx
+	// withTx runs fn in a transaction of the storage the service was created
+	// with
+	withTx func(ctx context.Context, fn func(tx emailTxStorage) error) error
 }
 
-func NewEmailSvc(
+func NewEmailSvc[S emailTxStorage](
 	cfg config.Config,
 	client EmailClient,
 	queueClient QueueClient,
+	storage emailTransactor[S],
 ) Email {
 	return Email{
 		cfg,
 		client,
 		queueClient,
+		storage,
+		nil,
+		func(ctx context.Context, fn func(tx emailTxStorage) error) error {
+			return storage.WithTx(ctx, func(tx S) error {
+				return fn(tx)
+			})
+		},
+	}
+}
+
+/*
+WithTx returns a copy of the service that stores attachments and enqueues jobs
+in the transaction of storage, see psql.Postgres.WithTx. The job only becomes
+visible to the workers once the transaction commits, and disappears with it
+on a rollback. Emails sent directly, without the queue, are not affected.
+*/
+func (e *Email) WithTx(storage emailTxStorage) *Email {
+	return &Email{
+		e.cfg,
+		e.client,
+		e.queueClient,
+		storage,
+		storage.Tx(),
+		e.withTx,
 	}
 }
 
@@ -56,118 +156,255 @@ func (e *Email) SendUserSignupWelcome(
 	activationTkn string,
 	putOnQueue bool,
 ) error {
-	newsletterEmail := emails.UserSignupWelcome{
+	return e.sendTemplate(ctx, email, &emails.UserSignupWelcome{
 		ConfirmationLink: fmt.Sprintf(
 			"%s/verify-email?token=%s",
 			e.cfg.GetFullDomain(),
 			activationTkn,
 		),
+		Locale: i18n.FromContext(ctx).Locale().String(),
+	}, putOnQueue)
+}
+
+func (e *Email) SendPasswordReset(
+	ctx context.Context,
+	email string,
+	resetTkn string,
+	putOnQueue bool,
+) error {
+	return e.sendTemplate(ctx, email, &emails.PasswordReset{
+		ResetPasswordLink: fmt.Sprintf(
+			"%s/reset-password?token=%s",
+			e.cfg.GetFullDomain(),
+			resetTkn,
+		),
+		Locale: i18n.FromContext(ctx).Locale().String(),
+	}, putOnQueue)
+}
+
+/*
+sendTemplate renders the email and delivers it, unless WorkerRendersEmails is
+set and the email is queued, in which case only the template name and props
+are queued and the worker renders the email, see RenderTemplatedEmail.
+*/
+func (e *Email) sendTemplate(
+	ctx context.Context,
+	to string,
+	props emails.TemplateHandler,
+	putOnQueue bool,
+) error {
+	idempotency := &jobs.Idempotency{
+		Key:    props.TemplateName() + ":" + to,
+		Window: emailIdempotencyWindow,
 	}
 
-	textVersion, err := newsletterEmail.GenerateTextVersion()
-	if err != nil {
-		slog.ErrorContext(
-			ctx,
-			"could not generate text version of UserSignupWelcomeEmail",
-			"error",
-			err,
-		)
+	if putOnQueue && e.cfg.WorkerRendersEmails {
+		encodedProps, err := json.Marshal(props)
+		if err != nil {
+			return err
+		}
+
+		_, err = e.enqueue(ctx, jobs.TemplatedEmailJobArgs{
+			To:          jobs.Recipients{to},
+			From:        e.cfg.App.DefaultSenderSignature,
+			Template:    props.TemplateName(),
+			Props:       encodedProps,
+			Idempotency: idempotency,
+		})
 		return err
 	}
 
-	htmlVersion, err := newsletterEmail.GenerateHtmlVersion()
+	payload, err := RenderEmail(props)
 	if err != nil {
 		slog.ErrorContext(
 			ctx,
-			"could not generate html version of UserSignupWelcomeEmail",
+			"could not render email",
 			"error",
 			err,
+			"template",
+			props.TemplateName(),
 		)
 		return err
 	}
+	payload.To = []string{to}
+	payload.From = e.cfg.App.DefaultSenderSignature
 
-	subject := "Grafto | Action Required"
+	return e.deliver(ctx, payload, putOnQueue, idempotency)
+}
 
-	if putOnQueue {
-		_, err := e.queueClient.Insert(ctx, jobs.EmailJobArgs{
-			To:          email,
-			From:        e.cfg.App.DefaultSenderSignature,
-			Subject:     subject,
-			TextVersion: textVersion,
-			HtmlVersion: htmlVersion,
-		}, nil)
-		if err != nil {
-			return err
-		}
+// RenderEmail returns a payload with the subject, html and text of the email
+// described by props.
+func RenderEmail(props emails.TemplateHandler) (EmailPayload, error) {
+	textVersion, err := props.GenerateTextVersion()
+	if err != nil {
+		return EmailPayload{}, fmt.Errorf("could not generate text version of %s: %w", props.TemplateName(), err)
+	}
 
-		return nil
+	htmlVersion, err := props.GenerateHtmlVersion()
+	if err != nil {
+		return EmailPayload{}, fmt.Errorf("could not generate html version of %s: %w", props.TemplateName(), err)
 	}
 
-	return e.client.SendEmail(ctx, EmailPayload{
-		To:       email,
-		From:     e.cfg.App.DefaultSenderSignature,
-		Subject:  subject,
+	return EmailPayload{
+		Subject:  props.Subject(),
 		HtmlBody: htmlVersion,
 		TextBody: textVersion,
-	})
+	}, nil
 }
 
-func (e *Email) SendPasswordReset(
+// RenderTemplatedEmail renders the email of a templated email job into a
+// payload ready to be sent.
+func RenderTemplatedEmail(args jobs.TemplatedEmailJobArgs) (EmailPayload, error) {
+	props, err := emails.New(args.Template)
+	if err != nil {
+		return EmailPayload{}, err
+	}
+
+	if err := json.Unmarshal(args.Props, props); err != nil {
+		return EmailPayload{}, fmt.Errorf("could not decode props of %s: %w", args.Template, err)
+	}
+
+	payload, err := RenderEmail(props)
+	if err != nil {
+		return EmailPayload{}, err
+	}
+
+	payload.To = args.To
+	payload.Cc = args.Cc
+	payload.Bcc = args.Bcc
+	payload.ReplyTo = args.ReplyTo
+	payload.From = args.From
+	payload.Headers = args.Headers
+
+	return payload, nil
+}
+
+/*
+Deliver sends the payload right away or puts it on the queue. Attachments
+larger than inlineAttachmentLimit are stored in the database when queued so the
+job args stay small; the worker loads and removes them again. They are stored
+in the same transaction as the job, and the ones of jobs that are never sent
+are purged, see jobs.PurgeEmailAttachmentsJobArgs.
+*/
+func (e *Email) Deliver(
 	ctx context.Context,
-	email string,
-	resetLink string,
+	payload EmailPayload,
 	putOnQueue bool,
 ) error {
-	newsletterEmail := emails.PasswordReset{
-		ResetPasswordLink: resetLink,
+	return e.deliver(ctx, payload, putOnQueue, nil)
+}
+
+func (e *Email) deliver(
+	ctx context.Context,
+	payload EmailPayload,
+	putOnQueue bool,
+	idempotency *jobs.Idempotency,
+) error {
+	if !putOnQueue {
+		return e.client.SendEmail(ctx, payload)
 	}
 
-	textVersion, err := newsletterEmail.GenerateTextVersion()
-	if err != nil {
-		slog.ErrorContext(
-			ctx,
-			"could not generate text version of PasswordReset",
-			"error",
-			err,
-		)
-		return err
+	// attachments that are stored must be committed along with the job that
+	// sends them, or they are left behind when the job is not inserted
+	if e.tx == nil && hasStoredAttachments(payload.Attachments) {
+		return e.withTx(ctx, func(tx emailTxStorage) error {
+			return e.WithTx(tx).deliver(ctx, payload, putOnQueue, idempotency)
+		})
 	}
 
-	htmlVersion, err := newsletterEmail.GenerateHtmlVersion()
-	if err != nil {
-		slog.ErrorContext(
+	var storedIDs []uuid.UUID
+	attachments := make([]jobs.EmailAttachment, 0, len(payload.Attachments))
+	for _, attachment := range payload.Attachments {
+		if len(attachment.Content) <= inlineAttachmentLimit {
+			attachments = append(attachments, jobs.EmailAttachment{
+				Filename:    attachment.Filename,
+				ContentType: attachment.ContentType,
+				Content:     attachment.Content,
+			})
+			continue
+		}
+
+		id := uuid.New()
+		if err := e.storage.InsertEmailAttachment(
 			ctx,
-			"could not generate html version of PasswordReset",
-			"error",
-			err,
-		)
+			id,
+			attachment.Filename,
+			attachment.ContentType,
+			attachment.Content,
+		); err != nil {
+			slog.ErrorContext(
+				ctx,
+				"could not store email attachment",
+				"error",
+				err,
+				"filename",
+				attachment.Filename,
+			)
+			return err
+		}
+
+		attachments = append(attachments, jobs.EmailAttachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			StoredID:    &id,
+		})
+		storedIDs = append(storedIDs, id)
+	}
+
+	args := jobs.EmailJobArgs{
+		To:          payload.To,
+		Cc:          payload.Cc,
+		Bcc:         payload.Bcc,
+		ReplyTo:     payload.ReplyTo,
+		From:        payload.From,
+		Subject:     payload.Subject,
+		TextVersion: payload.TextBody,
+		HtmlVersion: payload.HtmlBody,
+		Headers:     payload.Headers,
+		Attachments: attachments,
+		Lane:        emailLane(payload.Bulk),
+		Idempotency: idempotency,
+	}
+
+	job, err := e.enqueue(ctx, args)
+	if err != nil || job == nil || len(storedIDs) == 0 {
+		// the attachments of a duplicate belong to no job and are purged
+		// along with other orphans, see jobs.PurgeEmailAttachmentsJobArgs
 		return err
 	}
 
-	subject := "Grafto | Reset Password Request"
+	return e.storage.SetEmailAttachmentsJob(ctx, job.ID, storedIDs)
+}
 
-	if putOnQueue {
-		_, err := e.queueClient.Insert(ctx, jobs.EmailJobArgs{
-			To:          email,
-			From:        e.cfg.App.DefaultSenderSignature,
-			Subject:     subject,
-			TextVersion: textVersion,
-			HtmlVersion: htmlVersion,
-		}, nil)
-		if err != nil {
-			return err
+func hasStoredAttachments(attachments []Attachment) bool {
+	for _, attachment := range attachments {
+		if len(attachment.Content) > inlineAttachmentLimit {
+			return true
 		}
+	}
+
+	return false
+}
 
-		return nil
+// enqueue inserts the job, treating a duplicate of an email that is already
+// queued as sent, in which case no job is returned.
+func (e *Email) enqueue(ctx context.Context, args river.JobArgs) (*rivertype.JobRow, error) {
+	var (
+		job *rivertype.JobRow
+		err error
+	)
+	if e.tx != nil {
+		job, err = e.queueClient.InsertTx(ctx, e.tx, args, nil)
+	} else {
+		job, err = e.queueClient.Insert(ctx, args, nil)
 	}
 
-	return e.client.SendEmail(ctx, EmailPayload{
-		To:       email,
-		From:     e.cfg.App.DefaultSenderSignature,
-		Subject:  subject,
-		HtmlBody: htmlVersion,
-		TextBody: textVersion,
-	})
+	if errors.Is(err, jobs.ErrDuplicate) {
+		slog.InfoContext(ctx, "email is already queued, skipping duplicate", "kind", args.Kind())
+		return nil, nil
+	}
+
+	return job, err
 }
 
 func (e *Email) Send(
@@ -179,10 +416,18 @@ func (e *Email) Send(
 	htmlVersion string,
 ) error {
 	return e.client.SendEmail(ctx, EmailPayload{
-		To:       to,
+		To:       []string{to},
 		From:     from,
 		Subject:  subject,
 		HtmlBody: htmlVersion,
 		TextBody: textVersion,
 	})
 }
+
+func emailLane(bulk bool) jobs.EmailLane {
+	if bulk {
+		return jobs.EmailLaneBulk
+	}
+
+	return jobs.EmailLaneTransactional
+}
@@ -0,0 +1,56 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/psql/psqltest"
+	"github.com/mbvlabs/grafto/queue"
+	"github.com/mbvlabs/grafto/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliverStoresAttachmentsWithTheirJob(t *testing.T) {
+	db := psqltest.DB(t)
+	ctx := context.Background()
+
+	emailSvc := services.NewEmailSvc(config.Config{}, nil, queue.NewClient(psqltest.Pool(t)), db)
+	err := emailSvc.Deliver(ctx, services.EmailPayload{
+		To:       []string{"jon@stark.com"},
+		From:     "ned@stark.com",
+		Subject:  "Your invoice",
+		TextBody: "Find your invoice attached.",
+		Attachments: []services.Attachment{
+			{
+				Filename:    "invoice.pdf",
+				ContentType: "application/pdf",
+				Content:     bytes.Repeat([]byte{1}, 128*1024),
+			},
+		},
+	}, true)
+	assert.NoError(t, err)
+
+	orphan := uuid.New()
+	assert.NoError(t, db.InsertEmailAttachment(ctx, orphan, "orphan.pdf", "application/pdf", []byte{1}))
+
+	// every attachment is older than this, so only whether it belongs to a
+	// job decides if it is purged
+	purged, err := db.DeleteOrphanedEmailAttachments(ctx, pgtype.Timestamptz{
+		Time:  time.Now().Add(time.Hour),
+		Valid: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged, "only the attachment without a job is purged")
+
+	var jobID int64
+	assert.NoError(t, db.Tx().QueryRow(
+		ctx,
+		"select job_id from email_attachments join river_job on river_job.id = email_attachments.job_id",
+	).Scan(&jobID))
+	assert.NotZero(t, jobID)
+}
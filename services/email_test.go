@@ -0,0 +1,120 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mbvlabs/grafto/config"
+	"github.com/mbvlabs/grafto/pkg/i18n"
+	"github.com/mbvlabs/grafto/psql"
+	"github.com/mbvlabs/grafto/queue/jobs"
+	"github.com/mbvlabs/grafto/services"
+	"github.com/mbvlabs/grafto/views/emails"
+	"github.com/riverqueue/river"
+	"github.com/riverqueue/river/rivertype"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeQueue struct {
+	inserted []river.JobArgs
+}
+
+func (f *fakeQueue) Insert(
+	ctx context.Context,
+	args river.JobArgs,
+	opts *river.InsertOpts,
+) (*rivertype.JobRow, error) {
+	f.inserted = append(f.inserted, args)
+	return &rivertype.JobRow{}, nil
+}
+
+func (f *fakeQueue) InsertTx(
+	ctx context.Context,
+	tx pgx.Tx,
+	args river.JobArgs,
+	opts *river.InsertOpts,
+) (*rivertype.JobRow, error) {
+	return f.Insert(ctx, args, opts)
+}
+
+// roundTrip encodes and decodes args the way they are stored in river_job
+func roundTrip[T river.JobArgs](t *testing.T, args river.JobArgs) T {
+	t.Helper()
+
+	encoded, err := json.Marshal(args)
+	assert.NoError(t, err)
+
+	var decoded T
+	assert.NoError(t, json.Unmarshal(encoded, &decoded))
+
+	return decoded
+}
+
+/*
+TestTemplatedEmailsMatchRenderedEmails checks that an email rendered by the
+worker from a templated email job is the same as the one rendered up front
+into an email job, for as long as both job kinds are in use.
+*/
+func TestTemplatedEmailsMatchRenderedEmails(t *testing.T) {
+	send := map[string]func(svc *services.Email, ctx context.Context) error{
+		"user_signup_welcome": func(svc *services.Email, ctx context.Context) error {
+			return svc.SendUserSignupWelcome(ctx, "jon@stark.com", "activation-token", true)
+		},
+		"password_reset": func(svc *services.Email, ctx context.Context) error {
+			return svc.SendPasswordReset(ctx, "jon@stark.com", "reset-token", true)
+		},
+	}
+
+	for name, sendEmail := range send {
+		for _, locale := range []string{"en", "da"} {
+			t.Run(name+"/"+locale, func(t *testing.T) {
+				ctx := i18n.WithTranslator(context.Background(), i18n.NewTranslator(locale))
+
+				cfg := config.Config{
+					App: config.App{
+						AppProtocol:            "https",
+						AppDomain:              "grafto.com",
+						DefaultSenderSignature: "info@grafto.com",
+					},
+				}
+
+				renderedQueue := &fakeQueue{}
+				renderedSvc := services.NewEmailSvc(cfg, nil, renderedQueue, psql.NewPostgres(nil))
+				assert.NoError(t, sendEmail(&renderedSvc, ctx))
+
+				cfg.WorkerRendersEmails = true
+				templatedQueue := &fakeQueue{}
+				templatedSvc := services.NewEmailSvc(cfg, nil, templatedQueue, psql.NewPostgres(nil))
+				assert.NoError(t, sendEmail(&templatedSvc, ctx))
+
+				assert.Len(t, renderedQueue.inserted, 1)
+				assert.Len(t, templatedQueue.inserted, 1)
+
+				rendered := roundTrip[jobs.EmailJobArgs](t, renderedQueue.inserted[0])
+				templated := roundTrip[jobs.TemplatedEmailJobArgs](t, templatedQueue.inserted[0])
+				assert.Equal(t, name, templated.Template)
+
+				payload, err := services.RenderTemplatedEmail(templated)
+				assert.NoError(t, err)
+
+				assert.Equal(t, []string(rendered.To), payload.To)
+				assert.Equal(t, rendered.From, payload.From)
+				assert.NotContains(t, payload.Subject, "emails.", "subject is not translated")
+				assert.Equal(t, rendered.Subject, payload.Subject)
+				assert.Equal(t, rendered.TextVersion, payload.TextBody)
+				assert.Equal(t, rendered.HtmlVersion, payload.HtmlBody)
+			})
+		}
+	}
+}
+
+func TestRenderTemplatedEmailUnknownTemplate(t *testing.T) {
+	_, err := services.RenderTemplatedEmail(jobs.TemplatedEmailJobArgs{
+		Template: "newsletter",
+		Props:    json.RawMessage(`{}`),
+	})
+
+	assert.ErrorIs(t, err, emails.ErrUnknownTemplate)
+}
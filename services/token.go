@@ -8,7 +8,6 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
-	"hash"
 	"log/slog"
 	"time"
 
@@ -46,19 +45,26 @@ type tokenServiceStorage interface {
 }
 
 type Token struct {
-	storage tokenServiceStorage
-	hasher  hash.Hash
+	storage    tokenServiceStorage
+	signingKey []byte
 }
 
 func NewTokenSvc(
 	storage tokenServiceStorage,
 	tokenSigningKey string,
 ) *Token {
-	h := hmac.New(sha256.New, []byte(tokenSigningKey))
+	return &Token{
+		storage,
+		[]byte(tokenSigningKey),
+	}
+}
 
+// WithTx returns a copy of the service that uses the storage of an ongoing
+// transaction, see psql.Postgres.WithTx.
+func (svc *Token) WithTx(storage tokenServiceStorage) *Token {
 	return &Token{
 		storage,
-		h,
+		svc.signingKey,
 	}
 }
 
@@ -83,10 +89,12 @@ func (svc *Token) create() (tokenPair, error) {
 	}, nil
 }
 
+// a new hmac is created per call as hash.Hash is not safe for concurrent use
+// and the service is shared between requests
 func (svc *Token) hash(token string) string {
-	svc.hasher.Reset()
-	svc.hasher.Write([]byte(token))
-	b := svc.hasher.Sum(nil)
+	hasher := hmac.New(sha256.New, svc.signingKey)
+	hasher.Write([]byte(token))
+	b := hasher.Sum(nil)
 
 	return base64.URLEncoding.EncodeToString(b)
 }
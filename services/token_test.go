@@ -0,0 +1,44 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mbvlabs/grafto/factory"
+	"github.com/mbvlabs/grafto/psql/psqltest"
+	"github.com/mbvlabs/grafto/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenLifecycle(t *testing.T) {
+	db := psqltest.DB(t)
+	ctx := context.Background()
+	user := factory.New(1).User()
+	if _, err := db.InsertUser(ctx, user, "hashed"); err != nil {
+		t.Fatalf("could not insert user: %v", err)
+	}
+
+	tokenSvc := services.NewTokenSvc(db, "token-signing-key")
+
+	token, err := tokenSvc.CreateUserEmailVerification(ctx, user.ID)
+	assert.NoError(t, err)
+
+	assert.NoError(t, tokenSvc.Validate(ctx, token, services.ScopeEmailVerification))
+	assert.ErrorIs(
+		t,
+		tokenSvc.Validate(ctx, token, services.ScopeResetPassword),
+		services.ErrTokenScopeInvalid,
+	)
+	assert.NoError(t, tokenSvc.IsExpired(ctx, token))
+
+	userID, err := tokenSvc.GetAssociatedUserID(ctx, token)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, userID)
+
+	assert.NoError(t, tokenSvc.Delete(ctx, token))
+	assert.ErrorIs(
+		t,
+		tokenSvc.Validate(ctx, token, services.ScopeEmailVerification),
+		services.ErrTokenNotExist,
+	)
+}
@@ -3,17 +3,114 @@ package emails
 import (
 	"context"
 	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/mbvlabs/grafto/pkg/i18n"
 )
 
 //go:embed *.txt
 var TextTemplates embed.FS
 
-//go:embed *_templ.go
-var HtmlTemplates embed.FS
+var ErrUnknownTemplate = errors.New("no email template registered with that name")
 
 type TemplateHandler interface {
+	// TemplateName is the name the email is registered under, see New
+	TemplateName() string
+	Subject() string
 	GenerateTextVersion() (string, error)
 	GenerateHtmlVersion() (string, error)
 	Render(ctx context.Context, w io.Writer) error
 }
+
+var registry = map[string]func() TemplateHandler{}
+
+// register makes an email discoverable by tools such as the preview server.
+// newProps must return a pointer so fixtures can be unmarshalled into it.
+func register(name string, newProps func() TemplateHandler) {
+	registry[name] = newProps
+}
+
+// Templates returns the name of every registered email, sorted.
+func Templates() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// New returns empty props for the named email.
+func New(name string) (TemplateHandler, error) {
+	newProps, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	return newProps(), nil
+}
+
+type Fixture struct {
+	Name  string
+	Props TemplateHandler
+}
+
+/*
+LoadFixtures reads the sample props for an email from <name>/*.json in fsys.
+Every file is one fixture named after the file.
+*/
+func LoadFixtures(fsys fs.FS, name string) ([]Fixture, error) {
+	files, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures []Fixture
+	for _, f := range files {
+		fixtureName, ok := strings.CutSuffix(f.Name(), ".json")
+		if !ok || f.IsDir() {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(name, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		props, err := New(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(content, props); err != nil {
+			return nil, fmt.Errorf("fixture %s/%s: %w", name, f.Name(), err)
+		}
+
+		fixtures = append(fixtures, Fixture{fixtureName, props})
+	}
+
+	return fixtures, nil
+}
+
+// parseTextTemplate parses the text version of an email with a 't' function
+// that looks up translations for the provided locale.
+func parseTextTemplate(name, locale string) (*template.Template, error) {
+	fileName := fmt.Sprintf("%s.txt", name)
+
+	return template.New(fileName).
+		Funcs(i18n.NewTranslator(locale).FuncMap()).
+		ParseFS(TextTemplates, fileName)
+}
+
+func localizedContext(locale string) context.Context {
+	return i18n.WithTranslator(context.Background(), i18n.NewTranslator(locale))
+}
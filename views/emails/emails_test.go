@@ -0,0 +1,150 @@
+package emails_test
+
+import (
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mbvlabs/grafto/views/emails"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+/*
+TestEmailsGolden renders every registered email with each of its fixtures
+and compares the output with testdata/golden. Run with -update after an
+intended change to the templates.
+*/
+func TestEmailsGolden(t *testing.T) {
+	fixturesFS := os.DirFS("fixtures")
+
+	for _, name := range emails.Templates() {
+		fixtures, err := emails.LoadFixtures(fixturesFS, name)
+		if err != nil {
+			t.Fatalf("could not load fixtures for %s: %v", name, err)
+		}
+
+		if len(fixtures) == 0 {
+			t.Errorf("email %s has no fixtures in fixtures/%s", name, name)
+		}
+
+		for _, fixture := range fixtures {
+			t.Run(name+"/"+fixture.Name, func(t *testing.T) {
+				html, err := fixture.Props.GenerateHtmlVersion()
+				if err != nil {
+					t.Fatalf("could not generate html version: %v", err)
+				}
+
+				text, err := fixture.Props.GenerateTextVersion()
+				if err != nil {
+					t.Fatalf("could not generate text version: %v", err)
+				}
+
+				compareGolden(t, filepath.Join(name, fixture.Name+".html"), html)
+				compareGolden(t, filepath.Join(name, fixture.Name+".txt"), text)
+			})
+		}
+	}
+}
+
+func compareGolden(t *testing.T, file string, actual string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "golden", file)
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("could not create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(actual), 0o644); err != nil {
+			t.Fatalf("could not write golden file: %v", err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read golden file, run with -update to create it: %v", err)
+	}
+
+	assert.Equal(t, string(expected), actual, "output differs from %s", path)
+}
+
+/*
+TestEmailsRegistered cross-checks the emails of the package against the
+registry, so an email that is not registered, and would have neither a preview
+nor golden files, fails here. Every type with a TemplateName method must be
+registered, and every text template must belong to a registered email.
+*/
+func TestEmailsRegistered(t *testing.T) {
+	registered := map[string]string{}
+	for _, name := range emails.Templates() {
+		props, err := emails.New(name)
+		assert.NoError(t, err)
+		assert.Equal(t, name, props.TemplateName())
+
+		registered[reflect.TypeOf(props).Elem().Name()] = name
+	}
+
+	for _, handler := range templateHandlers(t) {
+		assert.Contains(t, registered, handler, "%s is not registered, see register", handler)
+	}
+
+	textTemplates, err := fs.Glob(emails.TextTemplates, "*.txt")
+	assert.NoError(t, err)
+	assert.Len(t, textTemplates, len(registered))
+	for _, textTemplate := range textTemplates {
+		name := strings.TrimSuffix(textTemplate, ".txt")
+		assert.Contains(t, emails.Templates(), name, "%s belongs to no registered email", textTemplate)
+	}
+}
+
+// templateHandlers returns the name of every type in the package that has a
+// TemplateName method, read from the source so unregistered ones are found.
+func templateHandlers(t *testing.T) []string {
+	t.Helper()
+
+	files, err := filepath.Glob("*.go")
+	assert.NoError(t, err)
+
+	var handlers []string
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		parsed, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			t.Fatalf("could not parse %s: %v", file, err)
+		}
+
+		for _, decl := range parsed.Decls {
+			method, ok := decl.(*ast.FuncDecl)
+			if !ok || method.Recv == nil || method.Name.Name != "TemplateName" {
+				continue
+			}
+
+			receiver := method.Recv.List[0].Type
+			if star, ok := receiver.(*ast.StarExpr); ok {
+				receiver = star.X
+			}
+			if ident, ok := receiver.(*ast.Ident); ok {
+				handlers = append(handlers, ident.Name)
+			}
+		}
+	}
+
+	if len(handlers) == 0 {
+		t.Fatal("found no emails in the package")
+	}
+
+	return handlers
+}
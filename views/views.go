@@ -9,6 +9,11 @@ import (
 )
 
 func setUserCtx(ctx echo.Context) context.Context {
+	// admin routes wrap the user context once more
+	if adminCtx, ok := ctx.(*middleware.AdminContext); ok {
+		ctx = adminCtx.Context
+	}
+
 	userCtx := ctx.(*middleware.UserContext)
 	return context.WithValue(ctx.Request().Context(), middleware.UserContext{}, userCtx)
 }